@@ -0,0 +1,398 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+type Status int
+
+const (
+	StatusRunning Status = iota
+	StatusFinished
+	StatusStopped
+	StatusFailed
+)
+
+// maxOutput is how many lines of tcpdump messages a session keeps.
+const maxOutput = 200
+
+// shutdownGrace is how long a capture has to clean up when d4s exits.
+const shutdownGrace = 5 * time.Second
+
+// RunFunc captures into w until ctx is done or the capture ends by itself,
+// passing the messages of the capture tool to status.
+type RunFunc func(ctx context.Context, w io.Writer, status func(string)) error
+
+// Session is a packet capture of a container written to a local pcap
+// file, running in the background for as long as d4s runs.
+type Session struct {
+	ID          string
+	ContextName string
+	Container   string
+	Filter      string
+	Limit       string
+	Path        string
+	CreatedAt   time.Time
+
+	state *state
+}
+
+type state struct {
+	mu      sync.Mutex
+	done    bool
+	stopped bool
+	err     error
+	output  []string
+	pcap    pcapCounter
+	cancel  context.CancelFunc
+	doneCh  chan struct{}
+}
+
+func (s Session) GetID() string { return s.ID }
+
+func (s Session) Status() Status {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	switch {
+	case !s.state.done:
+		return StatusRunning
+	case s.state.err != nil:
+		return StatusFailed
+	case s.state.stopped:
+		return StatusStopped
+	}
+	return StatusFinished
+}
+
+// StatusText is "capturing", "finished", "stopped" or why the capture failed.
+func (s Session) StatusText() string {
+	switch s.Status() {
+	case StatusRunning:
+		return "capturing"
+	case StatusFailed:
+		s.state.mu.Lock()
+		defer s.state.mu.Unlock()
+		return fmt.Sprintf("failed: %v", s.state.err)
+	case StatusStopped:
+		return "stopped"
+	}
+	return "finished"
+}
+
+// Packets and Size are what the capture file holds so far.
+func (s Session) Packets() int64 {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return s.state.pcap.packets
+}
+
+func (s Session) Size() int64 {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return s.state.pcap.bytes
+}
+
+// Output returns the messages of tcpdump.
+func (s Session) Output() []string {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	return append([]string(nil), s.state.output...)
+}
+
+func (s Session) GetCells() []string {
+	status := "●"
+	if s.Status() != StatusRunning {
+		status = "○"
+	}
+	return []string{
+		status,
+		s.ContextName,
+		s.Container,
+		common.Dash(s.Filter),
+		common.Dash(s.Limit),
+		s.StatusText(),
+		strconv.FormatInt(s.Packets(), 10),
+		common.FormatBytes(s.Size()),
+		filepath.Base(s.Path),
+		common.FormatAge(s.CreatedAt),
+	}
+}
+
+func (s Session) GetStatusColor() (tcell.Color, tcell.Color) {
+	switch s.Status() {
+	case StatusRunning:
+		return styles.ColorInfo, styles.ColorBlack
+	case StatusFailed:
+		return styles.ColorError, styles.ColorBlack
+	}
+	return styles.ColorStatusGray, styles.ColorBlack
+}
+
+func (s Session) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "context":
+		return s.ContextName
+	case "container":
+		return s.Container
+	case "filter":
+		return s.Filter
+	case "limit":
+		return s.Limit
+	case "state":
+		return s.StatusText()
+	case "packets":
+		return strconv.FormatInt(s.Packets(), 10)
+	case "size":
+		return common.FormatBytes(s.Size())
+	case "file":
+		return filepath.Base(s.Path)
+	case "age":
+		return common.FormatAge(s.CreatedAt)
+	}
+	return ""
+}
+
+func (s Session) GetDefaultColumn() string     { return "container" }
+func (s Session) GetDefaultSortColumn() string { return "file" }
+
+var _ common.Resource = Session{}
+
+type Manager struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		sessions: make(map[string]*Session),
+	}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Start creates the capture file of a container in dir and runs the
+// capture in the background. It ends after duration when it is not zero.
+func (m *Manager) Start(contextName, container, filter, limit, dir string, duration time.Duration, run RunFunc) (*Session, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("unable to determine d4s captures directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create captures dir: %v", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s.%s", unsafeFileChars.ReplaceAllString(container, "_"), now.Format("20060102-150405.000"))
+	path := filepath.Join(dir, name+".pcap")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if duration > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), duration)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	s := &Session{
+		ID:          name,
+		ContextName: contextName,
+		Container:   container,
+		Filter:      filter,
+		Limit:       limit,
+		Path:        path,
+		CreatedAt:   now,
+		state:       &state{cancel: cancel, doneCh: make(chan struct{})},
+	}
+
+	m.mu.Lock()
+	m.sessions[s.ID] = s
+	m.mu.Unlock()
+
+	go func() {
+		err := run(ctx, &sessionWriter{w: f, state: s.state}, s.state.appendOutput)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		cancel()
+
+		s.state.mu.Lock()
+		s.state.done = true
+		s.state.err = err
+		s.state.mu.Unlock()
+		close(s.state.doneCh)
+	}()
+	return s, nil
+}
+
+// Stop ends a capture; its file is kept and it stays listed.
+func (m *Manager) Stop(id string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.state.mu.Lock()
+		if !s.state.done {
+			s.state.stopped = true
+		}
+		s.state.mu.Unlock()
+		s.state.cancel()
+	}
+}
+
+// Remove ends a capture and deletes its file.
+func (m *Manager) Remove(id string) error {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	s.state.cancel()
+	<-s.state.doneCh
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) Get(id string) *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions[id]
+}
+
+func (m *Manager) List() []common.Resource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+
+	result := make([]common.Resource, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, *s)
+	}
+	return result
+}
+
+// Shutdown stops every capture when d4s exits, waiting a few seconds for
+// each one to remove its helper container.
+func (m *Manager) Shutdown() {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.state.cancel()
+			timer := time.NewTimer(shutdownGrace)
+			defer timer.Stop()
+			select {
+			case <-s.state.doneCh:
+			case <-timer.C:
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (st *state) appendOutput(line string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.output = append(st.output, line)
+	if len(st.output) > maxOutput {
+		st.output = st.output[len(st.output)-maxOutput:]
+	}
+}
+
+// sessionWriter writes the capture file, counting its packets on the way.
+type sessionWriter struct {
+	w     io.Writer
+	state *state
+}
+
+func (sw *sessionWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	sw.state.mu.Lock()
+	sw.state.pcap.count(p[:n])
+	sw.state.mu.Unlock()
+	return n, err
+}
+
+// pcapCounter follows a pcap stream to count its bytes and packets.
+type pcapCounter struct {
+	bytes   int64
+	packets int64
+	order   binary.ByteOrder
+	invalid bool
+	header  []byte // pending bytes of the file header or of a record header
+	skip    int64  // bytes left of the current packet
+}
+
+func (c *pcapCounter) count(p []byte) {
+	c.bytes += int64(len(p))
+	for len(p) > 0 && !c.invalid {
+		if c.skip > 0 {
+			n := min(c.skip, int64(len(p)))
+			c.skip -= n
+			p = p[n:]
+			continue
+		}
+
+		need := 16 // record header
+		if c.order == nil {
+			need = 24 // file header
+		}
+		n := min(need-len(c.header), len(p))
+		c.header = append(c.header, p[:n]...)
+		p = p[n:]
+		if len(c.header) < need {
+			return
+		}
+
+		if c.order == nil {
+			switch binary.LittleEndian.Uint32(c.header) {
+			case 0xa1b2c3d4, 0xa1b23c4d: // microsecond and nanosecond
+				c.order = binary.LittleEndian
+			case 0xd4c3b2a1, 0x4d3cb2a1:
+				c.order = binary.BigEndian
+			default:
+				c.invalid = true
+			}
+		} else {
+			c.packets++
+			c.skip = int64(c.order.Uint32(c.header[8:12]))
+		}
+		c.header = c.header[:0]
+	}
+}
@@ -29,6 +29,11 @@ type D4SConfig struct {
 
 	Logger   LoggerConfig   `yaml:"logger"`
 	ShellPod ShellPodConfig `yaml:"shellPod"`
+	DebugPod DebugPodConfig `yaml:"debugPod"`
+	Registry RegistryConfig `yaml:"registry"`
+
+	UpdateCheck UpdateCheckConfig `yaml:"updateCheck"`
+	Compose     ComposeConfig     `yaml:"compose"`
 }
 
 type UIConfig struct {
@@ -56,6 +61,46 @@ type ShellPodConfig struct {
 	Image string `yaml:"image"`
 }
 
+type DebugPodConfig struct {
+	Image string `yaml:"image"`
+}
+
+// GetDebugImage returns the image of debug containers, the shell pod image
+// unless one is configured.
+func (c *D4SConfig) GetDebugImage() string {
+	if c.DebugPod.Image != "" {
+		return c.DebugPod.Image
+	}
+	return c.ShellPod.Image
+}
+
+type RegistryConfig struct {
+	Endpoint string `yaml:"endpoint"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+type UpdateCheckConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Interval string   `yaml:"interval"`
+	Include  []string `yaml:"include,omitempty"`
+	Exclude  []string `yaml:"exclude,omitempty"`
+}
+
+type ComposeConfig struct {
+	// Directories scanned for compose files, on the host compose commands
+	// run on (the remote host for SSH contexts).
+	Workspaces []string `yaml:"workspaces,omitempty"`
+	ScanDepth  int      `yaml:"scanDepth"`
+}
+
+// GetScanDepth returns how deep workspaces are scanned, 3 by default.
+func (c *ComposeConfig) GetScanDepth() int {
+	if c.ScanDepth <= 0 {
+		return 3
+	}
+	return min(c.ScanDepth, 10)
+}
+
 // GetAPIServerTimeout parses the apiServerTimeout string into a time.Duration.
 func (c *D4SConfig) GetAPIServerTimeout() time.Duration {
 	if c.APIServerTimeout == "" {
@@ -68,6 +113,18 @@ func (c *D4SConfig) GetAPIServerTimeout() time.Duration {
 	return d
 }
 
+// GetInterval parses the update check interval, enforcing a 1m minimum.
+func (c *UpdateCheckConfig) GetInterval() time.Duration {
+	d, err := time.ParseDuration(c.Interval)
+	if err != nil {
+		return time.Hour
+	}
+	if d < time.Minute {
+		return time.Minute
+	}
+	return d
+}
+
 // GetRefreshInterval returns the refresh rate as a time.Duration, enforcing a 2s minimum.
 func (c *D4SConfig) GetRefreshInterval() time.Duration {
 	rate := c.RefreshRate
@@ -140,6 +197,10 @@ func DefaultConfig() *Config {
 			ShellPod: ShellPodConfig{
 				Image: "ghcr.io/jr-k/nget:latest",
 			},
+			UpdateCheck: UpdateCheckConfig{
+				Enabled:  false,
+				Interval: "1h",
+			},
 		},
 	}
 }
@@ -170,6 +231,15 @@ func LogsDir() string {
 	return filepath.Join(dir, "logs")
 }
 
+// CapturesDir returns the directory where packet captures are written.
+func CapturesDir() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "captures")
+}
+
 // ensureConfigDirs creates the config directory and skins subdirectory if they don't exist.
 func ensureConfigDirs() {
 	dir := configDir()
@@ -0,0 +1,266 @@
+package common
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCanceled is the error of a CmdRun stopped with Cancel.
+var ErrCanceled = errors.New("canceled")
+
+// Output lines kept per run; older ones are dropped.
+const maxRunLines = 20000
+
+// Longer lines are truncated.
+const maxLineBytes = 1024 * 1024
+
+// How long a canceled command has to exit after its interrupt before it
+// is killed.
+const cancelGrace = 10 * time.Second
+
+// CmdRun runs commands one after the other in the background and keeps
+// their combined output, so it can be followed live and read again once
+// the run is over.
+type CmdRun struct {
+	Title   string
+	Started time.Time
+
+	mu       sync.Mutex
+	lines    []string
+	dropped  int
+	updated  chan struct{}
+	current  *exec.Cmd
+	exited   chan struct{} // closed when current exits
+	canceled bool
+	done     bool
+	err      error
+	finished time.Time
+}
+
+// CmdStep is a command of a run; Label is what gets echoed before its
+// output (e.g. "docker compose up -d", whether it runs locally or over SSH).
+type CmdStep struct {
+	Label string
+	Cmd   *exec.Cmd
+}
+
+// StartCmdRun starts the steps in sequence, stopping at the first failure.
+func StartCmdRun(title string, steps ...CmdStep) *CmdRun {
+	r := &CmdRun{Title: title, Started: time.Now(), updated: make(chan struct{})}
+	go r.run(steps)
+	return r
+}
+
+func (r *CmdRun) run(steps []CmdStep) {
+	var err error
+	for _, step := range steps {
+		if err = r.runOne(step); err != nil {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	if r.canceled {
+		err = ErrCanceled
+	}
+	r.done = true
+	r.err = err
+	r.finished = time.Now()
+	r.current = nil
+	r.exited = nil
+	r.notifyLocked()
+	r.mu.Unlock()
+}
+
+func (r *CmdRun) runOne(step CmdStep) error {
+	cmd := step.Cmd
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	r.mu.Lock()
+	if r.canceled {
+		r.mu.Unlock()
+		return ErrCanceled
+	}
+	r.appendLocked("$ " + step.Label)
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	exited := make(chan struct{})
+	r.current = cmd
+	r.exited = exited
+	r.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		close(exited)
+		pw.CloseWithError(err)
+	}()
+
+	// Read until the pipe is closed whatever the lines, so that the
+	// command never blocks on a full pipe.
+	reader := bufio.NewReaderSize(pr, 64*1024)
+	var err error
+	for {
+		var line string
+		if line, err = readLine(reader); err != nil {
+			break
+		}
+		// Progress output rewrites lines with \r: keep the last state.
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		r.mu.Lock()
+		r.appendLocked(line)
+		r.mu.Unlock()
+	}
+	if err == io.EOF {
+		err = nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("%s: %v", step.Label, exitErr)
+	}
+	return err
+}
+
+// readLine reads a line without its end of line, truncated to maxLineBytes.
+func readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			return string(line), err
+		}
+		if n := min(len(chunk), maxLineBytes-len(line)); n > 0 {
+			line = append(line, chunk[:n]...)
+		}
+		if !isPrefix {
+			return strings.TrimRight(string(line), "\r"), nil
+		}
+	}
+}
+
+func (r *CmdRun) appendLocked(line string) {
+	r.lines = append(r.lines, line)
+	if len(r.lines) > maxRunLines+maxRunLines/10 {
+		n := len(r.lines) - maxRunLines
+		r.lines = append([]string(nil), r.lines[n:]...)
+		r.dropped += n
+	}
+	r.notifyLocked()
+}
+
+func (r *CmdRun) notifyLocked() {
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+// Since returns the lines after the first offset ones (offsets count every
+// line ever written), the offset to ask next, and a channel closed on the
+// next change unless the run is over.
+func (r *CmdRun) Since(offset int) ([]string, int, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := offset - r.dropped
+	if start < 0 {
+		start = 0
+	}
+	var lines []string
+	if start < len(r.lines) {
+		lines = append(lines, r.lines[start:]...)
+	}
+	next := r.dropped + len(r.lines)
+
+	if r.done {
+		return lines, next, nil
+	}
+	return lines, next, r.updated
+}
+
+// Output returns the whole output kept so far.
+func (r *CmdRun) Output() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.lines, "\n")
+}
+
+// LastLine returns the latest non-empty line of output.
+func (r *CmdRun) LastLine() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(r.lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Cancel interrupts the running command, as Ctrl-C would, and skips the
+// next ones. The command is killed if it did not exit after cancelGrace,
+// or when Cancel is called again.
+func (r *CmdRun) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return
+	}
+	cmd, exited := r.current, r.exited
+	if cmd == nil || cmd.Process == nil {
+		r.canceled = true
+		return
+	}
+	if r.canceled {
+		killProcess(cmd)
+		return
+	}
+	r.canceled = true
+
+	if err := interruptProcess(cmd); err != nil {
+		killProcess(cmd)
+		return
+	}
+	go func() {
+		timer := time.NewTimer(cancelGrace)
+		defer timer.Stop()
+		select {
+		case <-exited:
+		case <-timer.C:
+			killProcess(cmd)
+		}
+	}()
+}
+
+// Wait blocks until the run is over and returns its error.
+func (r *CmdRun) Wait() error {
+	for {
+		r.mu.Lock()
+		if r.done {
+			err := r.err
+			r.mu.Unlock()
+			return err
+		}
+		ch := r.updated
+		r.mu.Unlock()
+		<-ch
+	}
+}
+
+// State returns whether the run is over, when it ended and its error.
+func (r *CmdRun) State() (done bool, finished time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done, r.finished, r.err
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package common
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// interruptProcess kills cmd: console processes cannot be interrupted
+// without sharing the console of d4s.
+func interruptProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -27,6 +27,15 @@ type Resource interface {
 	GetDefaultSortColumn() string
 }
 
+// PruneCandidate is a resource a prune would remove, with the space it
+// would reclaim (-1 when unknown).
+type PruneCandidate struct {
+	ID     string
+	Name   string
+	Size   int64
+	Detail string
+}
+
 // HostStats represents basic host metrics
 type HostStats struct {
 	CPU        string
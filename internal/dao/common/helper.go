@@ -0,0 +1,102 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"golang.org/x/net/context"
+)
+
+// HelperLabel marks the throwaway containers d4s creates for its own work
+// (volume archives, diagnostics...).
+const HelperLabel = "d4s.helper"
+
+// EnsureImage pulls ref unless the daemon already has it.
+func EnsureImage(cli *client.Client, ctx context.Context, ref string) error {
+	if _, err := cli.ImageInspect(ctx, ref); err == nil {
+		return nil
+	}
+	reader, err := cli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %v", ref, err)
+	}
+	defer reader.Close()
+	_, err = io.Copy(io.Discard, reader)
+	return err
+}
+
+// CreateHelper creates, without starting it, a helper container named
+// name (with a unique suffix) and labelled with its purpose.
+func CreateHelper(cli *client.Client, ctx context.Context, name, purpose string, cfg *container.Config, host *container.HostConfig) (string, error) {
+	if err := EnsureImage(cli, ctx, cfg.Image); err != nil {
+		return "", err
+	}
+	if cfg.Labels == nil {
+		cfg.Labels = make(map[string]string)
+	}
+	cfg.Labels[HelperLabel] = purpose
+
+	created, err := cli.ContainerCreate(ctx, cfg, host, nil, nil, fmt.Sprintf("%s-%d", name, time.Now().UnixNano()))
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// RunHelper starts a helper container, waits for it to exit and returns
+// what it printed. A non-zero exit is an error carrying the last line.
+func RunHelper(cli *client.Client, ctx context.Context, id string) (string, error) {
+	// Wait before starting, so a quick exit cannot be missed
+	waitCh, errCh := cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+	if err := cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return "", err
+	}
+
+	var code int64
+	select {
+	case res := <-waitCh:
+		if res.Error != nil {
+			return "", fmt.Errorf("%s", res.Error.Message)
+		}
+		code = res.StatusCode
+	case err := <-errCh:
+		return "", err
+	}
+
+	output := helperOutput(cli, ctx, id)
+	if code != 0 {
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+			return output, fmt.Errorf("%s (exit code %d)", last, code)
+		}
+		return output, fmt.Errorf("helper exited with code %d", code)
+	}
+	return output, nil
+}
+
+func helperOutput(cli *client.Client, ctx context.Context, id string) string {
+	reader, err := cli.ContainerLogs(ctx, id, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if _, err := stdcopy.StdCopy(&buf, &buf, reader); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// RemoveHelper removes a helper container whatever its state. It uses its
+// own context so that cleanup still happens after a cancellation.
+func RemoveHelper(cli *client.Client, id string) {
+	_ = cli.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+}
@@ -17,6 +17,16 @@ func ShortenPath(path string) string {
 	return path
 }
 
+// ExpandHome expands a leading "~" or "~/" to the user's home directory.
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + path[1:]
+		}
+	}
+	return path
+}
+
 func ParseStatus(s string) (status, age, health string) {
 	s = strings.TrimSpace(s)
 
@@ -112,6 +122,21 @@ func ShortenDuration(d string) string {
 	return d
 }
 
+// FormatAge is the time elapsed since t in its largest unit, e.g. "3m".
+func FormatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
 func FormatTime(ts int64) string {
 	t := time.Unix(ts, 0)
 	now := time.Now()
@@ -277,3 +302,44 @@ func CalculateStatsFromMap(v map[string]interface{}) (float64, uint64, uint64, f
 
 	return cpuPercent, memUsage, memLimit, netRx, netTx, diskRead, diskWrite
 }
+
+// ParseKeyValues reads "key=value" pairs separated by commas, as entered
+// in a form field.
+func ParseKeyValues(s string) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid key=value pair %q", kv)
+		}
+		res[k] = strings.TrimSpace(v)
+	}
+	return res, nil
+}
+
+// UserAliases drops the network aliases the daemon adds by itself: the
+// container name and its short ID. A hex prefix shorter than the short ID
+// is a user alias that happens to match.
+func UserAliases(aliases []string, name, id string) []string {
+	var res []string
+	for _, a := range aliases {
+		if a == name || (len(a) >= 12 && strings.HasPrefix(id, a)) {
+			continue
+		}
+		res = append(res, a)
+	}
+	return res
+}
+
+// Dash stands in for an empty cell.
+func Dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
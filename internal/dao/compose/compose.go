@@ -29,6 +29,19 @@ type Manager struct {
 	targetMu    sync.RWMutex
 	contextName string
 	remoteHost  string // empty = run locally
+
+	// Projects found by scanning the configured workspaces
+	discovery discoveryCache
+
+	// Resolved compose models, by project
+	models modelCache
+
+	// Last up/build/down run, by project, kept for its output
+	runsMu sync.Mutex
+	runs   map[string]*common.CmdRun
+
+	// Start and finish times of the jobs that are done
+	jobTimes jobTimes
 }
 
 func NewManager(cli *client.Client, ctx context.Context) *Manager {
@@ -54,6 +67,12 @@ func (m *Manager) execTarget() (string, string) {
 // the compose files live: locally (pinned to the right docker context)
 // or on the remote SSH host.
 func (m *Manager) dockerCmd(args []string, workDir string) *exec.Cmd {
+	return m.dockerCmdTTY(args, workDir, false)
+}
+
+// dockerCmdTTY is dockerCmd with a pseudo-terminal over SSH when tty is
+// set, so that killing the local ssh also hangs up the remote command.
+func (m *Manager) dockerCmdTTY(args []string, workDir string, tty bool) *exec.Cmd {
 	contextName, remoteHost := m.execTarget()
 
 	if remoteHost != "" {
@@ -66,6 +85,9 @@ func (m *Manager) dockerCmd(args []string, workDir string) *exec.Cmd {
 		if workDir != "" {
 			remoteCmd = fmt.Sprintf("cd %s && %s", sshutil.ShellQuote(workDir), remoteCmd)
 		}
+		if tty {
+			return sshutil.SSHCommandTTY(contextName, remoteHost, remoteCmd)
+		}
 		return sshutil.SSHCommand(contextName, remoteHost, remoteCmd)
 	}
 
@@ -89,21 +111,29 @@ func (m *Manager) readConfigFile(path string) ([]byte, error) {
 	return cmd.Output()
 }
 
+// StatusNotDeployed is the status of a project only known from its compose
+// file in a workspace.
+const StatusNotDeployed = "Not deployed"
+
 // ComposeProject Model
 type ComposeProject struct {
 	Name        string
 	Status      string
 	Ready       string
+	Drift       string
 	ConfigFiles string
 	ConfigPaths []string
 }
 
 func (cp ComposeProject) GetID() string { return cp.Name }
 func (cp ComposeProject) GetCells() []string {
-	return []string{cp.Name, cp.Ready, cp.Status, cp.ConfigFiles}
+	return []string{cp.Name, cp.Ready, cp.Status, cp.Drift, cp.ConfigFiles}
 }
 
 func (cp ComposeProject) GetStatusColor() (tcell.Color, tcell.Color) {
+	if cp.Status == StatusNotDeployed {
+		return styles.ColorStatusGray, styles.ColorBlack
+	}
 	if strings.Contains(cp.Ready, "/") {
 		parts := strings.Split(cp.Ready, "/")
 		if len(parts) == 2 {
@@ -133,6 +163,8 @@ func (cp ComposeProject) GetColumnValue(column string) string {
 		return cp.Ready
 	case "status":
 		return cp.Status
+	case "drift":
+		return cp.Drift
 	case "config files":
 		return cp.ConfigFiles
 	}
@@ -158,6 +190,8 @@ func (m *Manager) List() ([]common.Resource, error) {
 		jobs        int
 		running     int
 		restarting  int
+		drifted     int
+		driftErr    error
 		config      string
 		configPaths []string
 	}
@@ -188,6 +222,13 @@ func (m *Manager) List() ([]common.Resource, error) {
 		}
 
 		projects[proj].total++
+		drifted, err := m.ContainerDrift(c.Labels)
+		if drifted && c.State == "running" {
+			projects[proj].drifted++
+		}
+		if err != nil {
+			projects[proj].driftErr = err
+		}
 		switch c.State {
 		case "running":
 			projects[proj].running++
@@ -209,10 +250,25 @@ func (m *Manager) List() ([]common.Resource, error) {
 			Name:        name,
 			Status:      status,
 			Ready:       fmt.Sprintf("%d/%d", data.running, data.total),
+			Drift:       DriftLabel(data.drifted > 0, data.driftErr),
 			ConfigFiles: data.config,
 			ConfigPaths: data.configPaths,
 		})
 	}
+
+	// Projects from the workspaces that have no container (never started or down)
+	for _, p := range m.discovered() {
+		if _, ok := projects[p.name]; ok {
+			continue
+		}
+		res = append(res, ComposeProject{
+			Name:        p.name,
+			Status:      StatusNotDeployed,
+			Ready:       "-",
+			ConfigFiles: common.ShortenPath(strings.Join(p.paths, ",")),
+			ConfigPaths: p.paths,
+		})
+	}
 	return res, nil
 }
 
@@ -246,26 +302,11 @@ func (m *Manager) Stop(projectName string) error {
 }
 
 func (m *Manager) GetConfig(projectName string) (string, error) {
-	// Find one container to get config path
-	args := filters.NewArgs()
-	args.Add("label", fmt.Sprintf("com.docker.compose.project=%s", projectName))
-	
-	containers, err := m.cli.ContainerList(m.ctx, container.ListOptions{Filters: args, All: true, Limit: 1})
+	files, err := m.getConfigPaths(projectName)
 	if err != nil {
 		return "", err
 	}
-	
-	if len(containers) == 0 {
-		return "", fmt.Errorf("project not found or no containers")
-	}
-	
-	configFiles := containers[0].Labels["com.docker.compose.project.config_files"]
-	if configFiles == "" {
-		return "", fmt.Errorf("no config files label found")
-	}
-	
-	// Handle multiple files (separated by comma)
-	files := strings.Split(configFiles, ",")
+
 	var sb strings.Builder
 	
 	for _, f := range files {
@@ -295,12 +336,18 @@ func (m *Manager) getConfigPaths(projectName string) ([]string, error) {
 		return nil, err
 	}
 	
-	if len(containers) == 0 {
-		return nil, fmt.Errorf("project '%s' not found or has no active containers to read configuration from", projectName)
+	configFiles := ""
+	if len(containers) > 0 {
+		configFiles = containers[0].Labels["com.docker.compose.project.config_files"]
 	}
-	
-	configFiles := containers[0].Labels["com.docker.compose.project.config_files"]
 	if configFiles == "" {
+		// Down or never started: fall back to the workspaces.
+		if p, ok := m.findDiscovered(projectName); ok {
+			return p.paths, nil
+		}
+		if len(containers) == 0 {
+			return nil, fmt.Errorf("project '%s' has no containers and no compose file in the configured workspaces", projectName)
+		}
 		return nil, fmt.Errorf("no config files label found for project '%s'", projectName)
 	}
 	
@@ -343,62 +390,146 @@ func (m *Manager) Logs(projectName string, since string, tail string, timestamps
 	return &cmdReadCloser{pipe: stdout, cmd: cmd}, nil
 }
 
-func (m *Manager) Down(projectName string) error {
-	cmd := m.dockerCmd([]string{"compose", "-p", projectName, "down"}, "")
-	output, err := cmd.CombinedOutput()
+// Down runs `docker compose down` in the background.
+func (m *Manager) Down(projectName string) (*common.CmdRun, error) {
+	return m.startRun(projectName, "Down", m.projectStep(projectName, nil, "down")), nil
+}
+
+// Redeploy takes the project down then brings it up again, in one run.
+func (m *Manager) Redeploy(projectName string, opts UpOptions) (*common.CmdRun, error) {
+	paths, err := m.getConfigPaths(projectName)
 	if err != nil {
-		return fmt.Errorf("error running docker compose down: %v, output: %s", err, string(output))
+		return nil, fmt.Errorf("failed to redeploy project: %v", err)
 	}
-	return nil
+
+	run := m.startRun(projectName, "Redeploy",
+		m.projectStep(projectName, nil, "down"),
+		m.upStep(projectName, paths, opts, "--force-recreate"),
+	)
+	m.rememberOnSuccess(run, projectName, opts)
+	return run, nil
 }
 
-func (m *Manager) Redeploy(projectName string) error {
+func (m *Manager) Up(projectName string, opts UpOptions) (*common.CmdRun, error) {
 	paths, err := m.getConfigPaths(projectName)
 	if err != nil {
-		return fmt.Errorf("failed to redeploy project: %v", err)
+		return nil, fmt.Errorf("failed to up project: %v", err)
 	}
+	run := m.startRun(projectName, "Up", m.upStep(projectName, paths, opts, "--force-recreate"))
+	m.rememberOnSuccess(run, projectName, opts)
+	return run, nil
+}
 
-	if err := m.Down(projectName); err != nil {
-		return err
+func (m *Manager) Build(projectName string, opts UpOptions) (*common.CmdRun, error) {
+	paths, err := m.getConfigPaths(projectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build project: %v", err)
 	}
+	run := m.startRun(projectName, "Build", m.upStep(projectName, paths, opts, "--build"))
+	m.rememberOnSuccess(run, projectName, opts)
+	return run, nil
+}
+
+// rememberOnSuccess records the options of an up as the most recent for the
+// project once it succeeded, and drops the model resolved with the
+// previous ones.
+func (m *Manager) rememberOnSuccess(run *common.CmdRun, projectName string, opts UpOptions) {
+	go func() {
+		if run.Wait() != nil {
+			return
+		}
+		// The history is a convenience: failing to save it is not an error of the run.
+		m.rememberOptions(projectName, opts)
+		m.InvalidateModel(projectName)
+	}()
+}
 
-	return m.up(projectName, paths, "--force-recreate")
+// upStep builds `docker compose up -d` with the chosen options.
+func (m *Manager) upStep(projectName string, paths []string, opts UpOptions, extraFlag string) common.CmdStep {
+	files := append(append([]string(nil), paths...), opts.Overrides...)
+	args := append(opts.globalArgs(), "up", "-d", extraFlag)
+	args = append(args, opts.upArgs()...)
+	return m.projectStep(projectName, files, args...)
 }
 
-func (m *Manager) Up(projectName string) error {
-	paths, err := m.getConfigPaths(projectName)
-	if err != nil {
-		return fmt.Errorf("failed to up project: %v", err)
+// projectStep is a projectCmd labelled with its compose subcommand only,
+// without the project and file flags.
+func (m *Manager) projectStep(projectName string, paths []string, args ...string) common.CmdStep {
+	return common.CmdStep{
+		Label: "docker compose " + strings.Join(args, " "),
+		Cmd:   m.projectCmd(projectName, paths, args...),
 	}
-	return m.up(projectName, paths, "--force-recreate")
 }
 
-func (m *Manager) Build(projectName string) error {
+// WatchStep builds `docker compose watch` for a project, to run for as
+// long as the watch session lasts.
+func (m *Manager) WatchStep(projectName string) (common.CmdStep, error) {
 	paths, err := m.getConfigPaths(projectName)
 	if err != nil {
-		return fmt.Errorf("failed to build project: %v", err)
+		return common.CmdStep{}, fmt.Errorf("failed to watch project: %v", err)
 	}
-	return m.up(projectName, paths, "--build")
-}
 
-func (m *Manager) up(projectName string, paths []string, extraFlag string) error {
 	args := []string{"compose", "-p", projectName}
 	for _, path := range paths {
 		args = append(args, "-f", path)
 	}
-	args = append(args, "up", "-d", extraFlag)
+	// No colors nor progress bars: the pseudo-terminal is only there for
+	// the hang-up.
+	args = append(args, "--ansi", "never", "--progress", "plain", "watch")
+	return common.CmdStep{
+		Label: "docker compose watch",
+		Cmd:   m.dockerCmdTTY(args, workDir(paths), true),
+	}, nil
+}
 
-	workDir := ""
-	if len(paths) > 0 {
-		workDir = filepath.Dir(paths[0])
+// startRun starts the steps and keeps the run as the project's last one.
+func (m *Manager) startRun(projectName, action string, steps ...common.CmdStep) *common.CmdRun {
+	run := common.StartCmdRun(fmt.Sprintf("%s %s", action, projectName), steps...)
+
+	m.runsMu.Lock()
+	if m.runs == nil {
+		m.runs = make(map[string]*common.CmdRun)
 	}
+	m.runs[projectName] = run
+	m.runsMu.Unlock()
 
-	cmd := m.dockerCmd(args, workDir)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("error running docker compose up: %v\nOutput: %s", err, string(output))
+	return run
+}
+
+// LastRun returns the last up/build/down (or job rerun) of a project,
+// running or not, or nil if there was none since d4s started.
+func (m *Manager) LastRun(projectName string) *common.CmdRun {
+	m.runsMu.Lock()
+	defer m.runsMu.Unlock()
+	return m.runs[projectName]
+}
+
+// projectCmd builds `docker compose -p <project> -f <path>... <args>`, run
+// from the directory of the main compose file so relative paths and .env
+// resolve like they do for the user.
+func (m *Manager) projectCmd(projectName string, paths []string, args ...string) *exec.Cmd {
+	cmdArgs := []string{"compose", "-p", projectName}
+	for _, path := range paths {
+		cmdArgs = append(cmdArgs, "-f", path)
 	}
-	return nil
+	cmdArgs = append(cmdArgs, args...)
+	return m.dockerCmd(cmdArgs, workDir(paths))
+}
+
+// workDir is the directory of the main compose file.
+func workDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	return filepath.Dir(paths[0])
+}
+
+// cmdError adds the stderr of a failed command to its error.
+func cmdError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
 }
 
 type cmdReadCloser struct {
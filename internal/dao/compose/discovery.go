@@ -0,0 +1,321 @@
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/sshutil"
+	"gopkg.in/yaml.v3"
+)
+
+// composeFileNames are the files `docker compose` picks up in a project
+// directory, by precedence.
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// Directories never worth descending into when looking for projects.
+var skippedDirs = []string{".git", "node_modules", "vendor", ".venv", "__pycache__"}
+
+// Workspaces are rescanned at most this often (scans may go over SSH).
+const discoveryTTL = 30 * time.Second
+
+var reInvalidProjectChars = regexp.MustCompile(`[^-_a-z0-9]`)
+
+type discoveredProject struct {
+	name  string
+	paths []string // main compose file first, then its override if any
+}
+
+type discoveryCache struct {
+	mu         sync.Mutex
+	roots      []string
+	depth      int
+	key        string
+	at         time.Time
+	projects   []discoveredProject
+	refreshing bool
+}
+
+// SetWorkspaces configures the directories scanned for compose projects.
+func (m *Manager) SetWorkspaces(roots []string, depth int) {
+	m.discovery.mu.Lock()
+	m.discovery.roots = roots
+	m.discovery.depth = depth
+	m.discovery.mu.Unlock()
+}
+
+// discovered returns the projects found in the workspaces. The first scan
+// is synchronous, later ones refresh the cache in the background.
+func (m *Manager) discovered() []discoveredProject {
+	contextName, remoteHost := m.execTarget()
+
+	d := &m.discovery
+	d.mu.Lock()
+	roots, depth := d.roots, d.depth
+	if len(roots) == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	key := fmt.Sprintf("%s|%s|%d|%s", contextName, remoteHost, depth, strings.Join(roots, "|"))
+	if d.key == key && d.at.After(time.Now().Add(-discoveryTTL)) {
+		projects := d.projects
+		d.mu.Unlock()
+		return projects
+	}
+	if d.key == key {
+		projects := d.projects
+		if !d.refreshing {
+			d.refreshing = true
+			go func() {
+				m.rescan(key, roots, depth)
+				d.mu.Lock()
+				d.refreshing = false
+				d.mu.Unlock()
+			}()
+		}
+		d.mu.Unlock()
+		return projects
+	}
+	d.mu.Unlock()
+
+	return m.rescan(key, roots, depth)
+}
+
+func (m *Manager) rescan(key string, roots []string, depth int) []discoveredProject {
+	files := m.scanWorkspaces(roots, depth)
+	projects := groupProjects(files)
+
+	m.discovery.mu.Lock()
+	m.discovery.key = key
+	m.discovery.at = time.Now()
+	m.discovery.projects = projects
+	m.discovery.mu.Unlock()
+	return projects
+}
+
+// findDiscovered returns the discovered project with the given name.
+func (m *Manager) findDiscovered(projectName string) (discoveredProject, bool) {
+	for _, p := range m.discovered() {
+		if p.name == projectName {
+			return p, true
+		}
+	}
+	return discoveredProject{}, false
+}
+
+// composeFile is a compose file found on disk along with its top-level
+// `name:` (empty when unset).
+type composeFile struct {
+	path string
+	name string
+}
+
+func (m *Manager) scanWorkspaces(roots []string, depth int) []composeFile {
+	_, remoteHost := m.execTarget()
+	if remoteHost != "" {
+		return m.scanRemote(roots, depth)
+	}
+
+	var files []composeFile
+	for _, root := range roots {
+		files = append(files, scanLocal(common.ExpandHome(root), depth)...)
+	}
+	return files
+}
+
+func scanLocal(root string, depth int) []composeFile {
+	root = filepath.Clean(root)
+	baseDepth := strings.Count(root, string(os.PathSeparator))
+
+	var files []composeFile
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if path != root && (isSkippedDir(entry.Name()) || strings.Count(path, string(os.PathSeparator))-baseDepth >= depth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isComposeFileName(entry.Name()) {
+			return nil
+		}
+		files = append(files, composeFile{path: path, name: readProjectName(path)})
+		return nil
+	})
+	return files
+}
+
+// scanRemote runs a single find on the SSH host, printing each compose
+// file followed by its `name:` line when it has one.
+func (m *Manager) scanRemote(roots []string, depth int) []composeFile {
+	contextName, remoteHost := m.execTarget()
+
+	var quotedRoots []string
+	for _, root := range roots {
+		if rest, ok := strings.CutPrefix(root, "~/"); ok {
+			quotedRoots = append(quotedRoots, `"$HOME"/`+sshutil.ShellQuote(rest))
+		} else if root == "~" {
+			quotedRoots = append(quotedRoots, `"$HOME"`)
+		} else if !strings.HasPrefix(root, "/") {
+			// find would print relative paths: resolve them as ssh does
+			quotedRoots = append(quotedRoots, `"$HOME"/`+sshutil.ShellQuote(root))
+		} else {
+			quotedRoots = append(quotedRoots, sshutil.ShellQuote(root))
+		}
+	}
+
+	var prune, names []string
+	for _, d := range skippedDirs {
+		prune = append(prune, "-name "+sshutil.ShellQuote(d))
+	}
+	for _, n := range allComposeFileNames() {
+		names = append(names, "-name "+sshutil.ShellQuote(n))
+	}
+
+	remoteCmd := fmt.Sprintf(
+		`find %s -maxdepth %d -type d \( %s \) -prune -o -type f \( %s \) -print -exec grep -m1 -E '^name:[[:space:]]' {} \; 2>/dev/null`,
+		strings.Join(quotedRoots, " "), depth, strings.Join(prune, " -o "), strings.Join(names, " -o "))
+
+	output, err := sshutil.SSHCommand(contextName, remoteHost, remoteCmd).Output()
+	if err != nil && len(output) == 0 {
+		return nil
+	}
+
+	var files []composeFile
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "name:"); ok && len(files) > 0 {
+			files[len(files)-1].name = cleanProjectName(value)
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			files = append(files, composeFile{path: line})
+		}
+	}
+	return files
+}
+
+// groupProjects turns compose files into one project per directory, named
+// like `docker compose` would (top-level name, else the directory name).
+func groupProjects(files []composeFile) []discoveredProject {
+	byDir := make(map[string]map[string]composeFile)
+	for _, f := range files {
+		dir := filepath.Dir(f.path)
+		if byDir[dir] == nil {
+			byDir[dir] = make(map[string]composeFile)
+		}
+		byDir[dir][filepath.Base(f.path)] = f
+	}
+
+	var projects []discoveredProject
+	seen := make(map[string]bool)
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		found := byDir[dir]
+		for _, base := range composeFileNames {
+			main, ok := found[base]
+			if !ok {
+				continue
+			}
+
+			name := main.name
+			if name == "" {
+				name = normalizeProjectName(filepath.Base(dir))
+			}
+			if name == "" || seen[name] {
+				break
+			}
+			seen[name] = true
+
+			p := discoveredProject{name: name, paths: []string{main.path}}
+			if override, ok := found[overrideFileName(base)]; ok {
+				p.paths = append(p.paths, override.path)
+			}
+			projects = append(projects, p)
+			break
+		}
+	}
+	return projects
+}
+
+func overrideFileName(base string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + ".override" + ext
+}
+
+func allComposeFileNames() []string {
+	names := append([]string(nil), composeFileNames...)
+	for _, n := range composeFileNames {
+		names = append(names, overrideFileName(n))
+	}
+	return names
+}
+
+func isComposeFileName(name string) bool {
+	for _, n := range allComposeFileNames() {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+func isSkippedDir(name string) bool {
+	for _, d := range skippedDirs {
+		if name == d {
+			return true
+		}
+	}
+	return false
+}
+
+func readProjectName(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var doc struct {
+		Name string `yaml:"name"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return ""
+	}
+	return cleanProjectName(doc.Name)
+}
+
+// cleanProjectName normalizes a top-level `name:` value; interpolated
+// names cannot be resolved here and are ignored.
+func cleanProjectName(value string) string {
+	value, _, _ = strings.Cut(value, " #")
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	if strings.Contains(value, "$") {
+		return ""
+	}
+	return normalizeProjectName(value)
+}
+
+// normalizeProjectName mirrors compose's project name normalization.
+func normalizeProjectName(name string) string {
+	name = reInvalidProjectChars.ReplaceAllString(strings.ToLower(name), "")
+	return strings.TrimLeft(name, "_-")
+}
@@ -0,0 +1,460 @@
+package compose
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+// DriftLabel is the DRIFT cell of a container or project: whether it no
+// longer matches its compose files, or why that is unknown.
+func DriftLabel(drifted bool, err error) string {
+	switch {
+	case err != nil:
+		return fmt.Sprintf("[%s]error[-]", styles.TagError)
+	case drifted:
+		return fmt.Sprintf("[%s]drifted[-]", styles.ColorStatusOrange.String())
+	}
+	return ""
+}
+
+// Fields compose leaves out of the config hash: changing them does not
+// recreate containers. Nested keys are dropped from the named object.
+var hashIgnoredFields = map[string][]string{
+	"build":       nil,
+	"pull_policy": nil,
+	"scale":       nil,
+	"depends_on":  nil,
+	"profiles":    nil,
+	"deploy":      {"replicas"},
+}
+
+// serviceHash approximates compose's ServiceHash: a sha256 of the service
+// JSON (as `docker compose config` marshals it) minus hashIgnoredFields.
+// It is not compose's own code: compose hashes its in-memory service
+// struct, whose encoding can differ from the `config` output (defaults,
+// key order, fields added in a release), so a container may be reported
+// drifted, or not, when compose would decide otherwise. To limit that, the
+// result is only compared with the config-hash label of containers created
+// by the compose version that resolved the model: the drift of containers
+// from other versions is not detected.
+func serviceHash(raw json.RawMessage) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return "", err
+	}
+	stripped, err := dropKeys(buf.Bytes(), hashIgnoredFields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(stripped)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dropKeys removes keys from a compact JSON object, keeping the order of
+// the others so the result hashes like the original marshalling.
+func dropKeys(obj []byte, drop map[string][]string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return obj, nil
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	first := true
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		if nested, ok := drop[key]; ok {
+			if len(nested) == 0 {
+				continue
+			}
+			sub := make(map[string][]string, len(nested))
+			for _, k := range nested {
+				sub[k] = nil
+			}
+			if value, err = dropKeys(value, sub); err != nil {
+				return nil, err
+			}
+		}
+
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		encodedKey, _ := json.Marshal(key)
+		out.Write(encodedKey)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// DriftCheck compares compose containers with the resolved model of their
+// project.
+type DriftCheck struct {
+	version string            // compose version that resolved the model
+	hashes  map[string]string // config hash per service
+	err     error             // why the model could not be resolved
+}
+
+// Drifted tells, from its labels, whether a container of the project was
+// created from another version of its service than the one in the compose
+// files.
+func (c DriftCheck) Drifted(labels map[string]string) (bool, error) {
+	service := labels["com.docker.compose.service"]
+	hash := labels["com.docker.compose.config-hash"]
+	if service == "" || hash == "" || labels["com.docker.compose.oneoff"] == "True" {
+		return false, nil
+	}
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.version == "" || labels["com.docker.compose.version"] != c.version {
+		return false, nil
+	}
+	expected, ok := c.hashes[service]
+	return ok && expected != hash, nil
+}
+
+// ProjectDrift returns the drift check of a project. It only uses models
+// already resolved, so it never waits on `docker compose config`, and is
+// reused for driftRecheck so that listing many containers of a project
+// looks the model up once.
+func (m *Manager) ProjectDrift(projectName string) DriftCheck {
+	m.models.mu.Lock()
+	if d, ok := m.models.checks[projectName]; ok && time.Since(d.at) < driftRecheck {
+		m.models.mu.Unlock()
+		return d.check
+	}
+	m.models.mu.Unlock()
+
+	e, ok := m.cachedModel(projectName)
+	if !ok {
+		// Still loading: ask again on the next refresh
+		return DriftCheck{}
+	}
+	check := DriftCheck{version: e.version, err: e.err}
+	if e.model != nil {
+		check.hashes = e.model.Hashes
+	}
+
+	m.models.mu.Lock()
+	if m.models.checks == nil {
+		m.models.checks = make(map[string]driftEntry)
+	}
+	m.models.checks[projectName] = driftEntry{at: time.Now(), check: check}
+	m.models.mu.Unlock()
+	return check
+}
+
+// ContainerDrift tells, from its labels, whether a compose container was
+// created from another version of its service than the one in the compose
+// files; the error is why the model could not be resolved.
+func (m *Manager) ContainerDrift(labels map[string]string) (bool, error) {
+	project := labels["com.docker.compose.project"]
+	if project == "" {
+		return false, nil
+	}
+	return m.ProjectDrift(project).Drifted(labels)
+}
+
+// DriftReport describes, as a diff, how the containers of a project (or
+// of one of its services) differ from the compose files on disk.
+func (m *Manager) DriftReport(projectName, service string) (string, error) {
+	m.InvalidateModel(projectName)
+	model, err := m.GetModel(projectName)
+	if err != nil {
+		return "", err
+	}
+
+	args := filters.NewArgs()
+	args.Add("label", fmt.Sprintf("com.docker.compose.project=%s", projectName))
+	if service != "" {
+		args.Add("label", fmt.Sprintf("com.docker.compose.service=%s", service))
+	}
+	list, err := m.cli.ContainerList(m.ctx, container.ListOptions{Filters: args, All: true})
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(list, func(i, j int) bool { return containerName(list[i].Names) < containerName(list[j].Names) })
+	version := m.composeVersion()
+
+	var sb strings.Builder
+	drifted := 0
+	for _, c := range list {
+		name := c.Labels["com.docker.compose.service"]
+		svc, ok := model.Services[name]
+		if !ok || c.Labels["com.docker.compose.oneoff"] == "True" {
+			continue
+		}
+		hash := c.Labels["com.docker.compose.config-hash"]
+		// Hashes of another compose version are not comparable: only the
+		// fields compared below tell whether the container drifted.
+		sameVersion := version != "" && c.Labels["com.docker.compose.version"] == version
+		if hash == "" || (sameVersion && hash == model.Hashes[name]) {
+			continue
+		}
+
+		inspect, err := m.cli.ContainerInspect(m.ctx, c.ID)
+		if err != nil {
+			drifted++
+			fmt.Fprintf(&sb, "# %s: %v\n\n", containerName(c.Names), err)
+			continue
+		}
+
+		var diff strings.Builder
+		changed := writeServiceDiff(&diff, projectName, name, svc, model, inspect)
+		if !sameVersion && !changed {
+			continue
+		}
+		drifted++
+
+		if sameVersion {
+			fmt.Fprintf(&sb, "--- running: %s (config-hash %s)\n", containerName(c.Names), shortHash(hash))
+			fmt.Fprintf(&sb, "+++ file:    %s (config-hash %s)\n", name, shortHash(model.Hashes[name]))
+		} else {
+			fmt.Fprintf(&sb, "--- running: %s (compose %s)\n", containerName(c.Names), c.Labels["com.docker.compose.version"])
+			fmt.Fprintf(&sb, "+++ file:    %s (compose %s)\n", name, version)
+		}
+		sb.WriteString(diff.String())
+		if !changed {
+			sb.WriteString("# the change is in a field not compared here; `docker compose up` will recreate this container\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if drifted == 0 {
+		return fmt.Sprintf("# no drift: the containers of %s match its compose files\n", projectName), nil
+	}
+	return sb.String(), nil
+}
+
+// writeServiceDiff compares the fields of a service that are visible on
+// its container. Environment and labels are compared on the keys of the
+// file only, since images and compose add their own.
+func writeServiceDiff(sb *strings.Builder, project, name string, svc ModelService, model *Model, c container.InspectResponse) bool {
+	if c.Config == nil || c.HostConfig == nil {
+		return false
+	}
+	changed := false
+	section := func(field string, running, file []string) {
+		if slices.Equal(running, file) {
+			return
+		}
+		changed = true
+		fmt.Fprintf(sb, "@@ %s @@\n", field)
+		for _, l := range running {
+			fmt.Fprintf(sb, "-%s\n", l)
+		}
+		for _, l := range file {
+			fmt.Fprintf(sb, "+%s\n", l)
+		}
+	}
+	one := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+
+	image := svc.Image
+	if image == "" {
+		image = project + "-" + name
+	}
+	section("image", one(c.Config.Image), one(image))
+
+	if svc.Command != nil {
+		section("command", c.Config.Cmd, svc.Command)
+	}
+	if svc.Entrypoint != nil {
+		section("entrypoint", c.Config.Entrypoint, svc.Entrypoint)
+	}
+
+	runningEnv := make(map[string]string)
+	for _, kv := range c.Config.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		runningEnv[k] = v
+	}
+	var envRunning, envFile []string
+	for _, k := range sortedKeys(svc.Environment) {
+		if svc.Environment[k] == nil {
+			continue
+		}
+		want := *svc.Environment[k]
+		if got, ok := runningEnv[k]; !ok || got != want {
+			if ok {
+				envRunning = append(envRunning, k+"="+got)
+			}
+			envFile = append(envFile, k+"="+want)
+		}
+	}
+	section("environment", envRunning, envFile)
+
+	var labelsRunning, labelsFile []string
+	for _, k := range sortedKeys(svc.Labels) {
+		if got, ok := c.Config.Labels[k]; !ok || got != svc.Labels[k] {
+			if ok {
+				labelsRunning = append(labelsRunning, k+"="+got)
+			}
+			labelsFile = append(labelsFile, k+"="+svc.Labels[k])
+		}
+	}
+	section("labels", labelsRunning, labelsFile)
+
+	var portsRunning, portsFile []string
+	for port, bindings := range c.HostConfig.PortBindings {
+		for _, b := range bindings {
+			portsRunning = append(portsRunning, formatPort(b.HostIP, b.HostPort, port.Int(), port.Proto()))
+		}
+	}
+	for _, p := range svc.Ports {
+		portsFile = append(portsFile, formatPort(p.HostIP, string(p.Published), p.Target, p.Protocol))
+	}
+	sort.Strings(portsRunning)
+	sort.Strings(portsFile)
+	section("ports", portsRunning, portsFile)
+
+	running := make(map[string]string)
+	for _, mp := range c.Mounts {
+		source := mp.Source
+		if mp.Type == "volume" {
+			source = mp.Name
+		}
+		running[mp.Destination] = formatMount(string(mp.Type), source, mp.Destination, !mp.RW)
+	}
+	var mountsRunning, mountsFile []string
+	for _, v := range svc.Volumes {
+		source := v.Source
+		switch {
+		case v.Type == "volume" && source != "":
+			if r, ok := model.Volumes[source]; ok && r.Name != "" {
+				source = r.Name
+			} else {
+				source = project + "_" + source
+			}
+		case v.Type == "volume":
+			// anonymous: the name is random, only the target matters
+			if got, ok := running[v.Target]; ok && strings.HasPrefix(got, "volume:") {
+				running[v.Target] = formatMount("volume", "", v.Target, v.ReadOnly)
+			}
+		case v.Type == "tmpfs":
+			continue
+		}
+		mountsFile = append(mountsFile, formatMount(v.Type, source, v.Target, v.ReadOnly))
+		if got, ok := running[v.Target]; ok {
+			mountsRunning = append(mountsRunning, got)
+			delete(running, v.Target)
+		}
+	}
+	for _, got := range running {
+		// Volumes declared by the image show up as mounts too: only
+		// leftover bind mounts are known to come from the compose file.
+		if strings.HasPrefix(got, "bind:") {
+			mountsRunning = append(mountsRunning, got)
+		}
+	}
+	sort.Strings(mountsRunning)
+	sort.Strings(mountsFile)
+	section("volumes", mountsRunning, mountsFile)
+
+	if svc.NetworkMode == "" && c.NetworkSettings != nil {
+		var netsRunning, netsFile []string
+		for n := range c.NetworkSettings.Networks {
+			netsRunning = append(netsRunning, n)
+		}
+		for key := range svc.Networks {
+			if r, ok := model.Networks[key]; ok && r.Name != "" {
+				netsFile = append(netsFile, r.Name)
+			} else {
+				netsFile = append(netsFile, project+"_"+key)
+			}
+		}
+		sort.Strings(netsRunning)
+		sort.Strings(netsFile)
+		section("networks", netsRunning, netsFile)
+	}
+
+	restart := string(c.HostConfig.RestartPolicy.Name)
+	if restart == "" {
+		restart = "no"
+	}
+	wantRestart := svc.Restart
+	if wantRestart == "" {
+		wantRestart = "no"
+	}
+	section("restart", one(restart), one(wantRestart))
+
+	if svc.User != "" {
+		section("user", one(c.Config.User), one(svc.User))
+	}
+	if svc.WorkingDir != "" {
+		section("working_dir", one(c.Config.WorkingDir), one(svc.WorkingDir))
+	}
+	return changed
+}
+
+func formatPort(hostIP, published string, target int, proto string) string {
+	if proto == "" {
+		proto = "tcp"
+	}
+	s := fmt.Sprintf("%d/%s", target, proto)
+	if published != "" {
+		s = published + "->" + s
+		if hostIP != "" && hostIP != "0.0.0.0" {
+			s = hostIP + ":" + s
+		}
+	}
+	return s
+}
+
+func formatMount(kind, source, target string, readOnly bool) string {
+	s := kind + ":" + source + ":" + target
+	if readOnly {
+		s += ":ro"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containerName(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(names[0], "/")
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
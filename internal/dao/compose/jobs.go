@@ -0,0 +1,259 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+// Kinds of jobs: services labelled d4s.lifecycle=job, `docker compose run`
+// containers, and service containers that exited.
+const (
+	JobKindJob    = "job"
+	JobKindRun    = "run"
+	JobKindExited = "exited"
+)
+
+// Job Model
+type Job struct {
+	ID         string
+	Name       string
+	Project    string
+	Service    string
+	Kind       string
+	State      string
+	ExitCode   int
+	OOMKilled  bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+func (j Job) GetID() string { return j.ID }
+
+func (j Job) Running() bool { return j.State == "running" || j.State == "restarting" }
+
+// Stopped tells whether the job was ended by SIGTERM or SIGKILL, as
+// `docker stop` and `docker compose stop` do, rather than by itself. The
+// exit code cannot tell them from a kill by something else, but an OOM
+// kill is known.
+func (j Job) Stopped() bool {
+	return !j.Running() && !j.OOMKilled && (j.ExitCode == 128+int(syscall.SIGTERM) || j.ExitCode == 128+int(syscall.SIGKILL))
+}
+
+// Failed tells whether the job ended by itself with a non-zero exit code,
+// or was killed for lack of memory.
+func (j Job) Failed() bool {
+	return !j.Running() && j.State != "created" && j.ExitCode != 0 && !j.Stopped()
+}
+
+func (j Job) exit() string {
+	if j.Running() || j.State == "created" {
+		return "-"
+	}
+	return fmt.Sprintf("%d", j.ExitCode)
+}
+
+func (j Job) duration() string {
+	if j.StartedAt.IsZero() {
+		return "-"
+	}
+	end := j.FinishedAt
+	if j.Running() || end.Before(j.StartedAt) {
+		end = time.Now()
+	}
+	return end.Sub(j.StartedAt).Round(time.Second).String()
+}
+
+func formatJobTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return common.FormatTime(t.Unix())
+}
+
+func (j Job) finished() string {
+	if j.Running() {
+		return "-"
+	}
+	return formatJobTime(j.FinishedAt)
+}
+
+func (j Job) GetCells() []string {
+	return []string{j.Project, j.Service, j.Name, j.Kind, j.State, j.exit(), formatJobTime(j.StartedAt), j.finished(), j.duration()}
+}
+
+func (j Job) GetStatusColor() (tcell.Color, tcell.Color) {
+	switch {
+	case j.Running():
+		return styles.ColorIdle, styles.ColorBlack
+	case j.State == "created", j.Stopped():
+		return styles.ColorStatusGray, styles.ColorBlack
+	case j.Failed():
+		return styles.ColorStatusRed, styles.ColorBlack
+	}
+	return styles.ColorStatusGreen, styles.ColorBlack
+}
+
+func (j Job) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "project":
+		return j.Project
+	case "service":
+		return j.Service
+	case "name":
+		return j.Name
+	case "kind":
+		return j.Kind
+	case "state":
+		return j.State
+	case "exit":
+		return j.exit()
+	case "started":
+		return formatJobTime(j.StartedAt)
+	case "finished":
+		return j.finished()
+	case "duration":
+		return j.duration()
+	}
+	return ""
+}
+
+func (j Job) GetDefaultColumn() string {
+	return "NAME"
+}
+
+func (j Job) GetDefaultSortColumn() string {
+	return "STARTED"
+}
+
+// jobTimes caches what only ContainerInspect tells about a container that
+// is done running: it does not change until the container starts again.
+type jobTimes struct {
+	mu      sync.Mutex
+	entries map[string]container.State
+}
+
+// Jobs lists the one-shot containers of compose projects: services
+// labelled d4s.lifecycle=job, `docker compose run` containers and service
+// containers that exited.
+func (m *Manager) Jobs() ([]common.Resource, error) {
+	args := filters.NewArgs()
+	args.Add("label", "com.docker.compose.project")
+	list, err := m.cli.ContainerList(m.ctx, container.ListOptions{Filters: args, All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	m.jobTimes.mu.Lock()
+	if m.jobTimes.entries == nil {
+		m.jobTimes.entries = make(map[string]container.State)
+	}
+	m.jobTimes.mu.Unlock()
+
+	// Forget the containers that were removed
+	listed := make(map[string]bool, len(list))
+	for _, c := range list {
+		listed[c.ID] = true
+	}
+	m.jobTimes.mu.Lock()
+	for id := range m.jobTimes.entries {
+		if !listed[id] {
+			delete(m.jobTimes.entries, id)
+		}
+	}
+	m.jobTimes.mu.Unlock()
+
+	var res []common.Resource
+	for _, c := range list {
+		kind := ""
+		switch {
+		case c.Labels["d4s.lifecycle"] == "job":
+			kind = JobKindJob
+		case c.Labels["com.docker.compose.oneoff"] == "True":
+			kind = JobKindRun
+		case c.State == "exited" || c.State == "dead":
+			kind = JobKindExited
+		default:
+			continue
+		}
+
+		job := Job{
+			ID:      c.ID,
+			Name:    containerName(c.Names),
+			Project: c.Labels["com.docker.compose.project"],
+			Service: c.Labels["com.docker.compose.service"],
+			Kind:    kind,
+			State:   c.State,
+		}
+		if state, ok := m.jobState(c); ok {
+			job.ExitCode = state.ExitCode
+			job.OOMKilled = state.OOMKilled
+			job.StartedAt, _ = time.Parse(time.RFC3339Nano, state.StartedAt)
+			job.FinishedAt, _ = time.Parse(time.RFC3339Nano, state.FinishedAt)
+		}
+		res = append(res, job)
+	}
+	return res, nil
+}
+
+func (m *Manager) jobState(c container.Summary) (container.State, bool) {
+	running := c.State == "running" || c.State == "restarting"
+
+	m.jobTimes.mu.Lock()
+	state, ok := m.jobTimes.entries[c.ID]
+	if running {
+		delete(m.jobTimes.entries, c.ID)
+	}
+	m.jobTimes.mu.Unlock()
+	if ok && !running {
+		return state, true
+	}
+
+	inspect, err := m.cli.ContainerInspect(m.ctx, c.ID)
+	if err != nil || inspect.State == nil {
+		return container.State{}, false
+	}
+	if !running {
+		m.jobTimes.mu.Lock()
+		m.jobTimes.entries[c.ID] = *inspect.State
+		m.jobTimes.mu.Unlock()
+	}
+	return *inspect.State, true
+}
+
+// RerunJob starts the container of a job again and follows its output.
+// Reusing the container keeps the command, entrypoint and environment it
+// was run with (`docker compose run` overrides included) and leaves no new
+// container behind.
+func (m *Manager) RerunJob(id string) (*common.CmdRun, error) {
+	inspect, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.Config == nil {
+		return nil, fmt.Errorf("container %s has no config", id)
+	}
+	name := strings.TrimPrefix(inspect.Name, "/")
+	project := inspect.Config.Labels["com.docker.compose.project"]
+	if project == "" {
+		return nil, fmt.Errorf("%s is not a compose container", name)
+	}
+
+	m.jobTimes.mu.Lock()
+	delete(m.jobTimes.entries, id)
+	m.jobTimes.mu.Unlock()
+
+	step := common.CmdStep{
+		Label: "docker start -a " + name,
+		Cmd:   m.dockerCmd([]string{"start", "-a", id}, ""),
+	}
+	return m.startRun(project, "Rerun", step), nil
+}
@@ -0,0 +1,294 @@
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jr-k/d4s/internal/config"
+	"github.com/jr-k/d4s/internal/sshutil"
+)
+
+// Pull policies accepted by `docker compose up --pull`.
+var PullPolicies = []string{"missing", "always", "never", "build"}
+
+// How many option combinations are remembered per project.
+const maxRecentOptions = 5
+
+const historyFileName = "compose-history.json"
+
+// UpOptions are the knobs of `docker compose up` d4s exposes on top of the
+// project's own compose files.
+type UpOptions struct {
+	Profiles      []string `json:"profiles,omitempty"`
+	EnvFiles      []string `json:"envFiles,omitempty"`
+	Overrides     []string `json:"overrides,omitempty"`
+	Pull          string   `json:"pull,omitempty"`
+	RemoveOrphans bool     `json:"removeOrphans,omitempty"`
+	NoDeps        bool     `json:"noDeps,omitempty"`
+}
+
+func (o UpOptions) IsZero() bool {
+	return len(o.Profiles) == 0 && len(o.EnvFiles) == 0 && len(o.Overrides) == 0 &&
+		o.Pull == "" && !o.RemoveOrphans && !o.NoDeps
+}
+
+// String is a one-line summary, e.g. "profiles dev · env .env.prod · pull always".
+func (o UpOptions) String() string {
+	var parts []string
+	if len(o.Profiles) > 0 {
+		parts = append(parts, "profiles "+strings.Join(o.Profiles, ","))
+	}
+	for _, f := range o.EnvFiles {
+		parts = append(parts, "env "+filepath.Base(f))
+	}
+	for _, f := range o.Overrides {
+		parts = append(parts, "+"+filepath.Base(f))
+	}
+	if o.Pull != "" {
+		parts = append(parts, "pull "+o.Pull)
+	}
+	if o.RemoveOrphans {
+		parts = append(parts, "remove-orphans")
+	}
+	if o.NoDeps {
+		parts = append(parts, "no-deps")
+	}
+	if len(parts) == 0 {
+		return "defaults"
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (o UpOptions) equal(other UpOptions) bool {
+	a, _ := json.Marshal(o)
+	b, _ := json.Marshal(other)
+	return bytes.Equal(a, b)
+}
+
+// globalArgs go before the compose subcommand, upArgs after `up`.
+func (o UpOptions) globalArgs() []string {
+	var args []string
+	for _, p := range o.Profiles {
+		args = append(args, "--profile", p)
+	}
+	for _, f := range o.EnvFiles {
+		args = append(args, "--env-file", f)
+	}
+	return args
+}
+
+func (o UpOptions) upArgs() []string {
+	var args []string
+	if o.Pull != "" {
+		args = append(args, "--pull", o.Pull)
+	}
+	if o.RemoveOrphans {
+		args = append(args, "--remove-orphans")
+	}
+	if o.NoDeps {
+		args = append(args, "--no-deps")
+	}
+	return args
+}
+
+// UpChoices is what can be picked for a project in the up options form.
+type UpChoices struct {
+	Profiles  []string
+	EnvFiles  []string // full paths, next to the main compose file
+	Overrides []string // full paths, next to the main compose file
+	Recent    []UpOptions
+}
+
+// UpChoices lists the profiles defined by the project and the env and
+// compose files found next to its main compose file.
+func (m *Manager) UpChoices(projectName string) (*UpChoices, error) {
+	paths, err := m.getConfigPaths(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := &UpChoices{Recent: m.RecentOptions(projectName)}
+
+	output, err := m.projectCmd(projectName, paths, "config", "--profiles").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running docker compose config: %v", cmdError(err))
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if p := strings.TrimSpace(scanner.Text()); p != "" {
+			choices.Profiles = append(choices.Profiles, p)
+		}
+	}
+
+	dir := workDir(paths)
+	names, err := m.listDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		switch {
+		case isEnvFileName(name):
+			choices.EnvFiles = append(choices.EnvFiles, path)
+		case isOverrideCandidate(name) && !slices.Contains(paths, path):
+			choices.Overrides = append(choices.Overrides, path)
+		}
+	}
+	return choices, nil
+}
+
+// listDir lists the files of a directory where the compose files live.
+func (m *Manager) listDir(dir string) ([]string, error) {
+	contextName, remoteHost := m.execTarget()
+	if remoteHost == "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			if !e.IsDir() {
+				names = append(names, e.Name())
+			}
+		}
+		return names, nil
+	}
+
+	remoteCmd := fmt.Sprintf("cd %s && for f in * .[!.]*; do [ -f \"$f\" ] && printf '%%s\\n' \"$f\"; done", sshutil.ShellQuote(dir))
+	output, err := sshutil.SSHCommand(contextName, remoteHost, remoteCmd).Output()
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("failed to list %s: %v", dir, cmdError(err))
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			names = append(names, line)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func isEnvFileName(name string) bool {
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
+
+// isOverrideCandidate matches compose.*.yaml, docker-compose.*.yml, etc.
+func isOverrideCandidate(name string) bool {
+	ext := filepath.Ext(name)
+	if ext != ".yml" && ext != ".yaml" {
+		return false
+	}
+	base := strings.TrimSuffix(name, ext)
+	return base == "compose" || base == "docker-compose" ||
+		strings.HasPrefix(base, "compose.") || strings.HasPrefix(base, "docker-compose.")
+}
+
+var historyMu sync.Mutex
+
+// history maps "<context>/<project>" to its recent options, latest first.
+type history map[string][]UpOptions
+
+func historyPath() string {
+	dir := config.ConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, historyFileName)
+}
+
+func readHistory() history {
+	h := make(history)
+	path := historyPath()
+	if path == "" {
+		return h
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	json.Unmarshal(data, &h)
+	return h
+}
+
+func (m *Manager) historyKey(projectName string) string {
+	contextName, _ := m.execTarget()
+	if contextName == "" {
+		contextName = "default"
+	}
+	return contextName + "/" + projectName
+}
+
+// RecentOptions returns the option combinations last used for a project,
+// the defaults aside.
+func (m *Manager) RecentOptions(projectName string) []UpOptions {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	var recent []UpOptions
+	for _, o := range readHistory()[m.historyKey(projectName)] {
+		if !o.IsZero() {
+			recent = append(recent, o)
+		}
+	}
+	return recent
+}
+
+// lastOptions returns the options of the last up of a project, so that its
+// compose files can be resolved the same way.
+func (m *Manager) lastOptions(projectName string) UpOptions {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if recent := readHistory()[m.historyKey(projectName)]; len(recent) > 0 {
+		return recent[0]
+	}
+	return UpOptions{}
+}
+
+// rememberOptions records a combination as the most recent for a project.
+// The defaults are recorded too, to know that the last up used them.
+func (m *Manager) rememberOptions(projectName string, opts UpOptions) error {
+	path := historyPath()
+	if path == "" {
+		return fmt.Errorf("cannot resolve config directory")
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	h := readHistory()
+	key := m.historyKey(projectName)
+	recent := []UpOptions{opts}
+	listed := 0 // combinations other than the defaults
+	if !opts.IsZero() {
+		listed++
+	}
+	for _, o := range h[key] {
+		if o.equal(opts) || (!o.IsZero() && listed == maxRecentOptions) {
+			continue
+		}
+		if !o.IsZero() {
+			listed++
+		}
+		recent = append(recent, o)
+	}
+	h[key] = recent
+
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,550 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+	"gopkg.in/yaml.v3"
+)
+
+// Resolved models are reused for this long: `docker compose config` is a
+// subprocess (and an SSH round-trip on remote contexts).
+const modelTTL = 30 * time.Second
+
+// Drift is checked on every refresh of the containers and compose views,
+// from the models already resolved: they are resolved again in the
+// background when their files change, or after driftTTL on SSH contexts
+// where the files cannot be checked cheaply.
+const driftTTL = 5 * time.Minute
+
+// driftRecheck is how long the drift check of a project is reused before
+// its model is looked up (and its compose files checked) again.
+const driftRecheck = 5 * time.Second
+
+// Model is the subset of a resolved compose project (`docker compose
+// config`) that d4s works with.
+type Model struct {
+	Name     string                   `json:"name"`
+	Services map[string]ModelService  `json:"services"`
+	Networks map[string]ModelResource `json:"networks,omitempty"`
+	Volumes  map[string]ModelResource `json:"volumes,omitempty"`
+
+	// Hashes is the config hash compose labels each service's containers
+	// with, computed from the resolved files.
+	Hashes map[string]string `json:"-"`
+}
+
+type ModelService struct {
+	Image       string                          `json:"image,omitempty"`
+	Build       *ModelBuild                     `json:"build,omitempty"`
+	Ports       []ModelPort                     `json:"ports,omitempty"`
+	Scale       *int                            `json:"scale,omitempty"`
+	Deploy      *ModelDeploy                    `json:"deploy,omitempty"`
+	Profiles    []string                        `json:"profiles,omitempty"`
+	Command     []string                        `json:"command,omitempty"`
+	Entrypoint  []string                        `json:"entrypoint,omitempty"`
+	Environment map[string]*string              `json:"environment,omitempty"`
+	Labels      map[string]string               `json:"labels,omitempty"`
+	Volumes     []ModelVolume                   `json:"volumes,omitempty"`
+	Networks    map[string]*ModelServiceNetwork `json:"networks,omitempty"`
+	NetworkMode string                          `json:"network_mode,omitempty"`
+	Restart     string                          `json:"restart,omitempty"`
+	User        string                          `json:"user,omitempty"`
+	WorkingDir  string                          `json:"working_dir,omitempty"`
+	DependsOn   map[string]ModelDependency      `json:"depends_on,omitempty"`
+	Healthcheck *ModelHealthcheck               `json:"healthcheck,omitempty"`
+
+	// Raw is the whole service definition, for display.
+	Raw map[string]any `json:"-"`
+}
+
+// ModelResource is a top-level network or volume; Name is the actual
+// docker name (project-prefixed unless set explicitly).
+type ModelResource struct {
+	Name     string `json:"name,omitempty"`
+	External bool   `json:"external,omitempty"`
+}
+
+type ModelVolume struct {
+	Type     string `json:"type"`
+	Source   string `json:"source,omitempty"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+type ModelServiceNetwork struct {
+	Aliases     []string `json:"aliases,omitempty"`
+	IPv4Address string   `json:"ipv4_address,omitempty"`
+}
+
+type ModelBuild struct {
+	Context    string `json:"context,omitempty"`
+	Dockerfile string `json:"dockerfile,omitempty"`
+}
+
+type ModelPort struct {
+	Target    int        `json:"target"`
+	Published flexString `json:"published,omitempty"`
+	Protocol  string     `json:"protocol,omitempty"`
+	HostIP    string     `json:"host_ip,omitempty"`
+}
+
+type ModelDependency struct {
+	Condition string `json:"condition,omitempty"`
+	Required  *bool  `json:"required,omitempty"` // nil means true
+}
+
+type ModelHealthcheck struct {
+	Test    []string `json:"test,omitempty"`
+	Disable bool     `json:"disable,omitempty"`
+}
+
+type ModelDeploy struct {
+	Replicas *int `json:"replicas,omitempty"`
+}
+
+// flexString accepts both strings and numbers (published ports are
+// numbers in older compose releases).
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// DesiredReplicas is the number of containers compose keeps for the service.
+func (s ModelService) DesiredReplicas() int {
+	if s.Deploy != nil && s.Deploy.Replicas != nil {
+		return *s.Deploy.Replicas
+	}
+	if s.Scale != nil {
+		return *s.Scale
+	}
+	return 1
+}
+
+func (p ModelPort) String() string {
+	proto := ""
+	if p.Protocol != "" && p.Protocol != "tcp" {
+		proto = "/" + p.Protocol
+	}
+	if p.Published == "" {
+		return fmt.Sprintf("%d%s", p.Target, proto)
+	}
+	host := string(p.Published)
+	if p.HostIP != "" {
+		host = p.HostIP + ":" + host
+	}
+	return fmt.Sprintf("%s->%d%s", host, p.Target, proto)
+}
+
+type modelCache struct {
+	mu       sync.Mutex
+	entries  map[string]modelEntry
+	loading  map[string]bool
+	versions map[string]string     // compose version per exec target
+	checks   map[string]driftEntry // drift check per project
+}
+
+type driftEntry struct {
+	at    time.Time
+	check DriftCheck
+}
+
+type modelEntry struct {
+	at      time.Time
+	model   *Model
+	err     error
+	paths   []string // compose files the model was resolved from
+	version string   // compose version that resolved it
+}
+
+// GetModel resolves the compose files of a project with `docker compose
+// config`, so overrides, interpolation and extends are applied.
+func (m *Manager) GetModel(projectName string) (*Model, error) {
+	m.models.mu.Lock()
+	if e, ok := m.models.entries[projectName]; ok && e.at.After(time.Now().Add(-modelTTL)) {
+		m.models.mu.Unlock()
+		return e.model, e.err
+	}
+	m.models.mu.Unlock()
+
+	return m.reloadModel(projectName)
+}
+
+// cachedModel returns the last resolved model of a project without
+// waiting: a missing or outdated one is (re)loaded in the background.
+func (m *Manager) cachedModel(projectName string) (modelEntry, bool) {
+	m.models.mu.Lock()
+	defer m.models.mu.Unlock()
+
+	e, ok := m.models.entries[projectName]
+	if (!ok || m.outdated(e)) && !m.models.loading[projectName] {
+		if m.models.loading == nil {
+			m.models.loading = make(map[string]bool)
+		}
+		m.models.loading[projectName] = true
+		go func() {
+			m.reloadModel(projectName)
+			m.models.mu.Lock()
+			delete(m.models.loading, projectName)
+			m.models.mu.Unlock()
+		}()
+	}
+	return e, ok
+}
+
+// outdated tells whether a model used for drift detection must be
+// resolved again: its local files changed, or it is older than driftTTL.
+func (m *Manager) outdated(e modelEntry) bool {
+	if time.Since(e.at) > driftTTL {
+		return true
+	}
+	if _, remoteHost := m.execTarget(); remoteHost != "" {
+		return false
+	}
+	for _, path := range e.paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(e.at) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) reloadModel(projectName string) (*Model, error) {
+	at := time.Now()
+	model, paths, err := m.loadModel(projectName)
+	version := m.composeVersion()
+
+	m.models.mu.Lock()
+	if m.models.entries == nil {
+		m.models.entries = make(map[string]modelEntry)
+	}
+	m.models.entries[projectName] = modelEntry{at: at, model: model, err: err, paths: paths, version: version}
+	delete(m.models.checks, projectName)
+	m.models.mu.Unlock()
+	return model, err
+}
+
+// composeVersion returns the version of the compose plugin that resolves
+// the models, as compose labels containers with, or "" when unknown.
+func (m *Manager) composeVersion() string {
+	contextName, remoteHost := m.execTarget()
+	key := contextName + "@" + remoteHost
+
+	m.models.mu.Lock()
+	version, ok := m.models.versions[key]
+	m.models.mu.Unlock()
+	if ok {
+		return version
+	}
+
+	if output, err := m.dockerCmd([]string{"compose", "version", "--short"}, "").Output(); err == nil {
+		version = strings.TrimPrefix(strings.TrimSpace(string(output)), "v")
+	}
+	m.models.mu.Lock()
+	if m.models.versions == nil {
+		m.models.versions = make(map[string]string)
+	}
+	m.models.versions[key] = version
+	m.models.mu.Unlock()
+	return version
+}
+
+// InvalidateModel drops the cached model of a project (after an edit or
+// an action that changes it).
+func (m *Manager) InvalidateModel(projectName string) {
+	m.models.mu.Lock()
+	delete(m.models.entries, projectName)
+	delete(m.models.checks, projectName)
+	m.models.mu.Unlock()
+}
+
+// loadModel resolves the compose files of a project with the options of
+// its last up: its overrides, and its env files and profiles, which change
+// interpolation and the services defined.
+func (m *Manager) loadModel(projectName string) (*Model, []string, error) {
+	paths, err := m.getConfigPaths(projectName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := m.lastOptions(projectName)
+	for _, f := range opts.Overrides {
+		if !slices.Contains(paths, f) {
+			paths = append(paths, f)
+		}
+	}
+	args := append(opts.globalArgs(), "config", "--format", "json")
+	output, err := m.projectCmd(projectName, paths, args...).Output()
+	if err != nil {
+		return nil, paths, fmt.Errorf("error running docker compose config: %v", cmdError(err))
+	}
+	model, err := parseModel(output)
+	return model, paths, err
+}
+
+func parseModel(data []byte) (*Model, error) {
+	var model Model
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %v", err)
+	}
+
+	var raw struct {
+		Services map[string]json.RawMessage `json:"services"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse compose config: %v", err)
+	}
+
+	model.Hashes = make(map[string]string)
+	for name, svc := range model.Services {
+		json.Unmarshal(raw.Services[name], &svc.Raw)
+		model.Services[name] = svc
+
+		hash, err := serviceHash(raw.Services[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash service %s: %v", name, err)
+		}
+		model.Hashes[name] = hash
+	}
+	return &model, nil
+}
+
+// ComposeService Model
+type ComposeService struct {
+	Project  string
+	Name     string
+	Running  int
+	Total    int
+	Desired  int // -1 when the compose files could not be resolved
+	Drifted  int
+	DriftErr error
+	Image    string
+	Ports    string
+	Build    string
+	Profiles string
+}
+
+func (s ComposeService) GetID() string { return s.Name }
+
+func (s ComposeService) ready() string {
+	if s.Desired < 0 {
+		return fmt.Sprintf("%d/?", s.Running)
+	}
+	return fmt.Sprintf("%d/%d", s.Running, s.Desired)
+}
+
+func (s ComposeService) GetCells() []string {
+	return []string{s.Name, s.ready(), s.drift(), s.Image, s.Ports, s.Build, s.Profiles}
+}
+
+func (s ComposeService) drift() string {
+	return DriftLabel(s.Drifted > 0, s.DriftErr)
+}
+
+func (s ComposeService) GetStatusColor() (tcell.Color, tcell.Color) {
+	switch {
+	case s.Desired < 0:
+		return styles.ColorFg, styles.ColorBlack
+	case s.Running == 0 && s.Desired == 0:
+		return styles.ColorStatusGray, styles.ColorBlack
+	case s.Running < s.Desired:
+		return styles.ColorStatusGray, styles.ColorBlack
+	case s.Running > s.Desired:
+		return tcell.ColorMediumPurple, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (s ComposeService) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "service":
+		return s.Name
+	case "ready":
+		return s.ready()
+	case "drift":
+		return s.drift()
+	case "image":
+		return s.Image
+	case "ports":
+		return s.Ports
+	case "build":
+		return s.Build
+	case "profiles":
+		return s.Profiles
+	}
+	return ""
+}
+
+func (s ComposeService) GetDefaultColumn() string {
+	return "SERVICE"
+}
+
+func (s ComposeService) GetDefaultSortColumn() string {
+	return "SERVICE"
+}
+
+// ListServices merges the services of the resolved compose model with the
+// containers labelled com.docker.compose.service. Services that only exist
+// as containers (orphans) are listed with no desired replica.
+func (m *Manager) ListServices(projectName string) ([]common.Resource, error) {
+	args := filters.NewArgs()
+	args.Add("label", fmt.Sprintf("com.docker.compose.project=%s", projectName))
+
+	containers, err := m.cli.ContainerList(m.ctx, container.ListOptions{Filters: args, All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]*ComposeService)
+	model, modelErr := m.GetModel(projectName)
+	if modelErr != nil && len(containers) == 0 {
+		return nil, modelErr
+	}
+
+	if model != nil {
+		for name, svc := range model.Services {
+			s := &ComposeService{
+				Project: projectName,
+				Name:    name,
+				Desired: svc.DesiredReplicas(),
+				Image:   svc.Image,
+			}
+			if svc.Build != nil {
+				s.Build = common.ShortenPath(svc.Build.Context)
+				if svc.Build.Dockerfile != "" && svc.Build.Dockerfile != "Dockerfile" {
+					s.Build += " (" + svc.Build.Dockerfile + ")"
+				}
+				if s.Image == "" {
+					// compose's default image name for built services
+					s.Image = projectName + "-" + name
+				}
+			}
+			var ports []string
+			for _, p := range svc.Ports {
+				ports = append(ports, p.String())
+			}
+			s.Ports = strings.Join(ports, ", ")
+			s.Profiles = strings.Join(svc.Profiles, ",")
+			services[name] = s
+		}
+	}
+
+	for _, c := range containers {
+		name := c.Labels["com.docker.compose.service"]
+		if name == "" || c.Labels["d4s.lifecycle"] == "job" || c.Labels["com.docker.compose.oneoff"] == "True" {
+			continue
+		}
+		s, ok := services[name]
+		if !ok {
+			desired := 0
+			if model == nil {
+				desired = -1
+			}
+			s = &ComposeService{Project: projectName, Name: name, Desired: desired, Image: c.Image}
+			services[name] = s
+		}
+		s.Total++
+		drifted, err := m.ContainerDrift(c.Labels)
+		if drifted && c.State == "running" {
+			s.Drifted++
+		}
+		if err != nil {
+			s.DriftErr = err
+		}
+		if c.State == "running" {
+			s.Running++
+		}
+	}
+
+	res := make([]common.Resource, 0, len(services))
+	for _, s := range services {
+		res = append(res, *s)
+	}
+	return res, nil
+}
+
+// DescribeService returns the resolved definition of a service as YAML.
+func (m *Manager) DescribeService(projectName, service string) (string, error) {
+	model, err := m.GetModel(projectName)
+	if err != nil {
+		return "", err
+	}
+	svc, ok := model.Services[service]
+	if !ok {
+		return "", fmt.Errorf("service '%s' is not defined in the compose files of '%s'", service, projectName)
+	}
+	out, err := yaml.Marshal(map[string]any{service: svc.Raw})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Service actions, each one `docker compose <args> <service>`.
+func (m *Manager) StartService(projectName, service string) error {
+	return m.runService(projectName, service, "start")
+}
+
+func (m *Manager) StopService(projectName, service string) error {
+	return m.runService(projectName, service, "stop")
+}
+
+func (m *Manager) RestartService(projectName, service string) error {
+	return m.runService(projectName, service, "restart")
+}
+
+func (m *Manager) PullService(projectName, service string) error {
+	return m.runService(projectName, service, "pull")
+}
+
+func (m *Manager) BuildService(projectName, service string) error {
+	return m.runService(projectName, service, "build")
+}
+
+func (m *Manager) RecreateService(projectName, service string) error {
+	return m.runService(projectName, service, "up", "-d", "--force-recreate", "--no-deps")
+}
+
+func (m *Manager) ScaleService(projectName, service string, replicas int) error {
+	if replicas < 0 {
+		return fmt.Errorf("invalid replica count %d", replicas)
+	}
+	return m.runService(projectName, service, "up", "-d", "--no-recreate", "--scale", service+"="+strconv.Itoa(replicas))
+}
+
+func (m *Manager) runService(projectName, service string, args ...string) error {
+	paths, err := m.getConfigPaths(projectName)
+	if err != nil {
+		return err
+	}
+	defer m.InvalidateModel(projectName)
+
+	args = append(args, service)
+	output, err := m.projectCmd(projectName, paths, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running docker compose %s: %v\nOutput: %s", args[0], err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Service states of a topology node, from its containers.
+const (
+	StateRunning    = "running"
+	StateHealthy    = "healthy"
+	StateUnhealthy  = "unhealthy"
+	StateStarting   = "starting"
+	StateRestarting = "restarting"
+	StatePaused     = "paused"
+	StateExited     = "exited"
+	StateCreated    = "created"
+	StateNotCreated = "not created"
+)
+
+// Topology is how the services of a project relate to each other:
+// startup dependencies, shared networks and shared named volumes.
+type Topology struct {
+	Project  string
+	Services []TopologyService // by startup wave, then name
+	Networks []TopologyLink
+	Volumes  []TopologyLink
+}
+
+type TopologyService struct {
+	Name string
+	// Wave is the startup order: 0 depends on nothing, n waits on wave n-1.
+	// -1 means the service is in (or waits on) a dependency cycle.
+	Wave        int
+	DependsOn   []TopologyDependency
+	NetworkMode string
+	State       string
+	ExitCode    int // of exited containers
+	Running     int
+	Desired     int
+	Healthcheck bool
+}
+
+type TopologyDependency struct {
+	Service   string
+	Condition string
+	Required  bool
+	// Problem explains why this dependency blocks or breaks startup.
+	Problem string
+}
+
+// TopologyLink is a network or named volume and the services using it.
+type TopologyLink struct {
+	Name     string
+	External bool
+	Services []string
+}
+
+func (l TopologyLink) Shared() bool { return len(l.Services) > 1 }
+
+// Topology resolves the project's compose files and the live state of its
+// containers into a dependency graph.
+func (m *Manager) Topology(projectName string) (*Topology, error) {
+	model, err := m.GetModel(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := filters.NewArgs()
+	args.Add("label", fmt.Sprintf("com.docker.compose.project=%s", projectName))
+	list, err := m.cli.ContainerList(m.ctx, container.ListOptions{Filters: args, All: true})
+	if err != nil {
+		return nil, err
+	}
+	byService := make(map[string][]container.Summary)
+	for _, c := range list {
+		if c.Labels["com.docker.compose.oneoff"] == "True" {
+			continue
+		}
+		name := c.Labels["com.docker.compose.service"]
+		byService[name] = append(byService[name], c)
+	}
+
+	t := &Topology{Project: projectName}
+	services := make(map[string]*TopologyService, len(model.Services))
+	networks := make(map[string]*TopologyLink)
+	volumes := make(map[string]*TopologyLink)
+
+	for _, name := range sortedKeys(model.Services) {
+		svc := model.Services[name]
+		node := &TopologyService{
+			Name:        name,
+			NetworkMode: svc.NetworkMode,
+			Desired:     svc.DesiredReplicas(),
+			Healthcheck: svc.Healthcheck != nil && !svc.Healthcheck.Disable && len(svc.Healthcheck.Test) > 0 && svc.Healthcheck.Test[0] != "NONE",
+		}
+		node.State, node.ExitCode = serviceState(byService[name])
+		for _, c := range byService[name] {
+			if c.State == "running" {
+				node.Running++
+			}
+			// A healthcheck from the image shows up in the container status
+			if strings.Contains(c.Status, "healthy") || strings.Contains(c.Status, "health:") {
+				node.Healthcheck = true
+			}
+		}
+		for _, dep := range sortedKeys(svc.DependsOn) {
+			d := svc.DependsOn[dep]
+			condition := d.Condition
+			if condition == "" {
+				condition = "service_started"
+			}
+			node.DependsOn = append(node.DependsOn, TopologyDependency{
+				Service:   dep,
+				Condition: condition,
+				Required:  d.Required == nil || *d.Required,
+			})
+		}
+		services[name] = node
+
+		if svc.NetworkMode == "" {
+			keys := sortedKeys(svc.Networks)
+			if len(keys) == 0 {
+				keys = []string{"default"}
+			}
+			for _, key := range keys {
+				link(networks, resourceName(projectName, key, model.Networks), model.Networks[key].External, name)
+			}
+		}
+		for _, v := range svc.Volumes {
+			if v.Type == "volume" && v.Source != "" {
+				link(volumes, resourceName(projectName, v.Source, model.Volumes), model.Volumes[v.Source].External, name)
+			}
+		}
+	}
+
+	assignWaves(services)
+	for _, node := range services {
+		for i := range node.DependsOn {
+			node.DependsOn[i].Problem = dependencyProblem(node.DependsOn[i], services)
+		}
+		t.Services = append(t.Services, *node)
+	}
+	sort.Slice(t.Services, func(i, j int) bool {
+		a, b := t.Services[i], t.Services[j]
+		if a.Wave != b.Wave {
+			// cycles last
+			return b.Wave < 0 || (a.Wave >= 0 && a.Wave < b.Wave)
+		}
+		return a.Name < b.Name
+	})
+
+	for _, key := range sortedKeys(networks) {
+		t.Networks = append(t.Networks, *networks[key])
+	}
+	for _, key := range sortedKeys(volumes) {
+		t.Volumes = append(t.Volumes, *volumes[key])
+	}
+	return t, nil
+}
+
+func link(links map[string]*TopologyLink, name string, external bool, service string) {
+	l, ok := links[name]
+	if !ok {
+		l = &TopologyLink{Name: name, External: external}
+		links[name] = l
+	}
+	l.Services = append(l.Services, service)
+}
+
+// resourceName is the docker name of a network or volume of the project.
+func resourceName(project, key string, resources map[string]ModelResource) string {
+	if r, ok := resources[key]; ok && r.Name != "" {
+		return r.Name
+	}
+	return project + "_" + key
+}
+
+// serviceState sums up the containers of a service, the worst state first.
+func serviceState(containers []container.Summary) (string, int) {
+	if len(containers) == 0 {
+		return StateNotCreated, 0
+	}
+	state, exitCode := "", 0
+	rank := func(s string) int {
+		switch s {
+		case StateRestarting:
+			return 7
+		case StateUnhealthy:
+			return 6
+		case StateExited:
+			return 5
+		case StateStarting:
+			return 4
+		case StatePaused:
+			return 3
+		case StateCreated:
+			return 2
+		case StateRunning:
+			return 1
+		}
+		return 0
+	}
+	for _, c := range containers {
+		s := c.State
+		switch {
+		case c.State == "running" && strings.Contains(c.Status, "(unhealthy)"):
+			s = StateUnhealthy
+		case c.State == "running" && strings.Contains(c.Status, "(health: starting)"):
+			s = StateStarting
+		case c.State == "running" && strings.Contains(c.Status, "(healthy)"):
+			s = StateHealthy
+		case c.State == "dead":
+			s = StateExited
+		}
+		if s == StateExited {
+			code := 0
+			fmt.Sscanf(c.Status, "Exited (%d)", &code)
+			if code > exitCode {
+				exitCode = code
+			}
+		}
+		if state == "" || rank(s) > rank(state) {
+			state = s
+		}
+	}
+	return state, exitCode
+}
+
+// assignWaves layers the services by startup order. Services left over
+// are in a dependency cycle or wait on one.
+func assignWaves(services map[string]*TopologyService) {
+	pending := make(map[string]bool, len(services))
+	for name := range services {
+		pending[name] = true
+	}
+	for wave := 0; len(pending) > 0; wave++ {
+		var ready []string
+		for name := range pending {
+			ok := true
+			for _, d := range services[name].DependsOn {
+				if pending[d.Service] {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		for _, name := range ready {
+			services[name].Wave = wave
+			delete(pending, name)
+		}
+	}
+	for name := range pending {
+		services[name].Wave = -1
+	}
+}
+
+// dependencyProblem tells why a depends_on edge can keep its service from
+// starting, from the compose files and the live state of the dependency.
+func dependencyProblem(d TopologyDependency, services map[string]*TopologyService) string {
+	dep, ok := services[d.Service]
+	switch {
+	case !ok && d.Required:
+		return "not defined (profile not enabled?)"
+	case !ok:
+		return ""
+	case dep.Wave < 0:
+		return "dependency cycle"
+	}
+
+	switch d.Condition {
+	case "service_healthy":
+		if !dep.Healthcheck {
+			return "no healthcheck: the condition can never be met"
+		}
+		if dep.State == StateUnhealthy {
+			return "unhealthy"
+		}
+	case "service_completed_successfully":
+		if dep.State == StateExited && dep.ExitCode != 0 {
+			return fmt.Sprintf("exited with %d", dep.ExitCode)
+		}
+		if dep.State == StateRestarting {
+			return "restarting: never completes"
+		}
+	}
+	if dep.State == StateRestarting {
+		return "restarting"
+	}
+	return ""
+}
@@ -20,7 +20,10 @@ import (
 	clicontext "github.com/docker/cli/cli/context"
 	"github.com/docker/cli/cli/context/docker"
 	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dimage "github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
+	dnetwork "github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
 	"github.com/jr-k/d4s/internal/dao/common"
@@ -31,7 +34,9 @@ import (
 	"github.com/jr-k/d4s/internal/dao/docker/network"
 	"github.com/jr-k/d4s/internal/dao/docker/secret"
 	"github.com/jr-k/d4s/internal/dao/docker/stack"
+	"github.com/jr-k/d4s/internal/dao/docker/system"
 	"github.com/jr-k/d4s/internal/dao/docker/volume"
+	"github.com/jr-k/d4s/internal/dao/registry"
 	"github.com/jr-k/d4s/internal/dao/swarm/node"
 	"github.com/jr-k/d4s/internal/dao/swarm/service"
 	"github.com/jr-k/d4s/internal/dao/swarm/task"
@@ -44,8 +49,14 @@ type Resource = common.Resource
 type HostStats = common.HostStats
 type Container = container.Container
 type Image = image.Image
+type ImageRepoInfo = image.RepoInfo
 type Volume = volume.Volume
+type VolumeFile = volume.File
 type Network = network.Network
+type NetworkSpec = network.CreateSpec
+type NetworkAddressPool = network.AddressPool
+type NetworkEndpoint = network.Endpoint
+type NetworkAttachment = network.Attachment
 type Service = service.Service
 type Node = node.Node
 type Secret = secret.Secret
@@ -53,6 +64,33 @@ type Config = dconfig.Config
 type Stack = stack.Stack
 type Task = task.Task
 type ComposeProject = compose.ComposeProject
+type ComposeService = compose.ComposeService
+type ComposeUpOptions = compose.UpOptions
+type ComposeUpChoices = compose.UpChoices
+type ComposeTopology = compose.Topology
+type ComposeJob = compose.Job
+type ComposeTopologyLink = compose.TopologyLink
+type CmdRun = common.CmdRun
+type RegistryClient = registry.Client
+type RegistryRepository = registry.Repository
+type RegistryTag = registry.Tag
+type RegistryManifest = registry.Manifest
+type DiskUsage = system.Usage
+type PruneCandidate = common.PruneCandidate
+
+// NetworkDrivers are the drivers offered when creating a network.
+var NetworkDrivers = network.Drivers
+
+// ContainerDebugPrefix names the debug containers.
+const ContainerDebugPrefix = container.DebugPrefix
+
+// Network diagnostics run from inside a container.
+const (
+	DiagnoseDNS     = container.DiagnoseDNS
+	DiagnoseTCP     = container.DiagnoseTCP
+	DiagnoseHTTP    = container.DiagnoseHTTP
+	DiagnoseSockets = container.DiagnoseSockets
+)
 
 // Cached container info for instant scoped queries (drill-down)
 type PluginInfo struct {
@@ -63,8 +101,47 @@ type PluginInfo struct {
 }
 
 type containerInfoCache struct {
-	Mounts []mountInfoCache
-	NetIDs map[string]bool
+	Mounts    []mountInfoCache
+	NetIDs    map[string]bool
+	Endpoints map[string]endpointInfoCache // network ID -> addresses
+}
+
+type endpointInfoCache struct {
+	IPv4     string
+	IPv6     string
+	Aliases  []string
+	DNSNames []string
+	Gateway  bool
+}
+
+func newEndpointInfo(n *dnetwork.EndpointSettings) endpointInfoCache {
+	return endpointInfoCache{
+		IPv4:     n.IPAddress,
+		IPv6:     n.GlobalIPv6Address,
+		Aliases:  n.Aliases,
+		DNSNames: n.DNSNames,
+		Gateway:  n.Gateway != "" || n.IPv6Gateway != "",
+	}
+}
+
+// newContainerInfo reads the mounts and networks of a listed container.
+func newContainerInfo(c dcontainer.Summary) containerInfoCache {
+	info := containerInfoCache{NetIDs: make(map[string]bool), Endpoints: make(map[string]endpointInfoCache)}
+	for _, m := range c.Mounts {
+		info.Mounts = append(info.Mounts, mountInfoCache{
+			Type:        string(m.Type),
+			Name:        m.Name,
+			Source:      m.Source,
+			Destination: m.Destination,
+		})
+	}
+	if c.NetworkSettings != nil {
+		for _, n := range c.NetworkSettings.Networks {
+			info.NetIDs[n.NetworkID] = true
+			info.Endpoints[n.NetworkID] = newEndpointInfo(n)
+		}
+	}
+	return info
 }
 
 type mountInfoCache struct {
@@ -92,6 +169,7 @@ type DockerClient struct {
 	Stack     *stack.Manager
 	Task      *task.Manager
 	Compose   *compose.Manager
+	System    *system.Manager
 
 	// Resource cache for fast scoped queries and stale-while-revalidate
 	cacheMu             sync.RWMutex
@@ -101,6 +179,8 @@ type DockerClient struct {
 	imageCache          []common.Resource             // Image.List() results
 	serviceCache        []common.Resource             // Service.List() results
 	containerInfoMap    map[string]containerInfoCache // containerID -> mount/network info
+	diskUsage           *system.Usage                 // DiskUsage() snapshot (expensive: walks volumes)
+	diskUsageAt         time.Time
 
 	// Guard against concurrent async refreshes
 	refreshMu  sync.Mutex
@@ -124,10 +204,17 @@ type DockerClient struct {
 }
 
 func NewDockerClient(contextName string, apiTimeout time.Duration, defaultContext string) (*DockerClient, error) {
+	return newDockerClient(contextName, apiTimeout, defaultContext, true)
+}
+
+// newDockerClient opens a client. With processEnv set, the SSH askpass
+// environment of its context is applied to the process, for the docker CLI
+// commands run on the active context; other clients leave it alone.
+func newDockerClient(contextName string, apiTimeout time.Duration, defaultContext string, processEnv bool) (*DockerClient, error) {
 	logger, cleanup := initLogger()
 	defer cleanup()
 
-	ctxName, opts, err := resolveClientOpts(contextName, defaultContext, logger, apiTimeout)
+	ctxName, opts, err := resolveClientOpts(contextName, defaultContext, logger, apiTimeout, processEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -154,6 +241,7 @@ func NewDockerClient(contextName string, apiTimeout time.Duration, defaultContex
 		Stack:            stack.NewManager(cli, ctx, ctxName),
 		Task:             task.NewManager(cli, ctx),
 		Compose:          compose.NewManager(cli, ctx),
+		System:           system.NewManager(cli, ctx),
 		containerInfoMap: make(map[string]containerInfoCache),
 		refreshing:       make(map[string]bool),
 	}, nil
@@ -181,7 +269,7 @@ func initLogger() (*log.Logger, func()) {
 	return log.New(f, "d4s-dao: ", log.LstdFlags), func() { f.Close() }
 }
 
-func resolveClientOpts(flagContext string, defaultContext string, logger *log.Logger, apiTimeout time.Duration) (string, []client.Opt, error) {
+func resolveClientOpts(flagContext string, defaultContext string, logger *log.Logger, apiTimeout time.Duration, processEnv bool) (string, []client.Opt, error) {
 	opts := []client.Opt{
 		client.WithAPIVersionNegotiation(),
 	}
@@ -196,7 +284,7 @@ func resolveClientOpts(flagContext string, defaultContext string, logger *log.Lo
 			return "default", opts, nil
 		}
 		logger.Printf("Explicit context requested via flag: %s", flagContext)
-		opts, err := loadSpecificContext(flagContext, logger, opts, apiTimeout)
+		opts, err := loadSpecificContext(flagContext, logger, opts, apiTimeout, processEnv)
 		return flagContext, opts, err
 	}
 
@@ -214,7 +302,7 @@ func resolveClientOpts(flagContext string, defaultContext string, logger *log.Lo
 			opts = append(opts, client.FromEnv)
 			return "default", opts, nil
 		}
-		opts, err := loadSpecificContext(envCtx, logger, opts, apiTimeout)
+		opts, err := loadSpecificContext(envCtx, logger, opts, apiTimeout, processEnv)
 		return envCtx, opts, err
 	}
 
@@ -226,7 +314,7 @@ func resolveClientOpts(flagContext string, defaultContext string, logger *log.Lo
 			return "default", opts, nil
 		}
 		logger.Printf("Using d4s default context: %s", defaultContext)
-		opts, err := loadSpecificContext(defaultContext, logger, opts, apiTimeout)
+		opts, err := loadSpecificContext(defaultContext, logger, opts, apiTimeout, processEnv)
 		if err == nil {
 			return defaultContext, opts, nil
 		}
@@ -249,11 +337,11 @@ func resolveClientOpts(flagContext string, defaultContext string, logger *log.Lo
 	}
 
 	// 6. Load Specific Context
-	opts, err := loadSpecificContext(targetCtx, logger, opts, apiTimeout)
+	opts, err := loadSpecificContext(targetCtx, logger, opts, apiTimeout, processEnv)
 	return targetCtx, opts, err
 }
 
-func loadSpecificContext(targetCtx string, logger *log.Logger, baseOpts []client.Opt, apiTimeout time.Duration) ([]client.Opt, error) {
+func loadSpecificContext(targetCtx string, logger *log.Logger, baseOpts []client.Opt, apiTimeout time.Duration, processEnv bool) ([]client.Opt, error) {
 	logger.Printf("Loading context: %s", targetCtx)
 
 	s := newContextStore()
@@ -281,17 +369,24 @@ func loadSpecificContext(targetCtx string, logger *log.Logger, baseOpts []client
 	var helper *connhelper.ConnectionHelper
 	if strings.HasPrefix(ep.Host, "ssh://") {
 		creds, _ := secrets.Load(targetCtx)
-		if creds.HasSecret() {
-			// Spawned ssh processes (docker dial-stdio, tunnels) will query
-			// d4s itself via SSH_ASKPASS to obtain the stored secret.
-			secrets.ApplyAskpassEnv(targetCtx)
-		} else {
-			secrets.ApplyAskpassEnv("")
+		if processEnv {
+			// Spawned ssh processes (docker CLI, tunnels) will query d4s
+			// itself via SSH_ASKPASS to obtain the stored secret.
+			if creds.HasSecret() {
+				secrets.ApplyAskpassEnv(targetCtx)
+			} else {
+				secrets.ApplyAskpassEnv("")
+			}
 		}
 		// ControlMaster multiplexes every dial-stdio over one SSH
 		// connection: single handshake, no sshd MaxStartups storms.
 		sshFlags := append(sshutil.ControlMasterArgs(), creds.SSHArgs()...)
 		helper, err = connhelper.GetConnectionHelperWithSSHOpts(ep.Host, sshFlags)
+		if err == nil && helper != nil && creds.HasSecret() {
+			// The dial-stdio ssh gets the askpass environment of this
+			// context on its own command, whatever the process has.
+			helper.Dialer, err = sshutil.DockerDialer(targetCtx, ep.Host, sshFlags)
+		}
 	} else {
 		helper, err = connhelper.GetConnectionHelper(ep.Host)
 	}
@@ -403,13 +498,58 @@ func (d *DockerClient) ListVolumes() ([]common.Resource, error) {
 
 	if cached != nil {
 		d.asyncRefresh("volumes", func() { d.fetchVolumes() })
-		return cached, nil
+		return d.withVolumeUsage(cached), nil
+	}
+
+	vols, err := d.fetchVolumes()
+	if err != nil {
+		return nil, err
+	}
+	return d.withVolumeUsage(vols), nil
+}
+
+// withVolumeUsage returns a copy of vols with the sizes and reference counts
+// of the disk usage snapshot. The snapshot is refreshed in the background
+// when missing or older than diskUsageMaxAge, so sizes show up on a later
+// refresh rather than delaying the list.
+func (d *DockerClient) withVolumeUsage(vols []common.Resource) []common.Resource {
+	d.cacheMu.RLock()
+	du, at := d.diskUsage, d.diskUsageAt
+	d.cacheMu.RUnlock()
+
+	if du == nil || time.Since(at) > diskUsageMaxAge {
+		d.asyncRefresh("diskusage", func() { d.RefreshDiskUsage() })
+	}
+	if du == nil {
+		return vols
+	}
+
+	usage := make(map[string]system.VolumeUsage)
+	for _, r := range du.Volumes() {
+		if u, ok := r.(system.VolumeUsage); ok {
+			usage[u.Name] = u
+		}
+	}
+
+	res := make([]common.Resource, len(vols))
+	for i, r := range vols {
+		if v, ok := r.(volume.Volume); ok {
+			if u, found := usage[v.Name]; found {
+				v.Size, v.RefCount = u.Size, u.RefCount
+			}
+			r = v
+		}
+		res[i] = r
 	}
+	return res
+}
 
-	return d.fetchVolumes()
+// VolumeTopDirectories reports the biggest directories of a volume.
+func (d *DockerClient) VolumeTopDirectories(name, image string) (string, error) {
+	return d.Volume.TopDirectories(name, image)
 }
 
-// fetchVolumes does the actual Docker API calls (expensive: Volume.List + DiskUsage + ContainerList).
+// fetchVolumes does the actual Docker API calls (Volume.List + ContainerList).
 func (d *DockerClient) fetchVolumes() ([]common.Resource, error) {
 	vols, err := d.Volume.List()
 	if err != nil {
@@ -437,26 +577,13 @@ func (d *DockerClient) fetchVolumes() ([]common.Resource, error) {
 				name = c.ID[:12]
 			}
 
-			info := containerInfoCache{NetIDs: make(map[string]bool)}
-
 			for _, m := range c.Mounts {
 				if m.Type == "volume" {
 					usageMap[m.Name] = append(usageMap[m.Name], name)
 				}
-				info.Mounts = append(info.Mounts, mountInfoCache{
-					Type:        string(m.Type),
-					Name:        m.Name,
-					Source:      m.Source,
-					Destination: m.Destination,
-				})
-			}
-			if c.NetworkSettings != nil {
-				for _, n := range c.NetworkSettings.Networks {
-					info.NetIDs[n.NetworkID] = true
-				}
 			}
 
-			infoMap[c.ID] = info
+			infoMap[c.ID] = newContainerInfo(c)
 		}
 
 		d.cacheMu.Lock()
@@ -482,6 +609,50 @@ func (d *DockerClient) fetchVolumes() ([]common.Resource, error) {
 	return vols, nil
 }
 
+// diskUsageMaxAge bounds how often DiskUsage hits the daemon: computing it
+// walks every layer and volume, far too slow for the view refresh rate.
+const diskUsageMaxAge = 30 * time.Second
+
+// DiskUsage returns the cached disk usage snapshot, refreshed in the
+// background once it is older than diskUsageMaxAge. First call is synchronous.
+func (d *DockerClient) DiskUsage() (*system.Usage, error) {
+	d.cacheMu.RLock()
+	cached, at := d.diskUsage, d.diskUsageAt
+	d.cacheMu.RUnlock()
+
+	if cached != nil {
+		if time.Since(at) > diskUsageMaxAge {
+			d.asyncRefresh("diskusage", func() { d.RefreshDiskUsage() })
+		}
+		return cached, nil
+	}
+
+	return d.RefreshDiskUsage()
+}
+
+// RefreshDiskUsage fetches a fresh disk usage snapshot.
+func (d *DockerClient) RefreshDiskUsage() (*system.Usage, error) {
+	du, err := d.System.DiskUsage()
+	if err != nil {
+		return nil, err
+	}
+	d.cacheMu.Lock()
+	d.diskUsage = du
+	d.diskUsageAt = time.Now()
+	d.cacheMu.Unlock()
+	return du, nil
+}
+
+// PruneBuildCache removes build cache records and returns the reclaimed
+// space and the number of records deleted.
+func (d *DockerClient) PruneBuildCache(all bool, until string) (uint64, int, error) {
+	report, err := d.System.PruneBuildCache(all, until)
+	if err != nil {
+		return 0, 0, err
+	}
+	return report.SpaceReclaimed, len(report.CachesDeleted), nil
+}
+
 // ListNetworks returns cached networks immediately if available,
 // then triggers an async refresh in the background. First call is synchronous.
 func (d *DockerClient) ListNetworks() ([]common.Resource, error) {
@@ -562,9 +733,16 @@ func (d *DockerClient) ListNodes() ([]common.Resource, error) {
 }
 
 func (d *DockerClient) ListCompose() ([]common.Resource, error) {
+	d.ensureComposeTarget()
 	return d.Compose.List()
 }
 
+// SetComposeWorkspaces sets the directories scanned for compose projects
+// that have no container yet (or anymore).
+func (d *DockerClient) SetComposeWorkspaces(roots []string, depth int) {
+	d.Compose.SetWorkspaces(roots, depth)
+}
+
 func (d *DockerClient) ListSecrets() ([]common.Resource, error) {
 	return d.Secret.List()
 }
@@ -582,26 +760,43 @@ func (d *DockerClient) RestartContainer(id string) error {
 	return d.Container.Restart(id)
 }
 
+func (d *DockerClient) RecreateContainer(id string) (string, error) {
+	return d.Container.Recreate(id)
+}
+
 func (d *DockerClient) RemoveContainer(id string, force bool) error {
 	return d.Container.Remove(id, force)
 }
 
-func (d *DockerClient) PruneContainers() error {
-	return d.Container.Prune()
+func (d *DockerClient) PruneContainersCandidates() ([]common.PruneCandidate, error) {
+	return d.Container.PruneCandidates()
 }
 
 func (d *DockerClient) RemoveImage(id string, force bool) error {
 	return d.Image.Remove(id, force)
 }
 
-func (d *DockerClient) PruneImages() error {
-	return d.Image.Prune()
+func (d *DockerClient) PruneImagesCandidates() ([]common.PruneCandidate, error) {
+	return d.Image.PruneCandidates()
 }
 
 func (d *DockerClient) PullImage(tag string) error {
 	return d.Image.Pull(tag)
 }
 
+func (d *DockerClient) ListImageRepoInfo() ([]ImageRepoInfo, error) {
+	return d.Image.ListRepoInfo()
+}
+
+func (d *DockerClient) InspectImage(ref string) (dimage.InspectResponse, error) {
+	return d.Image.Inspect(ref)
+}
+
+// NewRegistryClient opens a client for a Docker Registry HTTP API v2 endpoint.
+func NewRegistryClient(endpoint string, insecure bool) (*RegistryClient, error) {
+	return registry.NewClient(endpoint, insecure)
+}
+
 func (d *DockerClient) CreateVolume(name string) error {
 	return d.Volume.Create(name)
 }
@@ -610,20 +805,87 @@ func (d *DockerClient) RemoveVolume(id string, force bool) error {
 	return d.Volume.Remove(id, force)
 }
 
-func (d *DockerClient) PruneVolumes() error {
-	return d.Volume.Prune()
+func (d *DockerClient) ListVolumeFiles(volume, dir, image string) ([]common.Resource, error) {
+	return d.Volume.ListFiles(volume, dir, image)
 }
 
-func (d *DockerClient) CreateNetwork(name string) error {
-	return d.Network.Create(name)
+// ReadVolumeFile returns the content of a file of a volume, up to limit bytes.
+func (d *DockerClient) ReadVolumeFile(volume, file, image string, limit int64) ([]byte, error) {
+	return d.Volume.ReadFile(volume, file, image, limit)
+}
+
+func (d *DockerClient) DeleteVolumeFile(volume, file, image string) error {
+	defer d.Volume.ForgetFiles(volume)
+	return d.Volume.DeleteFile(volume, file, image)
+}
+
+func (d *DockerClient) PruneVolumesCandidates() ([]common.PruneCandidate, error) {
+	return d.Volume.PruneCandidates()
+}
+
+func (d *DockerClient) CreateNetwork(spec NetworkSpec) error {
+	return d.Network.Create(spec)
+}
+
+// ListNetworkEndpoints lists the containers and swarm endpoints of a network.
+// The aliases and gateways of the containers come from the container info
+// cache, refreshed from a single container list when it misses one of them.
+func (d *DockerClient) ListNetworkEndpoints(id string) ([]common.Resource, error) {
+	refreshed := false
+	lookup := func(containerID, networkID string) (network.ContainerEndpoint, bool) {
+		info, ok := d.cachedEndpoints(containerID, networkID)
+		if !ok && !refreshed {
+			refreshed = true
+			d.refreshNetworkContainers(networkID)
+			info, ok = d.cachedEndpoints(containerID, networkID)
+		}
+		if !ok {
+			return network.ContainerEndpoint{}, false
+		}
+
+		ep := info.Endpoints[networkID]
+		ce := network.ContainerEndpoint{Aliases: ep.Aliases, DNSNames: ep.DNSNames}
+		for _, e := range info.Endpoints {
+			if e.Gateway {
+				ce.Gateway = true
+			}
+		}
+		return ce, true
+	}
+	return d.Network.Endpoints(id, lookup)
+}
+
+// cachedEndpoints returns the cached info of a container attached to networkID.
+func (d *DockerClient) cachedEndpoints(containerID, networkID string) (containerInfoCache, bool) {
+	d.cacheMu.RLock()
+	info, ok := d.containerInfoMap[containerID]
+	d.cacheMu.RUnlock()
+	return info, ok && info.NetIDs[networkID]
+}
+
+// refreshNetworkContainers refreshes the cached info of the containers
+// attached to a network.
+func (d *DockerClient) refreshNetworkContainers(networkID string) {
+	containers, err := d.Cli.ContainerList(d.Ctx, dcontainer.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("network", networkID)),
+	})
+	if err != nil {
+		return
+	}
+	d.cacheMu.Lock()
+	for _, c := range containers {
+		d.containerInfoMap[c.ID] = newContainerInfo(c)
+	}
+	d.cacheMu.Unlock()
 }
 
 func (d *DockerClient) RemoveNetwork(id string) error {
 	return d.Network.Remove(id)
 }
 
-func (d *DockerClient) ConnectNetwork(networkID, containerID string) error {
-	err := d.Network.Connect(networkID, containerID)
+func (d *DockerClient) ConnectNetwork(networkID, containerID string, endpoint *NetworkEndpoint) error {
+	err := d.Network.Connect(networkID, containerID, endpoint)
 	if err == nil {
 		d.invalidateContainerInfoCache(containerID)
 	}
@@ -644,8 +906,8 @@ func (d *DockerClient) invalidateContainerInfoCache(containerID string) {
 	d.cacheMu.Unlock()
 }
 
-func (d *DockerClient) PruneNetworks() error {
-	return d.Network.Prune()
+func (d *DockerClient) PruneNetworksCandidates() ([]common.PruneCandidate, error) {
+	return d.Network.PruneCandidates()
 }
 
 func (d *DockerClient) ScaleService(id string, replicas uint64) error {
@@ -889,31 +1151,131 @@ func (d *DockerClient) StopComposeProject(projectName string) error {
 	return d.Compose.Stop(projectName)
 }
 
-func (d *DockerClient) UpComposeProject(projectName string) error {
+func (d *DockerClient) UpComposeProject(projectName string, opts ComposeUpOptions) (*CmdRun, error) {
+	d.ensureComposeTarget()
+	return d.Compose.Up(projectName, opts)
+}
+
+func (d *DockerClient) RedeployComposeProject(projectName string, opts ComposeUpOptions) (*CmdRun, error) {
 	d.ensureComposeTarget()
-	return d.Compose.Up(projectName)
+	return d.Compose.Redeploy(projectName, opts)
 }
 
-func (d *DockerClient) RedeployComposeProject(projectName string) error {
+func (d *DockerClient) BuildComposeProject(projectName string, opts ComposeUpOptions) (*CmdRun, error) {
 	d.ensureComposeTarget()
-	return d.Compose.Redeploy(projectName)
+	return d.Compose.Build(projectName, opts)
 }
 
-func (d *DockerClient) BuildComposeProject(projectName string) error {
+// ComposeUpChoices lists the profiles, env files and override files that
+// can be used when bringing a project up, and the recent combinations.
+func (d *DockerClient) ComposeUpChoices(projectName string) (*ComposeUpChoices, error) {
 	d.ensureComposeTarget()
-	return d.Compose.Build(projectName)
+	return d.Compose.UpChoices(projectName)
 }
 
-func (d *DockerClient) DownComposeProject(projectName string) error {
+func (d *DockerClient) DownComposeProject(projectName string) (*CmdRun, error) {
 	d.ensureComposeTarget()
 	return d.Compose.Down(projectName)
 }
 
+// ComposeWatchStep builds the `docker compose watch` command of a project,
+// for the watch manager to run.
+func (d *DockerClient) ComposeWatchStep(projectName string) (common.CmdStep, error) {
+	d.ensureComposeTarget()
+	return d.Compose.WatchStep(projectName)
+}
+
+// ComposeLastRun returns the last up/build/down run of a project, if any.
+func (d *DockerClient) ComposeLastRun(projectName string) *CmdRun {
+	return d.Compose.LastRun(projectName)
+}
+
 func (d *DockerClient) GetComposeConfig(projectName string) (string, error) {
 	d.ensureComposeTarget()
 	return d.Compose.GetConfig(projectName)
 }
 
+// ComposeProjectDrift returns the drift check of a compose project, to
+// tell which of its containers no longer match the compose files.
+func (d *DockerClient) ComposeProjectDrift(projectName string) compose.DriftCheck {
+	d.ensureComposeTarget()
+	return d.Compose.ProjectDrift(projectName)
+}
+
+// InvalidateComposeModel forgets the resolved compose files of a project,
+// e.g. after they were edited.
+func (d *DockerClient) InvalidateComposeModel(projectName string) {
+	d.Compose.InvalidateModel(projectName)
+}
+
+func (d *DockerClient) ComposeDriftReport(projectName, service string) (string, error) {
+	d.ensureComposeTarget()
+	return d.Compose.DriftReport(projectName, service)
+}
+
+// ComposeTopology returns the dependency graph of a project's services.
+func (d *DockerClient) ComposeTopology(projectName string) (*ComposeTopology, error) {
+	d.ensureComposeTarget()
+	return d.Compose.Topology(projectName)
+}
+
+// ListComposeJobs lists the one-shot and exited containers of compose projects.
+func (d *DockerClient) ListComposeJobs() ([]common.Resource, error) {
+	d.ensureComposeTarget()
+	return d.Compose.Jobs()
+}
+
+// RerunComposeJob runs a compose job again, following its output.
+func (d *DockerClient) RerunComposeJob(id string) (*CmdRun, error) {
+	d.ensureComposeTarget()
+	return d.Compose.RerunJob(id)
+}
+
+func (d *DockerClient) ListComposeServices(projectName string) ([]common.Resource, error) {
+	d.ensureComposeTarget()
+	return d.Compose.ListServices(projectName)
+}
+
+func (d *DockerClient) DescribeComposeService(projectName, service string) (string, error) {
+	d.ensureComposeTarget()
+	return d.Compose.DescribeService(projectName, service)
+}
+
+func (d *DockerClient) StartComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.StartService(projectName, service)
+}
+
+func (d *DockerClient) StopComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.StopService(projectName, service)
+}
+
+func (d *DockerClient) RestartComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.RestartService(projectName, service)
+}
+
+func (d *DockerClient) PullComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.PullService(projectName, service)
+}
+
+func (d *DockerClient) BuildComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.BuildService(projectName, service)
+}
+
+func (d *DockerClient) RecreateComposeService(projectName, service string) error {
+	d.ensureComposeTarget()
+	return d.Compose.RecreateService(projectName, service)
+}
+
+func (d *DockerClient) ScaleComposeService(projectName, service string, replicas int) error {
+	d.ensureComposeTarget()
+	return d.Compose.ScaleService(projectName, service, replicas)
+}
+
 // Common/Stats wrappers
 func (d *DockerClient) GetHostStats() (common.HostStats, error) {
 	d.hostStatsMu.Lock()
@@ -953,6 +1315,32 @@ func (d *DockerClient) GetContainerEnv(id string) ([]string, error) {
 	return d.Container.GetEnv(id)
 }
 
+func (d *DockerClient) ExportContainersCompose(ids []string) (string, error) {
+	return d.Container.ExportCompose(ids)
+}
+
+// ContainerDebugRun returns the docker run arguments of a debug container
+// for the target, and notes on what it cannot share.
+func (d *DockerClient) ContainerDebugRun(id, image string) ([]string, []string, error) {
+	return d.Container.DebugRun(id, image)
+}
+
+// ContainerDiagnose runs a network diagnostic from the network namespace
+// of a container.
+func (d *DockerClient) ContainerDiagnose(id, image, kind string, args ...string) (string, error) {
+	return d.Container.Diagnose(id, image, kind, args...)
+}
+
+// ContainerCapture streams a packet capture of a container's network into
+// w, until count packets are captured or ctx is done.
+func (d *DockerClient) ContainerCapture(ctx context.Context, id, image, filter string, count int, w io.Writer, status func(string)) error {
+	return d.Container.Capture(ctx, id, image, filter, count, w, status)
+}
+
+func (d *DockerClient) ContainerRunCommand(id string) (string, error) {
+	return d.Container.RunCommand(id)
+}
+
 func (d *DockerClient) HasTTY(id string) (bool, error) {
 	return common.HasTTY(d.Cli, d.Ctx, id)
 }
@@ -1074,7 +1462,7 @@ func (d *DockerClient) ListVolumesForContainer(id string) ([]common.Resource, er
 		if err != nil {
 			return nil, err
 		}
-		info = containerInfoCache{NetIDs: make(map[string]bool)}
+		info = containerInfoCache{NetIDs: make(map[string]bool), Endpoints: make(map[string]endpointInfoCache)}
 		for _, m := range cj.Mounts {
 			info.Mounts = append(info.Mounts, mountInfoCache{
 				Type:        string(m.Type),
@@ -1086,6 +1474,7 @@ func (d *DockerClient) ListVolumesForContainer(id string) ([]common.Resource, er
 		if cj.NetworkSettings != nil {
 			for _, n := range cj.NetworkSettings.Networks {
 				info.NetIDs[n.NetworkID] = true
+				info.Endpoints[n.NetworkID] = newEndpointInfo(n)
 			}
 		}
 		d.cacheMu.Lock()
@@ -1245,10 +1634,11 @@ func (d *DockerClient) ListNetworksForContainer(id string) ([]common.Resource, e
 		if err != nil {
 			return nil, err
 		}
-		info = containerInfoCache{NetIDs: make(map[string]bool)}
+		info = containerInfoCache{NetIDs: make(map[string]bool), Endpoints: make(map[string]endpointInfoCache)}
 		if cj.NetworkSettings != nil {
 			for _, n := range cj.NetworkSettings.Networks {
 				info.NetIDs[n.NetworkID] = true
+				info.Endpoints[n.NetworkID] = newEndpointInfo(n)
 			}
 		}
 		for _, m := range cj.Mounts {
@@ -0,0 +1,151 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"golang.org/x/net/context"
+)
+
+// captureScript runs tcpdump, installing it first on Alpine based images
+// that lack it. Its options come as positional parameters.
+const captureScript = `if ! command -v tcpdump >/dev/null 2>&1 && command -v apk >/dev/null 2>&1; then
+	echo "installing tcpdump..." >&2
+	apk add --no-cache -q tcpdump >&2
+fi
+command -v tcpdump >/dev/null 2>&1 || { echo "no tcpdump in the image" >&2; exit 127; }
+exec tcpdump -i any -U -w - "$@"`
+
+// Capture runs tcpdump from a helper container of image in the network
+// namespace of the target and streams the packets into w, in pcap format.
+// The stream goes through the attach API, so the capture file is written
+// locally whatever the context. With count > 0 the capture stops after
+// count packets; it also stops when ctx is done. The messages of tcpdump
+// are passed to status line by line.
+func (m *Manager) Capture(ctx context.Context, id, image, filter string, count int, w io.Writer, status func(string)) error {
+	cj, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return err
+	}
+	if cj.State == nil || !cj.State.Running {
+		return fmt.Errorf("%s is not running", strings.TrimPrefix(cj.Name, "/"))
+	}
+
+	cmd := []string{"sh", "-c", captureScript, "sh"}
+	if count > 0 {
+		cmd = append(cmd, "-c", strconv.Itoa(count))
+	}
+	if filter = strings.TrimSpace(filter); filter != "" {
+		cmd = append(cmd, filter)
+	}
+	hid, err := common.CreateHelper(m.cli, m.ctx, "d4s-capture", "capture", &container.Config{
+		Image:        image,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + cj.ID),
+		CapAdd:      []string{"NET_ADMIN", "NET_RAW"},
+	})
+	if err != nil {
+		return err
+	}
+	defer common.RemoveHelper(m.cli, hid)
+
+	// Attach before starting, so that no packet is lost
+	resp, err := m.cli.ContainerAttach(m.ctx, hid, container.AttachOptions{Stream: true, Stdout: true, Stderr: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	waitCh, errCh := m.cli.ContainerWait(context.Background(), hid, container.WaitConditionNextExit)
+	if err := m.cli.ContainerStart(m.ctx, hid, container.StartOptions{}); err != nil {
+		return err
+	}
+
+	// tcpdump flushes its file and reports its counts on SIGINT
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := m.cli.ContainerKill(context.Background(), hid, "SIGINT"); err != nil {
+				resp.Close()
+			}
+		case <-stopped:
+		}
+	}()
+
+	stderr := &lineWriter{fn: status}
+	_, copyErr := stdcopy.StdCopy(w, stderr, resp.Reader)
+	stderr.Flush()
+	if copyErr != nil {
+		// Nothing reads tcpdump anymore (broken stream or unwritable file)
+		_ = m.cli.ContainerKill(context.Background(), hid, "SIGKILL")
+	}
+
+	var code int64
+	select {
+	case res := <-waitCh:
+		if res.Error != nil {
+			return fmt.Errorf("%s", res.Error.Message)
+		}
+		code = res.StatusCode
+	case err := <-errCh:
+		return err
+	}
+	if copyErr != nil && ctx.Err() == nil {
+		return copyErr
+	}
+	if code != 0 {
+		if stderr.last != "" {
+			return fmt.Errorf("%s (exit code %d)", stderr.last, code)
+		}
+		return fmt.Errorf("tcpdump exited with code %d", code)
+	}
+	return nil
+}
+
+// lineWriter passes what is written to it to fn, line by line.
+type lineWriter struct {
+	fn   func(string)
+	buf  []byte
+	last string
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.line(string(l.buf[:i]))
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *lineWriter) Flush() {
+	if len(l.buf) > 0 {
+		l.line(string(l.buf))
+		l.buf = nil
+	}
+}
+
+func (l *lineWriter) line(s string) {
+	if s = strings.TrimSpace(s); s == "" {
+		return
+	}
+	l.last = s
+	if l.fn != nil {
+		l.fn(s)
+	}
+}
@@ -3,6 +3,8 @@ package container
 import (
 	"fmt"
 	"io"
+	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -12,6 +14,8 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao/common"
@@ -54,24 +58,26 @@ func (m *Manager) SetMinStatsInterval(d time.Duration) {
 
 // Container Model
 type Container struct {
-	ID          string
-	Names       string
-	Image       string
-	ImageID     string
-	Status      string
-	State       string
-	Health      string
-	Age         string
-	Ports       string
-	Created     string
-	Compose     string
-	ProjectName string
-	ServiceName string
-	CPU         string
-	Mem         string
-	IP          string
-	Cmd         string
-	Networks    map[string]string
+	ID             string
+	Names          string
+	Image          string
+	ImageID        string
+	Status         string
+	State          string
+	Health         string
+	Age            string
+	Ports          string
+	Created        string
+	Compose        string
+	ProjectName    string
+	ServiceName    string
+	ComposeService string
+	CPU            string
+	Mem            string
+	IP             string
+	Cmd            string
+	Networks       map[string]string
+	Labels         map[string]string
 }
 
 func (c Container) GetID() string { return c.ID }
@@ -263,6 +269,7 @@ func (m *Manager) List() ([]common.Resource, error) {
 
 		projectName := c.Labels["com.docker.compose.project"]
 		serviceName := c.Labels["com.docker.swarm.service.name"]
+		composeService := c.Labels["com.docker.compose.service"]
 
 		status, age, health := common.ParseStatus(c.Status)
 
@@ -312,24 +319,26 @@ func (m *Manager) List() ([]common.Resource, error) {
 		}
 
 		res[i] = Container{
-			ID:          c.ID,
-			Names:       name,
-			Image:       imageName,
-			ImageID:     strings.TrimPrefix(c.ImageID, "sha256:"),
-			Status:      status,
-			Age:         age,
-			State:       c.State,
-			Health:      health,
-			Ports:       ports,
-			Created:     common.FormatTime(c.Created),
-			Compose:     compose,
-			ProjectName: projectName,
-			ServiceName: serviceName,
-			CPU:         cpuStr,
-			Mem:         memStr,
-			IP:          ip,
-			Cmd:         cmd,
-			Networks:    networks,
+			ID:             c.ID,
+			Names:          name,
+			Image:          imageName,
+			ImageID:        strings.TrimPrefix(c.ImageID, "sha256:"),
+			Status:         status,
+			Age:            age,
+			State:          c.State,
+			Health:         health,
+			Ports:          ports,
+			Created:        common.FormatTime(c.Created),
+			Compose:        compose,
+			ProjectName:    projectName,
+			ServiceName:    serviceName,
+			ComposeService: composeService,
+			CPU:            cpuStr,
+			Mem:            memStr,
+			IP:             ip,
+			Cmd:            cmd,
+			Networks:       networks,
+			Labels:         c.Labels,
 		}
 	}
 	return res, nil
@@ -353,9 +362,195 @@ func (m *Manager) Remove(id string, force bool) error {
 	return m.cli.ContainerRemove(m.ctx, id, container.RemoveOptions{Force: force})
 }
 
-func (m *Manager) Prune() error {
-	_, err := m.cli.ContainersPrune(m.ctx, filters.NewArgs())
-	return err
+// stripImageConfig clears from cfg what the container inherited from img,
+// like compose does when it recreates a container on a new image.
+func stripImageConfig(cfg, img *container.Config, imageExposed map[string]bool) {
+	cfg.Env = without(cfg.Env, img.Env)
+	if slices.Equal(cfg.Entrypoint, img.Entrypoint) {
+		cfg.Entrypoint = nil
+		if slices.Equal(cfg.Cmd, img.Cmd) {
+			cfg.Cmd = nil
+		}
+	}
+	if cfg.User == img.User {
+		cfg.User = ""
+	}
+	if cfg.WorkingDir == img.WorkingDir {
+		cfg.WorkingDir = ""
+	}
+	if cfg.StopSignal == img.StopSignal {
+		cfg.StopSignal = ""
+	}
+	if reflect.DeepEqual(cfg.Healthcheck, img.Healthcheck) {
+		cfg.Healthcheck = nil
+	}
+	if slices.Equal(cfg.OnBuild, img.OnBuild) {
+		cfg.OnBuild = nil
+	}
+	if slices.Equal(cfg.Shell, img.Shell) {
+		cfg.Shell = nil
+	}
+	for k, v := range cfg.Labels {
+		if iv, ok := img.Labels[k]; ok && iv == v {
+			delete(cfg.Labels, k)
+		}
+	}
+	for v := range cfg.Volumes {
+		if _, ok := img.Volumes[v]; ok {
+			delete(cfg.Volumes, v)
+		}
+	}
+	for p := range cfg.ExposedPorts {
+		if imageExposed[string(p)] {
+			delete(cfg.ExposedPorts, p)
+		}
+	}
+}
+
+// Recreate replaces the container with a new one built from the same
+// configuration, picking up whatever image its reference now points to
+// locally. The old container is only removed once the new one is up; on
+// failure it is renamed back and restarted.
+func (m *Manager) Recreate(id string) (string, error) {
+	old, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimPrefix(old.Name, "/")
+	if old.Config == nil || old.HostConfig == nil {
+		return "", fmt.Errorf("no configuration found for %s", name)
+	}
+	wasRunning := old.State != nil && old.State.Running
+
+	cfg := old.Config
+	if len(old.ID) >= 12 && cfg.Hostname == old.ID[:12] {
+		// Docker defaults the hostname to the short ID: let the new one pick its own.
+		cfg.Hostname = ""
+	}
+	// Only keep what was set on the container, so that the new image brings
+	// its own env, command, labels... The old image may be gone, then the
+	// whole configuration is kept.
+	img, imageExposed := m.imageConfig(old.Image)
+	stripImageConfig(cfg, &img, imageExposed)
+
+	hostCfg := old.HostConfig
+	// Keep anonymous volumes: mount them by name in the new container.
+	declared := make(map[string]bool)
+	for _, b := range hostCfg.Binds {
+		if parts := strings.Split(b, ":"); len(parts) >= 2 {
+			declared[parts[1]] = true
+		}
+	}
+	for _, mt := range hostCfg.Mounts {
+		declared[mt.Target] = true
+	}
+	for _, mt := range old.Mounts {
+		if mt.Type == mount.TypeVolume && mt.Name != "" && !declared[mt.Destination] {
+			bind := mt.Name + ":" + mt.Destination
+			if !mt.RW {
+				bind += ":ro"
+			}
+			hostCfg.Binds = append(hostCfg.Binds, bind)
+		}
+	}
+
+	primary := string(hostCfg.NetworkMode)
+	if primary == "default" {
+		primary = "bridge"
+	}
+	endpoints := make(map[string]*network.EndpointSettings)
+	if old.NetworkSettings != nil {
+		for netName, ep := range old.NetworkSettings.Networks {
+			endpoints[netName] = &network.EndpointSettings{
+				IPAMConfig: ep.IPAMConfig,
+				Links:      ep.Links,
+				Aliases:    common.UserAliases(ep.Aliases, name, old.ID),
+				DriverOpts: ep.DriverOpts,
+			}
+		}
+	}
+
+	netCfg := &network.NetworkingConfig{}
+	if ep, ok := endpoints[primary]; ok {
+		netCfg.EndpointsConfig = map[string]*network.EndpointSettings{primary: ep}
+	}
+
+	if wasRunning {
+		// Without a stop timeout of its own, the daemon default applies.
+		if err := m.cli.ContainerStop(m.ctx, old.ID, container.StopOptions{Timeout: cfg.StopTimeout}); err != nil {
+			return "", err
+		}
+	}
+
+	backup := fmt.Sprintf("%s_d4s-old-%d", name, time.Now().Unix())
+	if err := m.cli.ContainerRename(m.ctx, old.ID, backup); err != nil {
+		return "", err
+	}
+
+	rollback := func(newID string, cause error) (string, error) {
+		if newID != "" {
+			_ = m.cli.ContainerRemove(m.ctx, newID, container.RemoveOptions{Force: true})
+		}
+		_ = m.cli.ContainerRename(m.ctx, old.ID, name)
+		if wasRunning {
+			_ = m.cli.ContainerStart(m.ctx, old.ID, container.StartOptions{})
+		}
+		return "", cause
+	}
+
+	created, err := m.cli.ContainerCreate(m.ctx, cfg, hostCfg, netCfg, nil, name)
+	if err != nil {
+		return rollback("", err)
+	}
+
+	for netName, ep := range endpoints {
+		if netName == primary {
+			continue
+		}
+		if err := m.cli.NetworkConnect(m.ctx, netName, created.ID, ep); err != nil {
+			return rollback(created.ID, fmt.Errorf("failed to connect network %s: %v", netName, err))
+		}
+	}
+
+	if wasRunning {
+		if err := m.cli.ContainerStart(m.ctx, created.ID, container.StartOptions{}); err != nil {
+			return rollback(created.ID, err)
+		}
+	}
+
+	if err := m.cli.ContainerRemove(m.ctx, old.ID, container.RemoveOptions{}); err != nil {
+		return created.ID, fmt.Errorf("new container started, but failed to remove %s: %v", backup, err)
+	}
+	return created.ID, nil
+}
+
+// PruneCandidates lists the containers a prune would remove, the stopped
+// ones, along with the size of their writable layer.
+func (m *Manager) PruneCandidates() ([]common.PruneCandidate, error) {
+	args := filters.NewArgs()
+	for _, status := range []string{"created", "exited", "dead"} {
+		args.Add("status", status)
+	}
+	list, err := m.cli.ContainerList(m.ctx, container.ListOptions{All: true, Size: true, Filters: args})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]common.PruneCandidate, 0, len(list))
+	for _, c := range list {
+		name := c.ID[:12]
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		res = append(res, common.PruneCandidate{
+			ID:     c.ID,
+			Name:   name,
+			Size:   c.SizeRw,
+			Detail: fmt.Sprintf("%s, %s", c.Image, c.Status),
+		})
+	}
+	return res, nil
 }
 
 func (m *Manager) Logs(id string, since string, tail string, timestamps bool) (io.ReadCloser, error) {
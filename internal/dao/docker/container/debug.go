@@ -0,0 +1,62 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jr-k/d4s/internal/dao/common"
+)
+
+// DebugPrefix names the debug containers, to clean up leftovers.
+const DebugPrefix = "d4s-debug-"
+
+// debugShell starts the best shell the debug image has.
+const debugShell = `if command -v bash >/dev/null 2>&1; then bash; else sh; fi; printf "\nReturning to d4s, please wait...\n"`
+
+// DebugRun returns the `docker run` arguments of a throwaway container of
+// image that joins the namespaces of the target, the way `kubectl debug`
+// does: network, PID (its processes are visible, and their filesystems
+// under /proc/<pid>/root) and IPC when the target shares it. The target's
+// volumes are mounted read-only at the same paths. Notes tell what could
+// not be shared.
+func (m *Manager) DebugRun(id, image string) ([]string, []string, error) {
+	cj, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := strings.TrimPrefix(cj.Name, "/")
+	args := []string{"run", "--rm", "-it",
+		"--name", fmt.Sprintf("%s%d", DebugPrefix, time.Now().UnixNano()),
+		"--label", common.HelperLabel + "=debug",
+		"--volumes-from", cj.ID + ":ro",
+	}
+	var notes []string
+
+	if cj.State != nil && cj.State.Running {
+		args = append(args,
+			"--network", "container:"+cj.ID,
+			"--pid", "container:"+cj.ID,
+			"--cap-add", "SYS_PTRACE",
+		)
+		if cj.HostConfig != nil && (cj.HostConfig.IpcMode.IsShareable() || cj.HostConfig.IpcMode.IsHost()) {
+			args = append(args, "--ipc", "container:"+cj.ID)
+		} else {
+			notes = append(notes, fmt.Sprintf("%s does not share its IPC namespace (ipc mode %q)", name, ipcMode(cj.HostConfig)))
+		}
+	} else {
+		notes = append(notes, fmt.Sprintf("%s is not running: only its volumes are available", name))
+	}
+
+	args = append(args, image, "sh", "-c", debugShell)
+	return args, notes, nil
+}
+
+func ipcMode(host *container.HostConfig) string {
+	if host == nil || host.IpcMode == "" {
+		return "private"
+	}
+	return string(host.IpcMode)
+}
@@ -0,0 +1,249 @@
+package container
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jr-k/d4s/internal/dao/common"
+)
+
+// Diagnostics run by Diagnose.
+const (
+	DiagnoseDNS     = "dns"
+	DiagnoseTCP     = "tcp"
+	DiagnoseHTTP    = "http"
+	DiagnoseSockets = "sockets"
+)
+
+// diagnoseTimer prints elapsed times when the date of the image knows %N.
+const diagnoseTimer = `now() { t=$(date +%s%N 2>/dev/null); case "$t" in ''|*N*) echo 0 ;; *) echo $((t / 1000000)) ;; esac; }
+took() { [ "$1" -gt 0 ] && echo "time: $(( $(now) - $1 )) ms"; }
+`
+
+// diagnoseScripts take their input as positional parameters, so that it is
+// never interpreted by the shell.
+var diagnoseScripts = map[string]string{
+	DiagnoseDNS: diagnoseTimer + `echo "resolv.conf:"
+sed 's/^/  /' /etc/resolv.conf 2>/dev/null
+echo
+start=$(now)
+if command -v nslookup >/dev/null 2>&1; then
+	nslookup "$1" 2>&1
+elif command -v getent >/dev/null 2>&1; then
+	getent ahosts "$1" 2>&1
+else
+	echo "no nslookup or getent in the image"; exit 127
+fi
+rc=$?
+took $start
+[ $rc -eq 0 ] || { echo "cannot resolve $1"; exit 1; }`,
+
+	DiagnoseTCP: diagnoseTimer + `start=$(now)
+if command -v nc >/dev/null 2>&1; then
+	nc -z -w 5 "$1" "$2" </dev/null 2>&1
+elif command -v bash >/dev/null 2>&1; then
+	timeout 5 bash -c 'exec 3<>"/dev/tcp/$0/$1"' "$1" "$2" 2>&1
+else
+	echo "no nc or bash in the image"; exit 127
+fi
+rc=$?
+took $start
+[ $rc -eq 0 ] || { echo "cannot connect to $1:$2"; exit 1; }
+echo "connected to $1:$2"`,
+
+	DiagnoseHTTP: diagnoseTimer + `if command -v curl >/dev/null 2>&1; then
+	curl -sS -o /dev/null --max-time 10 -w 'status:     %{http_code}\nremote:     %{remote_ip}:%{remote_port}\ndns:        %{time_namelookup}s\nconnect:    %{time_connect}s\ntls:        %{time_appconnect}s\nfirst byte: %{time_starttransfer}s\ntotal:      %{time_total}s\nsize:       %{size_download} bytes\n' "$1" 2>&1
+elif command -v wget >/dev/null 2>&1; then
+	start=$(now)
+	wget -S -T 10 -O /dev/null "$1" 2>&1
+	rc=$?
+	took $start
+	exit $rc
+else
+	echo "no curl or wget in the image"; exit 127
+fi`,
+
+	// Sockets are read from /proc rather than with ss or netstat, which
+	// the image may not have. The socket inodes of each process name the
+	// owners of the listening sockets.
+	DiagnoseSockets: `for f in tcp tcp6 udp udp6; do
+	echo "# $f"
+	cat /proc/net/$f 2>/dev/null
+done
+echo "# owners"
+for p in /proc/[0-9]*; do
+	pid=${p#/proc/}
+	[ "$pid" = "$$" ] && continue
+	comm=$(cat $p/comm 2>/dev/null)
+	for fd in $p/fd/*; do
+		l=$(readlink "$fd" 2>/dev/null) || continue
+		case "$l" in socket:*) echo "$pid $l $comm" ;; esac
+	done
+done`,
+}
+
+// Diagnose runs a network diagnostic from a helper container of image that
+// joins the network namespace of the target, so that names, routes and
+// firewall rules are the ones the target sees:
+//   - dns: resolve args[0]
+//   - tcp: connect to args[0] on port args[1]
+//   - http: GET the URL args[0], with the status and timings
+//   - sockets: list the listening sockets and their processes
+//
+// A failed probe is part of the report; errors are for the helper itself.
+func (m *Manager) Diagnose(id, image, kind string, args ...string) (string, error) {
+	script, ok := diagnoseScripts[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown diagnostic %q", kind)
+	}
+	cj, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if cj.State == nil || !cj.State.Running {
+		return "", fmt.Errorf("%s is not running", strings.TrimPrefix(cj.Name, "/"))
+	}
+
+	host := &container.HostConfig{NetworkMode: container.NetworkMode("container:" + cj.ID)}
+	if kind == DiagnoseSockets {
+		// Share the PID namespace to see the processes owning the sockets
+		host.PidMode = container.PidMode("container:" + cj.ID)
+		host.CapAdd = []string{"SYS_PTRACE"}
+	}
+	hid, err := common.CreateHelper(m.cli, m.ctx, "d4s-diag-"+kind, "diagnose", &container.Config{
+		Image: image,
+		Cmd:   append([]string{"sh", "-c", script, "sh"}, args...),
+	}, host)
+	if err != nil {
+		return "", err
+	}
+	defer common.RemoveHelper(m.cli, hid)
+
+	out, err := common.RunHelper(m.cli, m.ctx, hid)
+	if err != nil && strings.TrimSpace(out) == "" {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Container: %s\n", strings.TrimPrefix(cj.Name, "/"))
+	switch kind {
+	case DiagnoseDNS:
+		fmt.Fprintf(&sb, "Lookup:    %s\n\n", args[0])
+	case DiagnoseTCP:
+		fmt.Fprintf(&sb, "Connect:   %s:%s\n\n", args[0], args[1])
+	case DiagnoseHTTP:
+		fmt.Fprintf(&sb, "GET:       %s\n\n", args[0])
+	case DiagnoseSockets:
+		sb.WriteString("\n")
+		sb.WriteString(listeningSockets(out))
+		return sb.String(), nil
+	}
+	sb.WriteString(strings.TrimRight(out, "\n"))
+	sb.WriteString("\n\n")
+	if err != nil {
+		fmt.Fprintf(&sb, "Result: FAILED (%v)\n", err)
+	} else {
+		sb.WriteString("Result: OK\n")
+	}
+	return sb.String(), nil
+}
+
+// listeningSockets formats the TCP sockets in LISTEN state and the bound
+// UDP sockets found in the /proc dump of the sockets script.
+func listeningSockets(out string) string {
+	type socket struct {
+		proto string
+		addr  netip.AddrPort
+		inode string
+	}
+	var sockets []socket
+	owners := make(map[string][]string)
+
+	section := ""
+	for _, line := range strings.Split(out, "\n") {
+		if name, ok := strings.CutPrefix(line, "# "); ok {
+			section = name
+			continue
+		}
+		fields := strings.Fields(line)
+		if section == "owners" {
+			if len(fields) < 2 {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(fields[1], "socket:["), "]")
+			owner := fields[0]
+			if len(fields) > 2 {
+				owner += "/" + strings.Join(fields[2:], " ")
+			}
+			if !slices.Contains(owners[inode], owner) {
+				owners[inode] = append(owners[inode], owner)
+			}
+			continue
+		}
+		// sl local_address rem_address st tx:rx tr:when retrnsmt uid timeout inode
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+		listening := "0A" // TCP_LISTEN
+		if strings.HasPrefix(section, "udp") {
+			listening = "07" // unconnected
+		}
+		if fields[3] != listening {
+			continue
+		}
+		addr, err := procAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, socket{proto: section, addr: addr, inode: fields[9]})
+	}
+
+	if len(sockets) == 0 {
+		return "No listening sockets.\n"
+	}
+	sort.SliceStable(sockets, func(i, j int) bool {
+		if sockets[i].addr.Port() != sockets[j].addr.Port() {
+			return sockets[i].addr.Port() < sockets[j].addr.Port()
+		}
+		return sockets[i].proto < sockets[j].proto
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-6s %-40s %s\n", "PROTO", "LOCAL ADDRESS", "PROCESS")
+	for _, s := range sockets {
+		process := "-"
+		if o := owners[s.inode]; len(o) > 0 {
+			process = strings.Join(o, ", ")
+		}
+		fmt.Fprintf(&sb, "%-6s %-40s %s\n", s.proto, s.addr, process)
+	}
+	return sb.String()
+}
+
+// procAddr decodes an address of /proc/net: the IP in host (little
+// endian) order by 32-bit words, and the port in hexadecimal.
+func procAddr(s string) (netip.AddrPort, error) {
+	ipHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	raw, err := hex.DecodeString(ipHex)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	ip, _ := netip.AddrFromSlice(raw)
+	return netip.AddrPortFrom(ip.Unmap(), uint16(port)), nil
+}
@@ -0,0 +1,268 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"gopkg.in/yaml.v3"
+)
+
+type composeFile struct {
+	Services map[string]composeService  `yaml:"services"`
+	Networks map[string]composeExternal `yaml:"networks,omitempty"`
+	Volumes  map[string]composeExternal `yaml:"volumes,omitempty"`
+}
+
+// composeExternal refers to a network or volume that already exists.
+type composeExternal struct {
+	External bool `yaml:"external"`
+}
+
+type composeService struct {
+	Image         string                    `yaml:"image"`
+	ContainerName string                    `yaml:"container_name,omitempty"`
+	Hostname      string                    `yaml:"hostname,omitempty"`
+	Entrypoint    []string                  `yaml:"entrypoint,omitempty,flow"`
+	Command       []string                  `yaml:"command,omitempty,flow"`
+	User          string                    `yaml:"user,omitempty"`
+	WorkingDir    string                    `yaml:"working_dir,omitempty"`
+	Environment   []string                  `yaml:"environment,omitempty"`
+	Ports         []string                  `yaml:"ports,omitempty"`
+	Expose        []string                  `yaml:"expose,omitempty"`
+	Volumes       []string                  `yaml:"volumes,omitempty"`
+	Tmpfs         []string                  `yaml:"tmpfs,omitempty"`
+	NetworkMode   string                    `yaml:"network_mode,omitempty"`
+	Networks      map[string]composeNetwork `yaml:"networks,omitempty"`
+	ExtraHosts    []string                  `yaml:"extra_hosts,omitempty"`
+	DNS           []string                  `yaml:"dns,omitempty"`
+	Restart       string                    `yaml:"restart,omitempty"`
+	Healthcheck   *composeHealthcheck       `yaml:"healthcheck,omitempty"`
+	Labels        map[string]string         `yaml:"labels,omitempty"`
+	StopSignal    string                    `yaml:"stop_signal,omitempty"`
+	Tty           bool                      `yaml:"tty,omitempty"`
+	StdinOpen     bool                      `yaml:"stdin_open,omitempty"`
+	Init          bool                      `yaml:"init,omitempty"`
+	Privileged    bool                      `yaml:"privileged,omitempty"`
+	ReadOnly      bool                      `yaml:"read_only,omitempty"`
+	CapAdd        []string                  `yaml:"cap_add,omitempty"`
+	CapDrop       []string                  `yaml:"cap_drop,omitempty"`
+	Devices       []string                  `yaml:"devices,omitempty"`
+	CPUs          string                    `yaml:"cpus,omitempty"`
+	CPUShares     int64                     `yaml:"cpu_shares,omitempty"`
+	Cpuset        string                    `yaml:"cpuset,omitempty"`
+	MemLimit      string                    `yaml:"mem_limit,omitempty"`
+	MemReserve    string                    `yaml:"mem_reservation,omitempty"`
+	MemswapLimit  string                    `yaml:"memswap_limit,omitempty"`
+	PidsLimit     int64                     `yaml:"pids_limit,omitempty"`
+	ShmSize       string                    `yaml:"shm_size,omitempty"`
+}
+
+type composeNetwork struct {
+	Aliases     []string `yaml:"aliases,omitempty"`
+	IPv4Address string   `yaml:"ipv4_address,omitempty"`
+	IPv6Address string   `yaml:"ipv6_address,omitempty"`
+}
+
+type composeHealthcheck struct {
+	Test          []string `yaml:"test,omitempty,flow"`
+	Disable       bool     `yaml:"disable,omitempty"`
+	Interval      string   `yaml:"interval,omitempty"`
+	Timeout       string   `yaml:"timeout,omitempty"`
+	Retries       int      `yaml:"retries,omitempty"`
+	StartPeriod   string   `yaml:"start_period,omitempty"`
+	StartInterval string   `yaml:"start_interval,omitempty"`
+}
+
+var invalidServiceChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// ExportCompose writes a compose file that recreates the given containers,
+// one service each. Networks and named volumes they use are referenced as
+// external since they already exist.
+func (m *Manager) ExportCompose(ids []string) (string, error) {
+	file := composeFile{Services: make(map[string]composeService)}
+
+	for _, id := range ids {
+		s, err := m.spec(id)
+		if err != nil {
+			return "", err
+		}
+
+		name := s.Service
+		if name == "" {
+			name = s.Name
+		}
+		name = strings.Trim(invalidServiceChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+		if name == "" {
+			name = "service"
+		}
+		for i, base := 2, name; ; i++ {
+			if _, taken := file.Services[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		svc := composeService{
+			Image:        s.Image,
+			Hostname:     s.Hostname,
+			Entrypoint:   s.Entrypoint,
+			Command:      s.Command,
+			User:         s.User,
+			WorkingDir:   s.WorkingDir,
+			Environment:  s.Env,
+			Ports:        s.Ports,
+			Expose:       s.Expose,
+			Tmpfs:        s.Tmpfs,
+			NetworkMode:  s.NetworkMode,
+			ExtraHosts:   s.ExtraHosts,
+			DNS:          s.DNS,
+			Restart:      s.Restart,
+			Healthcheck:  composeHealth(s.Healthcheck),
+			Labels:       s.Labels,
+			StopSignal:   s.StopSignal,
+			Tty:          s.Tty,
+			StdinOpen:    s.StdinOpen,
+			Init:         s.Init,
+			Privileged:   s.Privileged,
+			ReadOnly:     s.ReadOnly,
+			CapAdd:       s.CapAdd,
+			CapDrop:      s.CapDrop,
+			Devices:      s.Devices,
+			CPUs:         s.CPUs,
+			CPUShares:    s.CPUShares,
+			Cpuset:       s.CpusetCpus,
+			MemLimit:     formatSize(s.Memory),
+			MemReserve:   formatSize(s.MemoryReservation),
+			MemswapLimit: formatSize(s.MemorySwap),
+			PidsLimit:    s.PidsLimit,
+			ShmSize:      formatSize(s.ShmSize),
+		}
+		// Compose names its containers itself; keep the name of those that
+		// were started by hand.
+		if s.Service == "" {
+			svc.ContainerName = s.Name
+		}
+
+		for _, mt := range s.Mounts {
+			volume := mt.Target
+			if mt.Source != "" {
+				volume = mt.Source + ":" + mt.Target
+			}
+			if mt.ReadOnly {
+				volume += ":ro"
+			}
+			svc.Volumes = append(svc.Volumes, volume)
+			if mt.Type == mount.TypeVolume && mt.Source != "" {
+				if file.Volumes == nil {
+					file.Volumes = make(map[string]composeExternal)
+				}
+				file.Volumes[mt.Source] = composeExternal{External: true}
+			}
+		}
+
+		for _, n := range s.Networks {
+			if svc.Networks == nil {
+				svc.Networks = make(map[string]composeNetwork)
+			}
+			svc.Networks[n.Name] = composeNetwork{Aliases: escapeDollarsAll(n.Aliases), IPv4Address: n.IPv4, IPv6Address: n.IPv6}
+			if file.Networks == nil {
+				file.Networks = make(map[string]composeExternal)
+			}
+			file.Networks[n.Name] = composeExternal{External: true}
+		}
+
+		escapeService(&svc)
+		file.Services[name] = svc
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// escapeService doubles the $ of the values of a service, which compose
+// would otherwise take for variables to interpolate.
+func escapeService(svc *composeService) {
+	for _, v := range []*string{&svc.Image, &svc.ContainerName, &svc.Hostname, &svc.User, &svc.WorkingDir, &svc.NetworkMode, &svc.StopSignal} {
+		*v = escapeDollars(*v)
+	}
+	for _, list := range []*[]string{&svc.Entrypoint, &svc.Command, &svc.Environment, &svc.Ports, &svc.Expose, &svc.Volumes,
+		&svc.Tmpfs, &svc.ExtraHosts, &svc.DNS, &svc.CapAdd, &svc.CapDrop, &svc.Devices} {
+		*list = escapeDollarsAll(*list)
+	}
+	if svc.Healthcheck != nil {
+		svc.Healthcheck.Test = escapeDollarsAll(svc.Healthcheck.Test)
+	}
+	for k, v := range svc.Labels {
+		svc.Labels[k] = escapeDollars(v)
+	}
+}
+
+func escapeDollars(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// escapeDollarsAll escapes a copy of list, which may belong to the inspect
+// data.
+func escapeDollarsAll(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	res := make([]string, len(list))
+	for i, s := range list {
+		res[i] = escapeDollars(s)
+	}
+	return res
+}
+
+func composeHealth(h *container.HealthConfig) *composeHealthcheck {
+	if h == nil {
+		return nil
+	}
+	if len(h.Test) > 0 && h.Test[0] == "NONE" {
+		return &composeHealthcheck{Disable: true}
+	}
+	return &composeHealthcheck{
+		Test:          h.Test,
+		Interval:      formatDuration(h.Interval),
+		Timeout:       formatDuration(h.Timeout),
+		Retries:       h.Retries,
+		StartPeriod:   formatDuration(h.StartPeriod),
+		StartInterval: formatDuration(h.StartInterval),
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// formatSize writes a byte count the way it is usually given to docker:
+// 512m rather than 536870912.
+func formatSize(n int64) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n%(1<<30) == 0:
+		return fmt.Sprintf("%dg", n>>30)
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dm", n>>20)
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dk", n>>10)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,228 @@
+package container
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes an argument for a POSIX shell when it needs it.
+func shellQuote(arg string) string {
+	if arg != "" && shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// RunCommand writes the `docker run` invocation that recreates the
+// container, one option per line. Networks beyond the first are joined
+// with `docker network connect` afterwards.
+func (m *Manager) RunCommand(id string) (string, error) {
+	s, err := m.spec(id)
+	if err != nil {
+		return "", err
+	}
+
+	var args [][]string
+	add := func(a ...string) { args = append(args, a) }
+
+	run := []string{"-d"}
+	if s.StdinOpen {
+		run = append(run, "-i")
+	}
+	if s.Tty {
+		run = append(run, "-t")
+	}
+	add(run...)
+	add("--name", s.Name)
+	if s.Hostname != "" {
+		add("--hostname", s.Hostname)
+	}
+	if s.Restart != "" {
+		add("--restart", s.Restart)
+	}
+	if s.User != "" {
+		add("--user", s.User)
+	}
+	if s.WorkingDir != "" {
+		add("--workdir", s.WorkingDir)
+	}
+
+	// --entrypoint takes a single word: the rest goes before the command
+	command := s.Command
+	if len(s.Entrypoint) > 0 {
+		add("--entrypoint", s.Entrypoint[0])
+		command = append(append([]string{}, s.Entrypoint[1:]...), s.Command...)
+	}
+
+	for _, p := range s.Ports {
+		add("-p", p)
+	}
+	for _, p := range s.Expose {
+		add("--expose", p)
+	}
+	for _, e := range s.Env {
+		add("-e", e)
+	}
+	for _, mt := range s.Mounts {
+		volume := mt.Target
+		if mt.Source != "" {
+			volume = mt.Source + ":" + mt.Target
+		}
+		if mt.ReadOnly {
+			volume += ":ro"
+		}
+		add("-v", volume)
+	}
+	for _, t := range s.Tmpfs {
+		add("--tmpfs", t)
+	}
+
+	if s.NetworkMode != "" {
+		add("--network", s.NetworkMode)
+	}
+	var connects []string
+	for i, n := range s.Networks {
+		if i == 0 {
+			add("--network", n.Name)
+			for _, a := range n.Aliases {
+				add("--network-alias", a)
+			}
+			if n.IPv4 != "" {
+				add("--ip", n.IPv4)
+			}
+			if n.IPv6 != "" {
+				add("--ip6", n.IPv6)
+			}
+			continue
+		}
+		connect := []string{"docker", "network", "connect"}
+		for _, a := range n.Aliases {
+			connect = append(connect, "--alias", shellQuote(a))
+		}
+		if n.IPv4 != "" {
+			connect = append(connect, "--ip", n.IPv4)
+		}
+		if n.IPv6 != "" {
+			connect = append(connect, "--ip6", n.IPv6)
+		}
+		connect = append(connect, shellQuote(n.Name), shellQuote(s.Name))
+		connects = append(connects, strings.Join(connect, " "))
+	}
+	for _, h := range s.ExtraHosts {
+		add("--add-host", h)
+	}
+	for _, d := range s.DNS {
+		add("--dns", d)
+	}
+
+	if h := s.Healthcheck; h != nil {
+		switch {
+		case len(h.Test) > 0 && h.Test[0] == "NONE":
+			add("--no-healthcheck")
+		case len(h.Test) > 1 && h.Test[0] == "CMD-SHELL":
+			add("--health-cmd", h.Test[1])
+		case len(h.Test) > 1 && h.Test[0] == "CMD":
+			quoted := make([]string, 0, len(h.Test)-1)
+			for _, t := range h.Test[1:] {
+				quoted = append(quoted, shellQuote(t))
+			}
+			add("--health-cmd", strings.Join(quoted, " "))
+		}
+		if h.Interval > 0 {
+			add("--health-interval", h.Interval.String())
+		}
+		if h.Timeout > 0 {
+			add("--health-timeout", h.Timeout.String())
+		}
+		if h.Retries > 0 {
+			add("--health-retries", fmt.Sprintf("%d", h.Retries))
+		}
+		if h.StartPeriod > 0 {
+			add("--health-start-period", h.StartPeriod.String())
+		}
+		if h.StartInterval > 0 {
+			add("--health-start-interval", h.StartInterval.String())
+		}
+	}
+
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add("--label", k+"="+s.Labels[k])
+	}
+	if s.StopSignal != "" {
+		add("--stop-signal", s.StopSignal)
+	}
+
+	if s.Init {
+		add("--init")
+	}
+	if s.Privileged {
+		add("--privileged")
+	}
+	if s.ReadOnly {
+		add("--read-only")
+	}
+	for _, c := range s.CapAdd {
+		add("--cap-add", c)
+	}
+	for _, c := range s.CapDrop {
+		add("--cap-drop", c)
+	}
+	for _, d := range s.Devices {
+		add("--device", d)
+	}
+	if s.CPUs != "" {
+		add("--cpus", s.CPUs)
+	}
+	if s.CPUShares > 0 {
+		add("--cpu-shares", fmt.Sprintf("%d", s.CPUShares))
+	}
+	if s.CpusetCpus != "" {
+		add("--cpuset-cpus", s.CpusetCpus)
+	}
+	if s.Memory > 0 {
+		add("--memory", formatSize(s.Memory))
+	}
+	if s.MemoryReservation > 0 {
+		add("--memory-reservation", formatSize(s.MemoryReservation))
+	}
+	if s.MemorySwap > 0 {
+		add("--memory-swap", formatSize(s.MemorySwap))
+	}
+	if s.PidsLimit > 0 {
+		add("--pids-limit", fmt.Sprintf("%d", s.PidsLimit))
+	}
+	if s.ShmSize > 0 {
+		add("--shm-size", formatSize(s.ShmSize))
+	}
+
+	image := []string{s.Image}
+	add(append(image, command...)...)
+
+	lines := make([]string, 0, len(args))
+	for _, a := range args {
+		quoted := make([]string, len(a))
+		for i, arg := range a {
+			quoted[i] = shellQuote(arg)
+		}
+		lines = append(lines, strings.Join(quoted, " "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("docker run ")
+	sb.WriteString(strings.Join(lines, " \\\n  "))
+	sb.WriteString("\n")
+	for _, c := range connects {
+		sb.WriteString(c)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
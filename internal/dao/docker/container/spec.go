@@ -0,0 +1,350 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/jr-k/d4s/internal/dao/common"
+)
+
+// spec is what a container was created with, minus what it inherits from
+// its image and what the daemon sets by default: the options one would
+// have to give again to get the same container.
+type spec struct {
+	Name    string
+	Service string // compose service the container belongs to, if any
+	Image   string
+
+	Entrypoint []string
+	Command    []string
+	Env        []string
+	User       string
+	WorkingDir string
+	Hostname   string
+	Labels     map[string]string
+	StopSignal string
+	Tty        bool
+	StdinOpen  bool
+
+	Ports  []string // [ip:]host:container[/proto], or container[/proto] for a random host port
+	Expose []string
+	Mounts []specMount
+	Tmpfs  []string
+
+	NetworkMode string // host, none or container:<name>; empty when on networks
+	Networks    []specNetwork
+	ExtraHosts  []string
+	DNS         []string
+
+	Restart     string
+	Healthcheck *container.HealthConfig
+
+	CPUs              string
+	CPUShares         int64
+	CpusetCpus        string
+	Memory            int64
+	MemoryReservation int64
+	MemorySwap        int64
+	PidsLimit         int64
+	ShmSize           int64
+
+	Privileged bool
+	ReadOnly   bool
+	Init       bool
+	CapAdd     []string
+	CapDrop    []string
+	Devices    []string
+}
+
+type specMount struct {
+	Type     mount.Type
+	Source   string // volume name or host path; empty for an anonymous volume
+	Target   string
+	ReadOnly bool
+}
+
+type specNetwork struct {
+	Name    string
+	Aliases []string
+	IPv4    string
+	IPv6    string
+}
+
+// imageConfig returns the configuration containers inherit from an image
+// and the ports it exposes. Both are empty when the image is gone.
+func (m *Manager) imageConfig(ref string) (container.Config, map[string]bool) {
+	var img container.Config
+	exposed := make(map[string]bool)
+	inspect, err := m.cli.ImageInspect(m.ctx, ref)
+	if err != nil || inspect.Config == nil {
+		return img, exposed
+	}
+	ic := inspect.Config
+	img = container.Config{
+		User:        ic.User,
+		Env:         ic.Env,
+		Entrypoint:  ic.Entrypoint,
+		Cmd:         ic.Cmd,
+		WorkingDir:  ic.WorkingDir,
+		Labels:      ic.Labels,
+		Volumes:     ic.Volumes,
+		StopSignal:  ic.StopSignal,
+		Healthcheck: ic.Healthcheck,
+		OnBuild:     ic.OnBuild,
+		Shell:       ic.Shell,
+	}
+	for p := range ic.ExposedPorts {
+		exposed[p] = true
+	}
+	return img, exposed
+}
+
+// defaultShmSize is the /dev/shm size the daemon gives containers.
+const defaultShmSize = 64 << 20
+
+func (m *Manager) spec(id string) (*spec, error) {
+	c, err := m.cli.ContainerInspect(m.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Config == nil || c.HostConfig == nil {
+		return nil, fmt.Errorf("container %s has no config", id)
+	}
+
+	// The image may be gone: then nothing is stripped as inherited.
+	img, imageExposed := m.imageConfig(c.Image)
+
+	cfg, host := c.Config, c.HostConfig
+	s := &spec{
+		Name:       strings.TrimPrefix(c.Name, "/"),
+		Service:    cfg.Labels["com.docker.compose.service"],
+		Image:      cfg.Image,
+		Env:        without(cfg.Env, img.Env),
+		Tty:        cfg.Tty,
+		StdinOpen:  cfg.OpenStdin,
+		ExtraHosts: host.ExtraHosts,
+		DNS:        host.DNS,
+		Privileged: host.Privileged,
+		ReadOnly:   host.ReadonlyRootfs,
+		Init:       host.Init != nil && *host.Init,
+		CapAdd:     host.CapAdd,
+		CapDrop:    host.CapDrop,
+	}
+
+	// Overriding the entrypoint drops the image command as well.
+	if !slices.Equal(cfg.Entrypoint, img.Entrypoint) && len(cfg.Entrypoint) > 0 {
+		s.Entrypoint = cfg.Entrypoint
+		s.Command = cfg.Cmd
+	} else if !slices.Equal(cfg.Cmd, img.Cmd) {
+		s.Command = cfg.Cmd
+	}
+	if cfg.User != img.User {
+		s.User = cfg.User
+	}
+	if cfg.WorkingDir != img.WorkingDir {
+		s.WorkingDir = cfg.WorkingDir
+	}
+	if cfg.StopSignal != img.StopSignal {
+		s.StopSignal = cfg.StopSignal
+	}
+	if cfg.Hostname != "" && !strings.HasPrefix(c.ID, cfg.Hostname) && !host.NetworkMode.IsHost() && !host.NetworkMode.IsContainer() {
+		s.Hostname = cfg.Hostname
+	}
+	if cfg.Healthcheck != nil && !reflect.DeepEqual(cfg.Healthcheck, img.Healthcheck) {
+		s.Healthcheck = cfg.Healthcheck
+	}
+
+	for k, v := range cfg.Labels {
+		if strings.HasPrefix(k, "com.docker.compose.") || strings.HasPrefix(k, "desktop.docker.io/") {
+			continue
+		}
+		if iv, ok := img.Labels[k]; ok && iv == v {
+			continue
+		}
+		if s.Labels == nil {
+			s.Labels = make(map[string]string)
+		}
+		s.Labels[k] = v
+	}
+
+	published := make(map[string]bool)
+	for port, bindings := range host.PortBindings {
+		published[string(port)] = true
+		containerPort := strings.TrimSuffix(string(port), "/tcp")
+		for _, b := range bindings {
+			switch {
+			case b.HostPort == "" && (b.HostIP == "" || b.HostIP == "0.0.0.0"):
+				s.Ports = append(s.Ports, containerPort)
+			case b.HostIP == "" || b.HostIP == "0.0.0.0":
+				s.Ports = append(s.Ports, b.HostPort+":"+containerPort)
+			case strings.Contains(b.HostIP, ":"):
+				s.Ports = append(s.Ports, "["+b.HostIP+"]:"+b.HostPort+":"+containerPort)
+			default:
+				s.Ports = append(s.Ports, b.HostIP+":"+b.HostPort+":"+containerPort)
+			}
+		}
+	}
+	sort.Strings(s.Ports)
+	for port := range cfg.ExposedPorts {
+		if imageExposed[string(port)] || published[string(port)] {
+			continue
+		}
+		s.Expose = append(s.Expose, strings.TrimSuffix(string(port), "/tcp"))
+	}
+	sort.Strings(s.Expose)
+
+	s.Mounts, s.Tmpfs = specMounts(c.Mounts, host, img.Volumes)
+
+	s.NetworkMode, s.Networks = m.specNetworks(c)
+
+	switch p := host.RestartPolicy; {
+	case p.Name == "" || p.Name == container.RestartPolicyDisabled:
+	case p.Name == container.RestartPolicyOnFailure && p.MaximumRetryCount > 0:
+		s.Restart = fmt.Sprintf("%s:%d", p.Name, p.MaximumRetryCount)
+	default:
+		s.Restart = string(p.Name)
+	}
+
+	r := host.Resources
+	switch {
+	case r.NanoCPUs > 0:
+		s.CPUs = strconv.FormatFloat(float64(r.NanoCPUs)/1e9, 'f', -1, 64)
+	case r.CPUQuota > 0 && r.CPUPeriod > 0:
+		s.CPUs = strconv.FormatFloat(float64(r.CPUQuota)/float64(r.CPUPeriod), 'f', -1, 64)
+	}
+	s.CPUShares = r.CPUShares
+	s.CpusetCpus = r.CpusetCpus
+	s.Memory = r.Memory
+	s.MemoryReservation = r.MemoryReservation
+	if r.MemorySwap > 0 {
+		s.MemorySwap = r.MemorySwap
+	}
+	if r.PidsLimit != nil && *r.PidsLimit > 0 {
+		s.PidsLimit = *r.PidsLimit
+	}
+	if host.ShmSize != 0 && host.ShmSize != defaultShmSize {
+		s.ShmSize = host.ShmSize
+	}
+	for _, d := range r.Devices {
+		dev := d.PathOnHost
+		if d.PathInContainer != "" && d.PathInContainer != d.PathOnHost {
+			dev += ":" + d.PathInContainer
+		}
+		if d.CgroupPermissions != "" && d.CgroupPermissions != "rwm" {
+			if d.PathInContainer == d.PathOnHost {
+				dev += ":" + d.PathInContainer
+			}
+			dev += ":" + d.CgroupPermissions
+		}
+		s.Devices = append(s.Devices, dev)
+	}
+	return s, nil
+}
+
+// without drops the entries of list that are also in inherited.
+func without(list, inherited []string) []string {
+	skip := make(map[string]bool, len(inherited))
+	for _, e := range inherited {
+		skip[e] = true
+	}
+	var res []string
+	for _, e := range list {
+		if !skip[e] {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// specMounts lists the bind mounts and volumes of a container. Anonymous
+// volumes the image declares are left out: they come back by themselves.
+func specMounts(points []container.MountPoint, host *container.HostConfig, imageVolumes map[string]struct{}) ([]specMount, []string) {
+	named := make(map[string]bool)
+	for _, b := range host.Binds {
+		if parts := strings.Split(b, ":"); len(parts) >= 2 {
+			named[parts[0]] = true
+		}
+	}
+	for _, mt := range host.Mounts {
+		named[mt.Source] = true
+	}
+
+	var mounts []specMount
+	var tmpfs []string
+	for _, mp := range points {
+		switch mp.Type {
+		case mount.TypeBind:
+			mounts = append(mounts, specMount{Type: mp.Type, Source: mp.Source, Target: mp.Destination, ReadOnly: !mp.RW})
+		case mount.TypeVolume:
+			source := mp.Name
+			if !named[source] {
+				if _, ok := imageVolumes[mp.Destination]; ok {
+					continue
+				}
+				source = ""
+			}
+			mounts = append(mounts, specMount{Type: mp.Type, Source: source, Target: mp.Destination, ReadOnly: !mp.RW})
+		case mount.TypeTmpfs:
+			tmpfs = append(tmpfs, mp.Destination)
+		}
+	}
+	for target, opts := range host.Tmpfs {
+		if opts != "" {
+			target += ":" + opts
+		}
+		tmpfs = append(tmpfs, target)
+	}
+
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Target < mounts[j].Target })
+	sort.Strings(tmpfs)
+	return mounts, tmpfs
+}
+
+// specNetworks tells how the container is networked. The default bridge
+// is left out, as is everything docker adds on its own to an endpoint.
+func (m *Manager) specNetworks(c container.InspectResponse) (string, []specNetwork) {
+	mode := c.HostConfig.NetworkMode
+	switch {
+	case mode.IsHost(), mode.IsNone():
+		return string(mode), nil
+	case mode.IsContainer():
+		ref := mode.ConnectedContainer()
+		if other, err := m.cli.ContainerInspect(m.ctx, ref); err == nil {
+			ref = strings.TrimPrefix(other.Name, "/")
+		}
+		return "container:" + ref, nil
+	}
+
+	if c.NetworkSettings == nil {
+		return "", nil
+	}
+	name := strings.TrimPrefix(c.Name, "/")
+	service := c.Config.Labels["com.docker.compose.service"]
+
+	var networks []specNetwork
+	for netName, ep := range c.NetworkSettings.Networks {
+		if netName == network.NetworkBridge {
+			continue
+		}
+		n := specNetwork{Name: netName}
+		for _, a := range common.UserAliases(ep.Aliases, name, c.ID) {
+			if a != service {
+				n.Aliases = append(n.Aliases, a)
+			}
+		}
+		if ep.IPAMConfig != nil {
+			n.IPv4 = ep.IPAMConfig.IPv4Address
+			n.IPv6 = ep.IPAMConfig.IPv6Address
+		}
+		networks = append(networks, n)
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].Name < networks[j].Name })
+	return "", networks
+}
@@ -1,6 +1,7 @@
 package image
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -40,6 +41,14 @@ type Image struct {
 	Size       string
 	Created    string
 	Containers int64
+	Update     string
+}
+
+// RepoInfo is the registry identity of a local image.
+type RepoInfo struct {
+	ID          string
+	RepoTags    []string
+	RepoDigests []string
 }
 
 func (i Image) GetID() string { return i.ID }
@@ -48,7 +57,7 @@ func (i Image) GetCells() []string {
 	if i.Containers <= 0 {
 		containersStr = ""
 	}
-	return []string{i.ID[:12], i.Tags, i.Size, containersStr, i.Created}
+	return []string{i.ID[:12], i.Tags, i.Size, containersStr, i.Created, i.Update}
 }
 
 func (i Image) GetStatusColor() (tcell.Color, tcell.Color) {
@@ -70,6 +79,8 @@ func (i Image) GetColumnValue(column string) string {
 		return fmt.Sprintf("%d", i.Containers)
 	case "created":
 		return i.Created
+	case "update":
+		return i.Update
 	}
 	return ""
 }
@@ -186,12 +197,113 @@ func (m *Manager) List() ([]common.Resource, error) {
 	return res, nil
 }
 
+// ListRepoInfo returns the tags and repo digests of every local image.
+func (m *Manager) ListRepoInfo() ([]RepoInfo, error) {
+	list, err := m.cli.ImageList(m.ctx, image.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]RepoInfo, 0, len(list))
+	for _, i := range list {
+		res = append(res, RepoInfo{
+			ID:          strings.TrimPrefix(i.ID, "sha256:"),
+			RepoTags:    i.RepoTags,
+			RepoDigests: i.RepoDigests,
+		})
+	}
+	return res, nil
+}
+
+func (m *Manager) Inspect(ref string) (image.InspectResponse, error) {
+	return m.cli.ImageInspect(m.ctx, ref)
+}
+
 func (m *Manager) Remove(id string, force bool) error {
 	_, err := m.cli.ImageRemove(m.ctx, id, image.RemoveOptions{Force: force, PruneChildren: true})
 	return err
 }
 
-func (m *Manager) Prune() error {
-	_, err := m.cli.ImagesPrune(m.ctx, filters.NewArgs())
+// PruneCandidates lists the images a prune would remove: dangling images
+// only, as `docker image prune` without -a. Images still used
+// by a container are kept by the daemon and left out; sizes exclude layers
+// shared with other images.
+func (m *Manager) PruneCandidates() ([]common.PruneCandidate, error) {
+	list, err := m.cli.ImageList(m.ctx, image.ListOptions{Filters: filters.NewArgs(filters.Arg("dangling", "true")), SharedSize: true, ContainerCount: true})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]common.PruneCandidate, 0, len(list))
+	for _, i := range list {
+		if i.Containers > 0 {
+			continue
+		}
+		size := i.Size
+		if i.SharedSize > 0 {
+			size -= i.SharedSize
+		}
+		name := "<none>"
+		if len(i.RepoDigests) > 0 {
+			name = i.RepoDigests[0]
+		}
+		res = append(res, common.PruneCandidate{
+			ID:     i.ID,
+			Name:   fmt.Sprintf("%s %s", strings.TrimPrefix(i.ID, "sha256:")[:12], name),
+			Size:   size,
+			Detail: fmt.Sprintf("created %s", common.FormatTime(i.Created)),
+		})
+	}
+	return res, nil
+}
+
+// Save streams refs as a `docker save` tar archive into w.
+func (m *Manager) Save(refs []string, w io.Writer) error {
+	reader, err := m.cli.ImageSave(m.ctx, refs)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(w, reader)
 	return err
 }
+
+// Load imports a `docker save` tar archive read from r and returns the
+// references reported by the daemon (e.g. "nginx:latest").
+func (m *Manager) Load(r io.Reader) ([]string, error) {
+	resp, err := m.cli.ImageLoad(m.ctx, r, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var loaded []string
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Stream      string `json:"stream"`
+			ErrorDetail *struct {
+				Message string `json:"message"`
+			} `json:"errorDetail"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return loaded, err
+		}
+		if msg.ErrorDetail != nil {
+			return loaded, fmt.Errorf("%s", msg.ErrorDetail.Message)
+		}
+		for line := range strings.SplitSeq(msg.Stream, "\n") {
+			line = strings.TrimSpace(line)
+			for _, prefix := range []string{"Loaded image: ", "Loaded image ID: "} {
+				if ref, ok := strings.CutPrefix(line, prefix); ok {
+					loaded = append(loaded, ref)
+				}
+			}
+		}
+	}
+	return loaded, nil
+}
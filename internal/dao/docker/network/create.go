@@ -0,0 +1,179 @@
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+// Drivers are the network drivers offered when creating a network.
+var Drivers = []string{"bridge", "overlay", "macvlan", "ipvlan"}
+
+// AddressPool is the IPAM configuration of one address family.
+type AddressPool struct {
+	Subnet  string
+	Gateway string
+	IPRange string
+}
+
+func (p AddressPool) empty() bool {
+	return p.Subnet == "" && p.Gateway == "" && p.IPRange == ""
+}
+
+// CreateSpec describes a network to create.
+type CreateSpec struct {
+	Name       string
+	Driver     string
+	IPv4       AddressPool
+	IPv6       AddressPool
+	Internal   bool
+	Attachable bool
+	Options    map[string]string
+	Labels     map[string]string
+}
+
+// ipamConfig checks an address pool of the given family and converts it
+// for the API. The subnet is returned to check overlaps.
+func ipamConfig(family string, p AddressPool, is4 bool) (*network.IPAMConfig, netip.Prefix, error) {
+	if p.Subnet == "" {
+		return nil, netip.Prefix{}, fmt.Errorf("%s gateway and IP range need a subnet", family)
+	}
+	subnet, err := netip.ParsePrefix(p.Subnet)
+	if err != nil {
+		return nil, netip.Prefix{}, fmt.Errorf("invalid %s subnet %q", family, p.Subnet)
+	}
+	if subnet.Addr().Is4() != is4 {
+		return nil, netip.Prefix{}, fmt.Errorf("%s is not an %s subnet", p.Subnet, family)
+	}
+	if subnet.Masked() != subnet {
+		return nil, netip.Prefix{}, fmt.Errorf("%s is not a network address, did you mean %s?", p.Subnet, subnet.Masked())
+	}
+
+	if p.Gateway != "" {
+		gw, err := netip.ParseAddr(p.Gateway)
+		if err != nil {
+			return nil, netip.Prefix{}, fmt.Errorf("invalid %s gateway %q", family, p.Gateway)
+		}
+		if !subnet.Contains(gw) {
+			return nil, netip.Prefix{}, fmt.Errorf("gateway %s is outside of %s", p.Gateway, p.Subnet)
+		}
+	}
+	if p.IPRange != "" {
+		r, err := netip.ParsePrefix(p.IPRange)
+		if err != nil {
+			return nil, netip.Prefix{}, fmt.Errorf("invalid %s IP range %q", family, p.IPRange)
+		}
+		if r.Bits() < subnet.Bits() || !subnet.Contains(r.Addr()) {
+			return nil, netip.Prefix{}, fmt.Errorf("IP range %s is outside of %s", p.IPRange, p.Subnet)
+		}
+	}
+
+	return &network.IPAMConfig{Subnet: p.Subnet, Gateway: p.Gateway, IPRange: p.IPRange}, subnet, nil
+}
+
+// overlapping finds an existing network whose subnets overlap one of
+// subnets, and returns a description of the clash.
+func (m *Manager) overlapping(subnets []netip.Prefix) (string, error) {
+	list, err := m.cli.NetworkList(m.ctx, network.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, n := range list {
+		for _, conf := range n.IPAM.Config {
+			existing, err := netip.ParsePrefix(conf.Subnet)
+			if err != nil {
+				continue
+			}
+			for _, s := range subnets {
+				if s.Overlaps(existing) {
+					return fmt.Sprintf("%s overlaps %s of network %s", s, existing, n.Name), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// Create validates spec, refusing subnets that overlap those of existing
+// networks, and creates the network.
+func (m *Manager) Create(spec CreateSpec) error {
+	if strings.TrimSpace(spec.Name) == "" {
+		return fmt.Errorf("network name is required")
+	}
+
+	opts := network.CreateOptions{
+		Driver:     spec.Driver,
+		Internal:   spec.Internal,
+		Attachable: spec.Attachable,
+		Options:    spec.Options,
+		Labels:     spec.Labels,
+	}
+
+	var ipam []network.IPAMConfig
+	var subnets []netip.Prefix
+	if !spec.IPv4.empty() {
+		conf, subnet, err := ipamConfig("IPv4", spec.IPv4, true)
+		if err != nil {
+			return err
+		}
+		ipam = append(ipam, *conf)
+		subnets = append(subnets, subnet)
+	}
+	if !spec.IPv6.empty() {
+		conf, subnet, err := ipamConfig("IPv6", spec.IPv6, false)
+		if err != nil {
+			return err
+		}
+		ipam = append(ipam, *conf)
+		subnets = append(subnets, subnet)
+		enable := true
+		opts.EnableIPv6 = &enable
+	}
+
+	if len(subnets) > 0 {
+		clash, err := m.overlapping(subnets)
+		if err != nil {
+			return err
+		}
+		if clash != "" {
+			return fmt.Errorf("%s", clash)
+		}
+		opts.IPAM = &network.IPAM{Driver: "default", Config: ipam}
+	}
+
+	_, err := m.cli.NetworkCreate(m.ctx, spec.Name, opts)
+	return err
+}
+
+// Endpoint holds the optional settings of a container on a network.
+type Endpoint struct {
+	IPv4    string
+	IPv6    string
+	Aliases []string
+}
+
+func (e *Endpoint) settings() (*network.EndpointSettings, error) {
+	if e == nil || (e.IPv4 == "" && e.IPv6 == "" && len(e.Aliases) == 0) {
+		return nil, nil
+	}
+	for _, ip := range []struct {
+		addr string
+		is4  bool
+	}{{e.IPv4, true}, {e.IPv6, false}} {
+		if ip.addr == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(ip.addr)
+		if err != nil || addr.Is4() != ip.is4 {
+			return nil, fmt.Errorf("invalid IP address %q", ip.addr)
+		}
+	}
+
+	settings := &network.EndpointSettings{Aliases: e.Aliases}
+	if e.IPv4 != "" || e.IPv6 != "" {
+		settings.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: e.IPv4, IPv6Address: e.IPv6}
+	}
+	return settings, nil
+}
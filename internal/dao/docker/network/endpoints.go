@@ -0,0 +1,216 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+// Kinds of network endpoints.
+const (
+	KindContainer    = "container"
+	KindVIP          = "service vip"
+	KindTask         = "task"
+	KindLoadBalancer = "load balancer"
+	KindPeer         = "peer"
+)
+
+// Attachment is an endpoint of a network: a container, or for swarm
+// networks a service VIP, a remote task, a load balancer or a peer node.
+type Attachment struct {
+	ID          string // container ID for containers, else endpoint ID or name
+	ContainerID string
+	Name        string
+	Kind        string
+	IPv4        string
+	IPv6        string
+	MAC         string
+	Aliases     []string
+	DNSNames    []string
+	Problem     string
+}
+
+func (a Attachment) GetID() string { return a.ID }
+
+func (a Attachment) GetCells() []string {
+	return []string{a.Name, a.Kind, common.Dash(a.IPv4), common.Dash(a.IPv6), common.Dash(a.MAC),
+		common.Dash(strings.Join(a.Aliases, ",")), common.Dash(strings.Join(a.DNSNames, ",")), a.Problem}
+}
+
+func (a Attachment) GetStatusColor() (tcell.Color, tcell.Color) {
+	if a.Problem != "" {
+		return styles.ColorStatusRed, styles.ColorBlack
+	}
+	if a.Kind != KindContainer {
+		return styles.ColorInfo, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (a Attachment) GetColumnValue(column string) string {
+	cells := a.GetCells()
+	switch strings.ToLower(column) {
+	case "name":
+		return a.Name
+	case "kind":
+		return a.Kind
+	case "ipv4":
+		return cells[2]
+	case "ipv6":
+		return cells[3]
+	case "mac":
+		return cells[4]
+	case "aliases":
+		return cells[5]
+	case "dns names":
+		return cells[6]
+	case "problem":
+		return a.Problem
+	}
+	return ""
+}
+
+func (a Attachment) GetDefaultColumn() string {
+	return "Name"
+}
+
+func (a Attachment) GetDefaultSortColumn() string {
+	return "IPv4"
+}
+
+// addr drops the prefix length of an endpoint address.
+func addr(cidr string) string {
+	a, _, _ := strings.Cut(cidr, "/")
+	return a
+}
+
+// ContainerEndpoint is what the container list tells about a container
+// attached to a network, which the network inspect leaves out.
+type ContainerEndpoint struct {
+	Aliases  []string
+	DNSNames []string
+	Gateway  bool // whether any network of the container gives it a default gateway
+}
+
+// Endpoints lists every endpoint of a network. Swarm-scoped networks are
+// inspected verbosely to get the service VIPs, the tasks running on other
+// nodes and the peers. lookup returns the endpoint of a container on the
+// network, as known from the container list. Addresses used twice and
+// containers left without a default gateway are reported in Problem.
+func (m *Manager) Endpoints(id string, lookup func(containerID, networkID string) (ContainerEndpoint, bool)) ([]common.Resource, error) {
+	n, err := m.cli.NetworkInspect(m.ctx, id, network.InspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if n.Scope == "swarm" {
+		if verbose, err := m.cli.NetworkInspect(m.ctx, id, network.InspectOptions{Verbose: true, Scope: "swarm"}); err == nil {
+			n = verbose
+		}
+	}
+
+	var res []Attachment
+	local := make(map[string]bool)
+	for key, ep := range n.Containers {
+		local[ep.EndpointID] = true
+		a := Attachment{
+			ID:          key,
+			ContainerID: key,
+			Name:        ep.Name,
+			Kind:        KindContainer,
+			IPv4:        addr(ep.IPv4Address),
+			IPv6:        addr(ep.IPv6Address),
+			MAC:         ep.MacAddress,
+		}
+		// Load balancer and ingress sandboxes are listed as containers
+		if strings.HasPrefix(key, "lb-") || key == "ingress-sbox" {
+			a.Kind = KindLoadBalancer
+			a.ContainerID = ""
+		} else if ce, ok := lookup(key, n.ID); ok {
+			a.Aliases = common.UserAliases(ce.Aliases, ep.Name, key)
+			a.DNSNames = ce.DNSNames
+			if !ce.Gateway {
+				a.Problem = "no gateway"
+				if n.Internal {
+					a.Problem += " (internal network)"
+				}
+			}
+		}
+		res = append(res, a)
+	}
+
+	for name, svc := range n.Services {
+		if svc.VIP != "" {
+			res = append(res, Attachment{
+				ID:   "vip/" + name,
+				Name: name,
+				Kind: KindVIP,
+				IPv4: addr(svc.VIP),
+			})
+		}
+		for _, t := range svc.Tasks {
+			if local[t.EndpointID] {
+				continue
+			}
+			res = append(res, Attachment{
+				ID:   t.EndpointID,
+				Name: t.Name,
+				Kind: KindTask,
+				IPv4: addr(t.EndpointIP),
+			})
+		}
+	}
+	for _, p := range n.Peers {
+		res = append(res, Attachment{
+			ID:   "peer/" + p.Name,
+			Name: p.Name,
+			Kind: KindPeer,
+			IPv4: p.IP,
+		})
+	}
+
+	// Peers are node addresses, outside of the network's subnet
+	owners := make(map[string][]string)
+	for _, a := range res {
+		if a.Kind == KindPeer {
+			continue
+		}
+		for _, ip := range []string{a.IPv4, a.IPv6} {
+			if ip != "" {
+				owners[ip] = append(owners[ip], a.Name)
+			}
+		}
+	}
+	for i, a := range res {
+		for _, ip := range []string{a.IPv4, a.IPv6} {
+			if a.Kind == KindPeer || len(owners[ip]) < 2 {
+				continue
+			}
+			var others []string
+			for _, o := range owners[ip] {
+				if o != a.Name {
+					others = append(others, o)
+				}
+			}
+			conflict := fmt.Sprintf("IP conflict with %s", strings.Join(others, ", "))
+			if len(others) == 0 {
+				conflict = "IP used twice"
+			}
+			if res[i].Problem != "" {
+				conflict = res[i].Problem + "; " + conflict
+			}
+			res[i].Problem = conflict
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	out := make([]common.Resource, 0, len(res))
+	for _, a := range res {
+		out = append(out, a)
+	}
+	return out, nil
+}
@@ -5,8 +5,8 @@ import (
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/client"
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao/common"
@@ -135,22 +135,75 @@ func (m *Manager) List() ([]common.Resource, error) {
 	return res, nil
 }
 
-func (m *Manager) Create(name string) error {
-	_, err := m.cli.NetworkCreate(m.ctx, name, network.CreateOptions{})
-	return err
-}
-
 func (m *Manager) Remove(id string) error {
 	return m.cli.NetworkRemove(m.ctx, id)
 }
 
-func (m *Manager) Prune() error {
-	_, err := m.cli.NetworksPrune(m.ctx, filters.NewArgs())
-	return err
+// PruneCandidates lists the networks a prune would remove: user-defined
+// networks no container (running or stopped) and no swarm service uses.
+// Swarm-scoped networks are only listed when the services are readable.
+func (m *Manager) PruneCandidates() ([]common.PruneCandidate, error) {
+	list, err := m.cli.NetworkList(m.ctx, network.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := m.cli.ContainerList(m.ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, c := range containers {
+		if c.NetworkSettings == nil {
+			continue
+		}
+		for name, n := range c.NetworkSettings.Networks {
+			used[n.NetworkID] = true
+			used[name] = true
+		}
+	}
+
+	serviceNets := make(map[string]bool)
+	services, svcErr := m.cli.ServiceList(m.ctx, swarm.ServiceListOptions{})
+	for _, s := range services {
+		for _, n := range s.Spec.TaskTemplate.Networks {
+			serviceNets[n.Target] = true
+		}
+		for _, vip := range s.Endpoint.VirtualIPs {
+			serviceNets[vip.NetworkID] = true
+		}
+	}
+
+	res := make([]common.PruneCandidate, 0)
+	for _, n := range list {
+		switch n.Name {
+		case "bridge", "host", "none":
+			continue
+		}
+		if n.Ingress || used[n.ID] || used[n.Name] {
+			continue
+		}
+		if n.Scope == "swarm" && (svcErr != nil || serviceNets[n.ID] || serviceNets[n.Name]) {
+			continue
+		}
+		res = append(res, common.PruneCandidate{
+			ID:     n.ID,
+			Name:   n.Name,
+			Size:   -1,
+			Detail: fmt.Sprintf("%s/%s, created %s", n.Driver, n.Scope, common.FormatTime(n.Created.Unix())),
+		})
+	}
+	return res, nil
 }
 
-func (m *Manager) Connect(networkID, containerID string) error {
-	return m.cli.NetworkConnect(m.ctx, networkID, containerID, nil)
+// Connect attaches a container to a network. endpoint, when set, gives it
+// a static address and aliases.
+func (m *Manager) Connect(networkID, containerID string, endpoint *Endpoint) error {
+	settings, err := endpoint.settings()
+	if err != nil {
+		return err
+	}
+	return m.cli.NetworkConnect(m.ctx, networkID, containerID, settings)
 }
 
 func (m *Manager) Disconnect(networkID, containerID string) error {
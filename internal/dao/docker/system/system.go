@@ -0,0 +1,510 @@
+package system
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+	"golang.org/x/net/context"
+)
+
+// Disk usage categories, used as summary row IDs.
+const (
+	KindImages     = "images"
+	KindContainers = "containers"
+	KindVolumes    = "volumes"
+	KindBuildCache = "build-cache"
+)
+
+type Manager struct {
+	cli *client.Client
+	ctx context.Context
+}
+
+func NewManager(cli *client.Client, ctx context.Context) *Manager {
+	return &Manager{cli: cli, ctx: ctx}
+}
+
+// Usage is a snapshot of the daemon disk usage (`docker system df -v`).
+type Usage struct {
+	raw types.DiskUsage
+}
+
+func (m *Manager) DiskUsage() (*Usage, error) {
+	du, err := m.cli.DiskUsage(m.ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &Usage{raw: du}, nil
+}
+
+// PruneBuildCache removes build cache records. Without all, only records
+// not referenced by any image are removed (`docker builder prune`); until
+// keeps records used more recently than the given duration ("24h").
+func (m *Manager) PruneBuildCache(all bool, until string) (*build.CachePruneReport, error) {
+	args := filters.NewArgs()
+	if until != "" {
+		args.Add("until", until)
+	}
+	return m.cli.BuildCachePrune(m.ctx, build.CachePruneOptions{All: all, Filters: args})
+}
+
+// Summary Model
+type Summary struct {
+	Kind        string
+	Total       int
+	Active      int
+	Size        int64
+	Reclaimable int64
+}
+
+var kindLabels = map[string]string{
+	KindImages:     "Images",
+	KindContainers: "Containers",
+	KindVolumes:    "Local Volumes",
+	KindBuildCache: "Build Cache",
+}
+
+func (s Summary) GetID() string { return s.Kind }
+func (s Summary) GetCells() []string {
+	return []string{kindLabels[s.Kind], fmt.Sprintf("%d", s.Total), fmt.Sprintf("%d", s.Active), common.FormatBytes(s.Size), s.reclaimable()}
+}
+
+func (s Summary) reclaimable() string {
+	if s.Size <= 0 {
+		return common.FormatBytes(s.Reclaimable)
+	}
+	return fmt.Sprintf("%s (%d%%)", common.FormatBytes(s.Reclaimable), s.Reclaimable*100/s.Size)
+}
+
+func (s Summary) GetStatusColor() (tcell.Color, tcell.Color) {
+	if s.Size > 0 && s.Reclaimable*2 >= s.Size {
+		return styles.ColorStatusOrange, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (s Summary) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "type":
+		return kindLabels[s.Kind]
+	case "total":
+		return fmt.Sprintf("%d", s.Total)
+	case "active":
+		return fmt.Sprintf("%d", s.Active)
+	case "size":
+		return common.FormatBytes(s.Size)
+	case "reclaimable":
+		return s.reclaimable()
+	}
+	return ""
+}
+
+func (s Summary) GetDefaultColumn() string {
+	return "Type"
+}
+
+func (s Summary) GetDefaultSortColumn() string {
+	return "Size"
+}
+
+// ImageUsage Model
+type ImageUsage struct {
+	ID         string
+	Tags       string
+	Size       int64
+	Shared     int64
+	Containers int64
+	Created    string
+}
+
+func (i ImageUsage) GetID() string { return i.ID }
+func (i ImageUsage) GetCells() []string {
+	return []string{shortID(i.ID), i.Tags, common.FormatBytes(i.Size), sizeOrDash(i.Shared), sizeOrDash(i.Size - max(i.Shared, 0)), fmt.Sprintf("%d", max(i.Containers, 0)), i.Created}
+}
+
+func (i ImageUsage) GetStatusColor() (tcell.Color, tcell.Color) {
+	if i.Containers <= 0 {
+		return styles.ColorStatusGray, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (i ImageUsage) GetColumnValue(column string) string {
+	cells := i.GetCells()
+	switch strings.ToLower(column) {
+	case "id":
+		return i.ID
+	case "tags":
+		return i.Tags
+	case "size":
+		return cells[2]
+	case "shared":
+		return cells[3]
+	case "unique":
+		return cells[4]
+	case "containers":
+		return cells[5]
+	case "created":
+		return i.Created
+	}
+	return ""
+}
+
+func (i ImageUsage) GetDefaultColumn() string {
+	return "Tags"
+}
+
+func (i ImageUsage) GetDefaultSortColumn() string {
+	return "Size"
+}
+
+// ContainerUsage Model
+type ContainerUsage struct {
+	ID      string
+	Name    string
+	Image   string
+	State   string
+	SizeRw  int64
+	Virtual int64
+	Created string
+}
+
+func (c ContainerUsage) GetID() string { return c.ID }
+func (c ContainerUsage) GetCells() []string {
+	return []string{shortID(c.ID), c.Name, c.Image, c.State, common.FormatBytes(c.SizeRw), sizeOrDash(c.Virtual), c.Created}
+}
+
+func (c ContainerUsage) GetStatusColor() (tcell.Color, tcell.Color) {
+	if !isActiveState(c.State) {
+		return styles.ColorStatusGray, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (c ContainerUsage) GetColumnValue(column string) string {
+	cells := c.GetCells()
+	switch strings.ToLower(column) {
+	case "id":
+		return c.ID
+	case "name":
+		return c.Name
+	case "image":
+		return c.Image
+	case "state":
+		return c.State
+	case "size":
+		return cells[4]
+	case "virtual":
+		return cells[5]
+	case "created":
+		return c.Created
+	}
+	return ""
+}
+
+func (c ContainerUsage) GetDefaultColumn() string {
+	return "Name"
+}
+
+func (c ContainerUsage) GetDefaultSortColumn() string {
+	return "Size"
+}
+
+// VolumeUsage Model
+type VolumeUsage struct {
+	Name     string
+	Driver   string
+	Size     int64
+	RefCount int64
+	Created  string
+}
+
+func (v VolumeUsage) GetID() string { return v.Name }
+func (v VolumeUsage) GetCells() []string {
+	refs := "-"
+	if v.RefCount >= 0 {
+		refs = fmt.Sprintf("%d", v.RefCount)
+	}
+	return []string{v.Name, v.Driver, sizeOrDash(v.Size), refs, v.Created}
+}
+
+func (v VolumeUsage) GetStatusColor() (tcell.Color, tcell.Color) {
+	if v.RefCount == 0 {
+		return styles.ColorStatusGray, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (v VolumeUsage) GetColumnValue(column string) string {
+	cells := v.GetCells()
+	switch strings.ToLower(column) {
+	case "name":
+		return v.Name
+	case "driver":
+		return v.Driver
+	case "size":
+		return cells[2]
+	case "links":
+		return cells[3]
+	case "created":
+		return v.Created
+	}
+	return ""
+}
+
+func (v VolumeUsage) GetDefaultColumn() string {
+	return "Name"
+}
+
+func (v VolumeUsage) GetDefaultSortColumn() string {
+	return "Size"
+}
+
+// CacheRecord Model
+type CacheRecord struct {
+	ID          string
+	Type        string
+	Size        int64
+	Shared      bool
+	InUse       bool
+	LastUsed    string
+	UsageCount  int
+	Description string
+}
+
+func (r CacheRecord) GetID() string { return r.ID }
+func (r CacheRecord) GetCells() []string {
+	return []string{shortID(r.ID), r.Type, common.FormatBytes(r.Size), yesOrEmpty(r.Shared), yesOrEmpty(r.InUse), r.LastUsed, fmt.Sprintf("%d", r.UsageCount), r.Description}
+}
+
+func (r CacheRecord) GetStatusColor() (tcell.Color, tcell.Color) {
+	if r.InUse {
+		return styles.ColorStatusBlue, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (r CacheRecord) GetColumnValue(column string) string {
+	cells := r.GetCells()
+	switch strings.ToLower(column) {
+	case "id":
+		return r.ID
+	case "type":
+		return r.Type
+	case "size":
+		return cells[2]
+	case "shared":
+		return cells[3]
+	case "in use":
+		return cells[4]
+	case "last used":
+		return r.LastUsed
+	case "usage":
+		return cells[6]
+	case "description":
+		return r.Description
+	}
+	return ""
+}
+
+func (r CacheRecord) GetDefaultColumn() string {
+	return "Description"
+}
+
+func (r CacheRecord) GetDefaultSortColumn() string {
+	return "Size"
+}
+
+// Summary aggregates the snapshot like `docker system df`.
+func (u *Usage) Summary() []common.Resource {
+	images := Summary{Kind: KindImages, Total: len(u.raw.Images), Size: u.raw.LayersSize}
+	var usedByContainers int64
+	for _, i := range u.raw.Images {
+		if i.Containers > 0 {
+			images.Active++
+			if i.Size >= 0 && i.SharedSize >= 0 {
+				usedByContainers += i.Size - i.SharedSize
+			}
+		}
+	}
+	images.Reclaimable = max(images.Size-usedByContainers, 0)
+
+	containers := Summary{Kind: KindContainers, Total: len(u.raw.Containers)}
+	for _, c := range u.raw.Containers {
+		containers.Size += c.SizeRw
+		if isActiveState(string(c.State)) {
+			containers.Active++
+		} else {
+			containers.Reclaimable += c.SizeRw
+		}
+	}
+
+	volumes := Summary{Kind: KindVolumes, Total: len(u.raw.Volumes)}
+	for _, v := range u.raw.Volumes {
+		if v.UsageData == nil {
+			continue
+		}
+		if v.UsageData.Size > 0 {
+			volumes.Size += v.UsageData.Size
+		}
+		if v.UsageData.RefCount > 0 {
+			volumes.Active++
+		} else if v.UsageData.Size > 0 {
+			volumes.Reclaimable += v.UsageData.Size
+		}
+	}
+
+	cache := Summary{Kind: KindBuildCache, Total: len(u.raw.BuildCache)}
+	for _, r := range u.raw.BuildCache {
+		if r.InUse {
+			cache.Active++
+		}
+		if !r.Shared {
+			cache.Size += r.Size
+			if !r.InUse {
+				cache.Reclaimable += r.Size
+			}
+		}
+	}
+
+	return []common.Resource{images, containers, volumes, cache}
+}
+
+// Images lists every image with its unique (non-shared) size.
+func (u *Usage) Images() []common.Resource {
+	res := make([]common.Resource, 0, len(u.raw.Images))
+	for _, i := range u.raw.Images {
+		tags := "<none>"
+		if len(i.RepoTags) > 0 {
+			tags = strings.Join(i.RepoTags, ", ")
+		}
+		res = append(res, ImageUsage{
+			ID:         i.ID,
+			Tags:       tags,
+			Size:       i.Size,
+			Shared:     i.SharedSize,
+			Containers: i.Containers,
+			Created:    common.FormatTime(i.Created),
+		})
+	}
+	return sortBySize(res)
+}
+
+// Containers lists the writable layer size of every container.
+func (u *Usage) Containers() []common.Resource {
+	res := make([]common.Resource, 0, len(u.raw.Containers))
+	for _, c := range u.raw.Containers {
+		name := shortID(c.ID)
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		res = append(res, ContainerUsage{
+			ID:      c.ID,
+			Name:    name,
+			Image:   c.Image,
+			State:   string(c.State),
+			SizeRw:  c.SizeRw,
+			Virtual: c.SizeRootFs,
+			Created: common.FormatTime(c.Created),
+		})
+	}
+	return sortBySize(res)
+}
+
+// Volumes lists local volumes with their size and reference count.
+func (u *Usage) Volumes() []common.Resource {
+	res := make([]common.Resource, 0, len(u.raw.Volumes))
+	for _, v := range u.raw.Volumes {
+		vu := VolumeUsage{Name: v.Name, Driver: v.Driver, Size: -1, RefCount: -1, Created: "-"}
+		if v.UsageData != nil {
+			vu.Size = v.UsageData.Size
+			vu.RefCount = v.UsageData.RefCount
+		}
+		if t, err := time.Parse(time.RFC3339, v.CreatedAt); err == nil {
+			vu.Created = common.FormatTime(t.Unix())
+		}
+		res = append(res, vu)
+	}
+	return sortBySize(res)
+}
+
+// BuildCache lists build cache records.
+func (u *Usage) BuildCache() []common.Resource {
+	res := make([]common.Resource, 0, len(u.raw.BuildCache))
+	for _, r := range u.raw.BuildCache {
+		lastUsed := "-"
+		if r.LastUsedAt != nil {
+			lastUsed = common.FormatTime(r.LastUsedAt.Unix())
+		}
+		res = append(res, CacheRecord{
+			ID:          r.ID,
+			Type:        r.Type,
+			Size:        r.Size,
+			Shared:      r.Shared,
+			InUse:       r.InUse,
+			LastUsed:    lastUsed,
+			UsageCount:  r.UsageCount,
+			Description: r.Description,
+		})
+	}
+	return sortBySize(res)
+}
+
+func sortBySize(res []common.Resource) []common.Resource {
+	size := func(r common.Resource) int64 {
+		switch v := r.(type) {
+		case ImageUsage:
+			return v.Size
+		case ContainerUsage:
+			return v.SizeRw
+		case VolumeUsage:
+			return v.Size
+		case CacheRecord:
+			return v.Size
+		}
+		return 0
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return size(res[i]) > size(res[j])
+	})
+	return res
+}
+
+func isActiveState(state string) bool {
+	switch strings.ToLower(state) {
+	case "running", "paused", "restarting":
+		return true
+	}
+	return false
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func sizeOrDash(size int64) string {
+	if size < 0 {
+		return "-"
+	}
+	return common.FormatBytes(size)
+}
+
+func yesOrEmpty(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package volume
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/jr-k/d4s/internal/dao/common"
+)
+
+// dataDir is where helper containers mount the volume they work on.
+const dataDir = "/data"
+
+// helper creates a stopped helper container of image with the volume
+// mounted on dataDir. The archive API works on it without starting it.
+func (m *Manager) helper(name, image, purpose string, readOnly bool, cmd ...string) (string, error) {
+	return common.CreateHelper(m.cli, m.ctx, "d4s-vol-"+purpose, purpose, &container.Config{
+		Image:      image,
+		Cmd:        cmd,
+		WorkingDir: dataDir,
+	}, &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: name, Target: dataDir, ReadOnly: readOnly}},
+	})
+}
+
+// Backup writes the content of a volume into w as a tar archive whose
+// entries are relative to the volume root, streamed through the archive
+// API: nothing is staged on the daemon host.
+func (m *Manager) Backup(name, image string, w io.Writer) error {
+	id, err := m.helper(name, image, "backup", true)
+	if err != nil {
+		return err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	reader, _, err := m.cli.CopyFromContainer(m.ctx, id, dataDir)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return rebaseTar(reader, w, strings.TrimPrefix(dataDir, "/"))
+}
+
+// rebaseTar copies a tar stream, moving the entries under root to the top
+// of the archive; root itself becomes "./".
+func rebaseTar(r io.Reader, w io.Writer, root string) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(hdr.Name, root)
+		name = strings.TrimPrefix(name, "/")
+		if name == "" {
+			name = "./"
+		}
+		hdr.Name = name
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = strings.TrimPrefix(strings.TrimPrefix(hdr.Linkname, root), "/")
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (m *Manager) Exists(name string) (bool, error) {
+	_, err := m.cli.VolumeInspect(m.ctx, name)
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// createNew creates a volume that must not exist yet.
+func (m *Manager) createNew(name string) error {
+	exists, err := m.Exists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("volume %s already exists", name)
+	}
+	return m.Create(name)
+}
+
+// Clone copies a volume into a new volume on the same daemon, keeping
+// owners, modes and timestamps. The new volume is removed on failure.
+func (m *Manager) Clone(source, target, image string) error {
+	if err := m.createNew(target); err != nil {
+		return err
+	}
+
+	id, err := common.CreateHelper(m.cli, m.ctx, "d4s-vol-clone", "clone", &container.Config{
+		Image: image,
+		Cmd:   []string{"cp", "-a", "/from/.", "/to/"},
+	}, &container.HostConfig{
+		Mounts: []mount.Mount{
+			{Type: mount.TypeVolume, Source: source, Target: "/from", ReadOnly: true},
+			{Type: mount.TypeVolume, Source: target, Target: "/to"},
+		},
+	})
+	if err == nil {
+		_, err = common.RunHelper(m.cli, m.ctx, id)
+		common.RemoveHelper(m.cli, id)
+	}
+	if err != nil {
+		_ = m.cli.VolumeRemove(m.ctx, target, true)
+		return err
+	}
+	return nil
+}
+
+// checksumScript hashes the content of every regular file, along with the
+// type, mode, owner and symlink target of every entry below the root.
+// Timestamps are left out: extracting an archive touches directories.
+const checksumScript = `{ find . -type f -exec sha256sum {} + ; find . -mindepth 1 -exec stat -c '%F %a %u:%g %N' {} + ; } | LC_ALL=C sort | sha256sum`
+
+// Checksum digests the files, directories and symlinks of a volume with
+// their modes and owners, to compare two copies of it.
+func (m *Manager) Checksum(name, image string) (string, error) {
+	id, err := m.helper(name, image, "checksum", true, "sh", "-c", checksumScript)
+	if err != nil {
+		return "", err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	out, err := common.RunHelper(m.cli, m.ctx, id)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no checksum computed for %s", name)
+	}
+	return fields[0], nil
+}
+
+// Restore extracts a tar archive (plain or compressed) into a volume,
+// creating the volume if it does not exist. With empty set, what the
+// volume holds is removed first.
+func (m *Manager) Restore(name, image string, r io.Reader, empty bool) error {
+	exists, err := m.Exists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := m.Create(name); err != nil {
+			return err
+		}
+	}
+
+	id, err := m.helper(name, image, "restore", false, "find", dataDir, "-mindepth", "1", "-delete")
+	if err != nil {
+		return err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	if empty {
+		if _, err := common.RunHelper(m.cli, m.ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return m.cli.CopyToContainer(m.ctx, id, dataDir, r, container.CopyToContainerOptions{})
+}
@@ -0,0 +1,370 @@
+package volume
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+// File is an entry of a directory inside a volume.
+type File struct {
+	Volume   string
+	Path     string // from the volume root, e.g. /conf/app.yml
+	Name     string
+	Type     string // file, dir, link or other
+	Size     int64
+	Owner    string
+	Mode     string
+	Modified time.Time
+}
+
+func (f File) GetID() string { return f.Path }
+
+func (f File) IsDir() bool { return f.Type == "dir" }
+
+func (f File) size() string {
+	if f.IsDir() {
+		return "-"
+	}
+	return common.FormatBytes(f.Size)
+}
+
+func (f File) modified() string {
+	if f.Modified.IsZero() {
+		return "-"
+	}
+	return common.FormatTime(f.Modified.Unix())
+}
+
+func (f File) GetCells() []string {
+	name := f.Name
+	if f.IsDir() {
+		name += "/"
+	}
+	return []string{name, f.Type, f.size(), f.Owner, f.Mode, f.modified()}
+}
+
+func (f File) GetStatusColor() (tcell.Color, tcell.Color) {
+	switch f.Type {
+	case "dir":
+		return styles.ColorInfo, styles.ColorBlack
+	case "link":
+		return styles.ColorIdle, styles.ColorBlack
+	}
+	return styles.ColorFg, styles.ColorBlack
+}
+
+func (f File) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "name":
+		return f.Name
+	case "type":
+		return f.Type
+	case "size":
+		return f.size()
+	case "owner":
+		return f.Owner
+	case "mode":
+		return f.Mode
+	case "modified":
+		return f.modified()
+	}
+	return ""
+}
+
+func (f File) GetDefaultColumn() string     { return "NAME" }
+func (f File) GetDefaultSortColumn() string { return "TYPE" }
+
+// filesTTL is how long a directory listing is reused: each listing costs
+// a helper container.
+const filesTTL = 30 * time.Second
+
+type fileListing struct {
+	files []common.Resource
+	at    time.Time
+}
+
+// fileCache keeps the recent directory listings, per volume and path.
+type fileCache struct {
+	mu      sync.Mutex
+	entries map[string]fileListing
+}
+
+func (c *fileCache) get(key string) ([]common.Resource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.entries[key]
+	if !ok || time.Since(l.at) > filesTTL {
+		return nil, false
+	}
+	return l.files, true
+}
+
+func (c *fileCache) put(key string, files []common.Resource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]fileListing)
+	}
+	c.entries[key] = fileListing{files: files, at: time.Now()}
+}
+
+func (c *fileCache) forget(volume string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, volume+":") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// ForgetFiles drops the cached listings of a volume after it changed.
+func (m *Manager) ForgetFiles(volume string) {
+	m.files.forget(volume)
+}
+
+// cleanPath makes p an absolute path inside the volume.
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// statFormat is what the helper prints for each entry, tab separated.
+const statFormat = "%F\t%s\t%u\t%g\t%U\t%G\t%A\t%Y\t%n"
+
+// ListFiles lists a directory of a volume, from a helper container that
+// mounts it read-only.
+func (m *Manager) ListFiles(volume, dir, image string) ([]common.Resource, error) {
+	dir = cleanPath(dir)
+	key := volume + ":" + dir
+	if files, ok := m.files.get(key); ok {
+		return files, nil
+	}
+
+	id, err := m.helper(volume, image, "files", true,
+		"find", dataDir+dir, "-mindepth", "1", "-maxdepth", "1", "-exec", "stat", "-c", statFormat, "{}", "+")
+	if err != nil {
+		return nil, err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	out, err := common.RunHelper(m.cli, m.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []common.Resource
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\t", 9)
+		if len(parts) < 9 {
+			continue
+		}
+		size, _ := strconv.ParseInt(parts[1], 10, 64)
+		mtime, _ := strconv.ParseInt(parts[7], 10, 64)
+		name := path.Base(parts[8])
+		files = append(files, File{
+			Volume:   volume,
+			Path:     path.Join(dir, name),
+			Name:     name,
+			Type:     fileType(parts[0]),
+			Size:     size,
+			Owner:    owner(parts[2], parts[3], parts[4], parts[5]),
+			Mode:     parts[6],
+			Modified: time.Unix(mtime, 0),
+		})
+	}
+	// Directories first
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i].(File), files[j].(File)
+		if a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		return a.Name < b.Name
+	})
+
+	m.files.put(key, files)
+	return files, nil
+}
+
+func fileType(statType string) string {
+	switch {
+	case statType == "directory":
+		return "dir"
+	case statType == "symbolic link":
+		return "link"
+	case strings.Contains(statType, "regular"):
+		return "file"
+	}
+	return "other"
+}
+
+// owner prefers names, falling back to ids the helper image cannot name.
+func owner(uid, gid, user, group string) string {
+	if user == "" || user == "UNKNOWN" {
+		user = uid
+	}
+	if group == "" || group == "UNKNOWN" {
+		group = gid
+	}
+	return user + ":" + group
+}
+
+// ReadFile returns the content of a file of a volume, up to limit bytes.
+func (m *Manager) ReadFile(volume, file, image string, limit int64) ([]byte, error) {
+	id, err := m.helper(volume, image, "files", true)
+	if err != nil {
+		return nil, err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	reader, stat, err := m.cli.CopyFromContainer(m.ctx, id, dataDir+cleanPath(file))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	if stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", file)
+	}
+	if stat.Size > limit {
+		return nil, fmt.Errorf("%s is too big to view (%s), download it instead", file, common.FormatBytes(stat.Size))
+	}
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(tr, limit)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Download writes a file of a volume into w, or a directory as a tar
+// archive. It tells which of the two it was.
+func (m *Manager) Download(volume, file, image string, w io.Writer) (bool, error) {
+	id, err := m.helper(volume, image, "files", true)
+	if err != nil {
+		return false, err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	reader, stat, err := m.cli.CopyFromContainer(m.ctx, id, dataDir+cleanPath(file))
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	if stat.Mode.IsDir() {
+		_, err = io.Copy(w, reader)
+		return true, err
+	}
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		return false, err
+	}
+	_, err = io.Copy(w, tr)
+	return false, err
+}
+
+// Upload copies a local file or directory into a directory of a volume,
+// through a helper container that mounts it read-write.
+func (m *Manager) Upload(volume, dir, image, localPath string) error {
+	if _, err := os.Stat(localPath); err != nil {
+		return err
+	}
+
+	id, err := m.helper(volume, image, "files", false)
+	if err != nil {
+		return err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarLocal(pw, localPath))
+	}()
+	err = m.cli.CopyToContainer(m.ctx, id, dataDir+cleanPath(dir), pr, container.CopyToContainerOptions{})
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+// tarLocal writes a local file, or a directory and its content, as a tar
+// archive whose top entry is its base name.
+func tarLocal(w io.Writer, localPath string) error {
+	tw := tar.NewWriter(w)
+	base := filepath.Dir(localPath)
+
+	err := filepath.Walk(localPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// DeleteFile removes a file or a directory tree from a volume.
+func (m *Manager) DeleteFile(volume, file, image string) error {
+	file = cleanPath(file)
+	if file == "/" {
+		return fmt.Errorf("refusing to delete the volume root")
+	}
+
+	id, err := m.helper(volume, image, "files", false, "rm", "-rf", "--", dataDir+file)
+	if err != nil {
+		return err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	_, err = common.RunHelper(m.cli, m.ctx, id)
+	return err
+}
@@ -0,0 +1,83 @@
+package volume
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jr-k/d4s/internal/dao/common"
+)
+
+// topDepth is how deep below the volume root directories are measured.
+const topDepth = 3
+
+// topCount bounds the number of directories reported.
+const topCount = 50
+
+// TopDirectories reports the biggest directories of a volume, measured by
+// `du` in a helper container that mounts it read-only. Sizes of nested
+// directories are included in their parents'.
+func (m *Manager) TopDirectories(name, image string) (string, error) {
+	id, err := m.helper(name, image, "usage", true,
+		"du", "-k", "-x", "-d", strconv.Itoa(topDepth), ".")
+	if err != nil {
+		return "", err
+	}
+	defer common.RemoveHelper(m.cli, id)
+
+	out, err := common.RunHelper(m.cli, m.ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	type dirSize struct {
+		path string
+		size int64
+	}
+	var dirs []dirSize
+	var total int64
+	for _, line := range strings.Split(out, "\n") {
+		kb, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(kb), 10, 64)
+		if err != nil {
+			continue
+		}
+		path = strings.TrimPrefix(path, ".")
+		if path == "" {
+			total = size * 1024
+			continue
+		}
+		dirs = append(dirs, dirSize{path: path, size: size * 1024})
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		if dirs[i].size != dirs[j].size {
+			return dirs[i].size > dirs[j].size
+		}
+		return dirs[i].path < dirs[j].path
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Volume: %s\n", name)
+	fmt.Fprintf(&sb, "Total:  %s\n\n", common.FormatBytes(total))
+	if len(dirs) == 0 {
+		sb.WriteString("No directories.\n")
+		return sb.String(), nil
+	}
+	fmt.Fprintf(&sb, "%-10s %6s  %s\n", "SIZE", "SHARE", "DIRECTORY")
+	for i, d := range dirs {
+		if i == topCount {
+			fmt.Fprintf(&sb, "\n... %d more directories\n", len(dirs)-topCount)
+			break
+		}
+		share := "-"
+		if total > 0 {
+			share = fmt.Sprintf("%.1f%%", float64(d.size)*100/float64(total))
+		}
+		fmt.Fprintf(&sb, "%-10s %6s  %s\n", common.FormatBytes(d.size), share, d.path)
+	}
+	return sb.String(), nil
+}
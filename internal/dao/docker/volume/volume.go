@@ -1,11 +1,18 @@
 package volume
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/versions"
 	volTypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/gdamore/tcell/v2"
@@ -19,6 +26,8 @@ var reAnonymousVolume = regexp.MustCompile(`^[a-f0-9]{64}$`)
 type Manager struct {
 	cli *client.Client
 	ctx context.Context
+
+	files fileCache
 }
 
 func NewManager(cli *client.Client, ctx context.Context) *Manager {
@@ -34,6 +43,8 @@ type Volume struct {
 	Scope     string
 	UsedBy    string
 	Anonymous bool
+	Size      int64 // -1 until the disk usage is known
+	RefCount  int64 // -1 until the disk usage is known
 }
 
 func IsAnonymousVolume(name string) bool {
@@ -46,7 +57,21 @@ func (v Volume) GetCells() []string {
 	if v.Anonymous {
 		anon = "Yes"
 	}
-	return []string{v.Name, v.Driver, v.Scope, v.UsedBy, v.Mount, v.Created, anon}
+	return []string{v.Name, v.Driver, v.Scope, v.size(), v.refCount(), v.UsedBy, v.Mount, v.Created, anon}
+}
+
+func (v Volume) size() string {
+	if v.Size < 0 {
+		return "-"
+	}
+	return common.FormatBytes(v.Size)
+}
+
+func (v Volume) refCount() string {
+	if v.RefCount < 0 {
+		return "-"
+	}
+	return strconv.FormatInt(v.RefCount, 10)
 }
 
 func (v Volume) GetStatusColor() (tcell.Color, tcell.Color) {
@@ -65,6 +90,10 @@ func (v Volume) GetColumnValue(column string) string {
 		return v.Mount
 	case "created":
 		return v.Created
+	case "size":
+		return v.size()
+	case "refcount":
+		return v.refCount()
 	case "used by":
 		return v.UsedBy
 	case "anon":
@@ -125,6 +154,8 @@ func (m *Manager) List() ([]common.Resource, error) {
 			Created:   created,
 			Scope:     v.Scope,
 			Anonymous: IsAnonymousVolume(v.Name),
+			Size:      -1,
+			RefCount:  -1,
 		})
 	}
 	return res, nil
@@ -141,7 +172,135 @@ func (m *Manager) Remove(id string, force bool) error {
 	return m.cli.VolumeRemove(m.ctx, id, force)
 }
 
-func (m *Manager) Prune() error {
-	_, err := m.cli.VolumesPrune(m.ctx, filters.NewArgs())
-	return err
+// anonymousLabel is set by the daemon on volumes it creates without a name.
+const anonymousLabel = "com.docker.volume.anonymous"
+
+// lastUseWindow bounds how far back the daemon events are read to find the
+// containers that last mounted a volume.
+const lastUseWindow = 7 * 24 * time.Hour
+
+// apiVersion returns the API version requests are made with: the lowest of
+// the client and daemon versions.
+func (m *Manager) apiVersion() string {
+	version := m.cli.ClientVersion()
+	if sv, err := m.cli.ServerVersion(m.ctx); err == nil && versions.LessThan(sv.APIVersion, version) {
+		version = sv.APIVersion
+	}
+	return version
+}
+
+// PruneCandidates lists the volumes a prune would remove: unused volumes,
+// restricted to anonymous ones since API 1.42 (as the daemon does without
+// the "all" filter). Detail names the containers that last mounted each
+// volume, as far as the daemon events of the last lastUseWindow tell.
+func (m *Manager) PruneCandidates() ([]common.PruneCandidate, error) {
+	args := filters.NewArgs(filters.Arg("dangling", "true"))
+	if !versions.LessThan(m.apiVersion(), "1.42") {
+		args.Add("label", anonymousLabel)
+	}
+
+	list, err := m.cli.VolumeList(m.ctx, volTypes.ListOptions{Filters: args})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Volumes) == 0 {
+		return nil, nil
+	}
+
+	sizes := make(map[string]int64)
+	if du, err := m.cli.DiskUsage(m.ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}}); err == nil {
+		for _, v := range du.Volumes {
+			if v.UsageData != nil {
+				sizes[v.Name] = v.UsageData.Size
+			}
+		}
+	}
+	lastUsers, complete := m.lastUsers()
+
+	res := make([]common.PruneCandidate, 0, len(list.Volumes))
+	for _, v := range list.Volumes {
+		size, ok := sizes[v.Name]
+		if !ok {
+			size = -1
+		}
+		detail := fmt.Sprintf("not mounted in the last %d days", int(lastUseWindow.Hours()/24))
+		if !complete {
+			detail = "last use unknown: daemon events unavailable"
+		}
+		if users := lastUsers[v.Name]; len(users) > 0 {
+			detail = "last used by: " + strings.Join(users, ", ")
+		} else if project := v.Labels["com.docker.compose.project"]; project != "" {
+			detail = "compose project: " + project + ", " + detail
+		}
+		res = append(res, common.PruneCandidate{
+			ID:     v.Name,
+			Name:   v.Name,
+			Size:   size,
+			Detail: detail,
+		})
+	}
+	return res, nil
+}
+
+// lastUsers maps volume names to the names of the containers that mounted
+// them, most recent first, from the daemon events of the last lastUseWindow.
+// complete is false when the events could not all be read.
+func (m *Manager) lastUsers() (users map[string][]string, complete bool) {
+	ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
+	defer cancel()
+
+	args := filters.NewArgs(
+		filters.Arg("type", string(events.VolumeEventType)),
+		filters.Arg("type", string(events.ContainerEventType)),
+		filters.Arg("event", string(events.ActionMount)),
+		filters.Arg("event", string(events.ActionCreate)),
+		filters.Arg("event", string(events.ActionDestroy)),
+	)
+	now := time.Now()
+	msgs, errs := m.cli.Events(ctx, events.ListOptions{
+		Since:   strconv.FormatInt(now.Add(-lastUseWindow).Unix(), 10),
+		Until:   strconv.FormatInt(now.Unix(), 10),
+		Filters: args,
+	})
+
+	names := make(map[string]string)    // container ID -> name
+	mounts := make(map[string][]string) // volume -> container IDs, oldest first
+	func() {
+		for {
+			select {
+			case msg := <-msgs:
+				switch msg.Type {
+				case events.ContainerEventType:
+					if name := msg.Actor.Attributes["name"]; name != "" {
+						names[msg.Actor.ID] = name
+					}
+				case events.VolumeEventType:
+					if id := msg.Actor.Attributes["container"]; id != "" {
+						mounts[msg.Actor.ID] = append(mounts[msg.Actor.ID], id)
+					}
+				}
+			case err := <-errs:
+				complete = errors.Is(err, io.EOF)
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	res := make(map[string][]string, len(mounts))
+	for vol, ids := range mounts {
+		seen := make(map[string]bool)
+		for i := len(ids) - 1; i >= 0 && len(res[vol]) < 3; i-- {
+			name := names[ids[i]]
+			if name == "" {
+				name = ids[i][:min(12, len(ids[i]))]
+			}
+			if !seen[name] {
+				seen[name] = true
+				res[vol] = append(res[vol], name)
+			}
+		}
+	}
+	return res, complete
 }
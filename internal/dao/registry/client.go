@@ -0,0 +1,432 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/types"
+)
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+var manifestAccept = strings.Join([]string{
+	mediaTypeOCIIndex,
+	mediaTypeDockerManifestList,
+	mediaTypeOCIManifest,
+	mediaTypeDockerManifest,
+}, ", ")
+
+// dockerHubHost is where Docker Hub serves the v2 API; credentials are
+// stored under the legacy index address.
+const (
+	dockerHubHost    = "registry-1.docker.io"
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+// Client talks to a Docker Registry HTTP API v2 endpoint. Credentials
+// come from the Docker credential store (docker login), bearer tokens
+// are negotiated on demand and cached per scope.
+type Client struct {
+	base *url.URL
+	http *http.Client
+
+	authOnce sync.Once
+	auth     types.AuthConfig
+
+	mu     sync.Mutex
+	tokens map[string]string
+	lists  map[string]cachedList
+}
+
+// NewClient builds a client for endpoint ("localhost:5000",
+// "https://registry.example.com"). Endpoints without scheme default to
+// https, except localhost which defaults to http like `registry:2`.
+func NewClient(endpoint string, insecure bool) (*Client, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("no registry endpoint configured")
+	}
+	if !strings.Contains(endpoint, "://") {
+		scheme := "https"
+		if isLocalHost(endpoint) {
+			scheme = "http"
+		}
+		endpoint = scheme + "://" + endpoint
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry endpoint '%s': %v", endpoint, err)
+	}
+	if u.Host == "docker.io" || u.Host == "index.docker.io" {
+		u.Host = dockerHubHost
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Client{
+		base:   u,
+		http:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		tokens: make(map[string]string),
+		lists:  make(map[string]cachedList),
+	}, nil
+}
+
+func isLocalHost(hostport string) bool {
+	host := strings.Split(hostport, "/")[0]
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return host == "localhost" || host == "127.0.0.1" || host == "::1" || host == "[::1]"
+}
+
+// Host returns the registry host (with port) as used in image references.
+func (c *Client) Host() string {
+	return c.base.Host
+}
+
+// Endpoint returns the base URL of the registry.
+func (c *Client) Endpoint() string {
+	return c.base.String()
+}
+
+// ImageRef builds the reference a docker daemon uses for repo:tag on
+// this registry.
+func (c *Client) ImageRef(repo, tag string) string {
+	sep := ":"
+	if strings.HasPrefix(tag, "sha256:") {
+		sep = "@"
+	}
+	if c.base.Host == dockerHubHost {
+		return strings.TrimPrefix(repo, "library/") + sep + tag
+	}
+	return c.base.Host + "/" + repo + sep + tag
+}
+
+func (c *Client) credentials() types.AuthConfig {
+	c.authOnce.Do(func() {
+		key := c.base.Host
+		if key == dockerHubHost {
+			key = dockerHubAuthKey
+		}
+		cf := config.LoadDefaultConfigFile(io.Discard)
+		if auth, err := cf.GetAuthConfig(key); err == nil {
+			c.auth = auth
+		}
+	})
+	return c.auth
+}
+
+// Catalog lists every repository of the registry, following pagination.
+func (c *Client) Catalog() ([]string, error) {
+	var repos []string
+	next := "/v2/_catalog?n=1000"
+	for next != "" {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		resp, err := c.get(next, "registry:catalog:*", "")
+		if err != nil {
+			return nil, err
+		}
+		err = decodeJSON(resp, &page)
+		link := resp.Header.Get("Link")
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, page.Repositories...)
+		next = parseNextLink(link)
+	}
+	return repos, nil
+}
+
+// Tags lists the tags of repo.
+func (c *Client) Tags(repo string) ([]string, error) {
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	resp, err := c.get(fmt.Sprintf("/v2/%s/tags/list", repo), pullScope(repo), "")
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(resp, &list); err != nil {
+		return nil, err
+	}
+	return list.Tags, nil
+}
+
+// Digest resolves ref (tag or digest) to its manifest digest without
+// downloading the manifest.
+func (c *Client) Digest(repo, ref string) (string, error) {
+	resp, err := c.do(http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", repo, ref), pullScope(repo), manifestAccept)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Some registries omit the header on HEAD: fall back to hashing the body.
+	m, err := c.Manifest(repo, ref)
+	if err != nil {
+		return "", err
+	}
+	return m.Digest, nil
+}
+
+// Delete removes the manifest identified by digest. Registries only allow
+// it when deletion is enabled (REGISTRY_STORAGE_DELETE_ENABLED=true for
+// registry:2).
+func (c *Client) Delete(repo, digest string) error {
+	resp, err := c.do(http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repo, digest), fmt.Sprintf("repository:%s:delete", repo), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	defer c.Invalidate()
+
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusUnsupportedMediaType {
+		return fmt.Errorf("registry does not allow deletes (%s)", resp.Status)
+	}
+	return checkStatus(resp)
+}
+
+func (c *Client) fetchManifest(repo, ref string) (rawManifest, error) {
+	resp, err := c.get(fmt.Sprintf("/v2/%s/manifests/%s", repo, ref), pullScope(repo), manifestAccept)
+	if err != nil {
+		return rawManifest{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return rawManifest{}, err
+	}
+
+	var m rawManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return rawManifest{}, fmt.Errorf("invalid manifest for %s:%s: %v", repo, ref, err)
+	}
+	m.body = body
+	m.digest = resp.Header.Get("Docker-Content-Digest")
+	if m.digest == "" {
+		m.digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	if m.MediaType == "" {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+	return m, nil
+}
+
+func (c *Client) fetchConfig(repo, digest string) (imageConfig, error) {
+	var cfg imageConfig
+	resp, err := c.get(fmt.Sprintf("/v2/%s/blobs/%s", repo, digest), pullScope(repo), "")
+	if err != nil {
+		return cfg, err
+	}
+	err = decodeJSON(resp, &cfg)
+	return cfg, err
+}
+
+func (c *Client) get(path, scope, accept string) (*http.Response, error) {
+	resp, err := c.do(http.MethodGet, path, scope, accept)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// do sends the request, answering a 401 challenge (basic or bearer) once.
+func (c *Client) do(method, path, scope, accept string) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, c.base.String()+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c.authorize(req, scope)
+		return c.http.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := c.answerChallenge(challenge, scope); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+func (c *Client) authorize(req *http.Request, scope string) {
+	c.mu.Lock()
+	token, ok := c.tokens[scope]
+	if !ok {
+		token, ok = c.tokens[""]
+	}
+	c.mu.Unlock()
+
+	if ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+	if ok {
+		// Basic auth registry: empty token marks "send credentials".
+		auth := c.credentials()
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func (c *Client) answerChallenge(challenge, scope string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	auth := c.credentials()
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if auth.Username == "" {
+			return fmt.Errorf("registry %s requires credentials (docker login %s)", c.base.Host, c.base.Host)
+		}
+		c.mu.Lock()
+		c.tokens[""] = ""
+		c.mu.Unlock()
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported registry auth challenge: %q", challenge)
+	}
+
+	values := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[m[1]] = m[2]
+	}
+	realm := values["realm"]
+	if realm == "" {
+		return fmt.Errorf("invalid registry auth challenge: %q", challenge)
+	}
+
+	q := url.Values{}
+	if values["service"] != "" {
+		q.Set("service", values["service"])
+	}
+	if s := values["scope"]; s != "" {
+		q.Set("scope", s)
+	} else if scope != "" {
+		q.Set("scope", scope)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get registry token: %v", err)
+	}
+	if err := checkStatus(resp); err != nil {
+		resp.Body.Close()
+		return fmt.Errorf("failed to get registry token: %v", err)
+	}
+
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeJSON(resp, &tok); err != nil {
+		return fmt.Errorf("failed to get registry token: %v", err)
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[scope] = tok.Token
+	c.mu.Unlock()
+	return nil
+}
+
+func pullScope(repo string) string {
+	return fmt.Sprintf("repository:%s:pull", repo)
+}
+
+func decodeJSON(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var apiErr struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if resp.Body != nil {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			e := apiErr.Errors[0]
+			return fmt.Errorf("%s: %s", e.Code, e.Message)
+		}
+	}
+	return fmt.Errorf("registry returned %s", resp.Status)
+}
+
+// parseNextLink extracts the path of a `Link: </v2/...>; rel="next"` header.
+func parseNextLink(link string) string {
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+	if start < 0 || end <= start {
+		return ""
+	}
+	next := link[start+1 : end]
+	if u, err := url.Parse(next); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	return next
+}
@@ -0,0 +1,173 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant,omitempty"`
+	} `json:"platform,omitempty"`
+}
+
+type rawManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+	Manifests     []descriptor `json:"manifests"`
+
+	body   []byte
+	digest string
+}
+
+func (m rawManifest) isIndex() bool {
+	return m.MediaType == mediaTypeOCIIndex || m.MediaType == mediaTypeDockerManifestList || len(m.Manifests) > 0
+}
+
+func (m rawManifest) size() int64 {
+	total := m.Config.Size
+	for _, l := range m.Layers {
+		total += l.Size
+	}
+	return total
+}
+
+type imageConfig struct {
+	Created      time.Time `json:"created"`
+	OS           string    `json:"os"`
+	Architecture string    `json:"architecture"`
+	Variant      string    `json:"variant"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+// Platform is one image of a multi-platform manifest.
+type Platform struct {
+	Name   string // os/arch[/variant]
+	Digest string
+	Size   int64
+}
+
+// Manifest summarizes a tag: digest, platforms, size and config labels.
+type Manifest struct {
+	Repository string
+	Reference  string
+	Digest     string
+	MediaType  string
+	Size       int64
+	Created    time.Time
+	Platforms  []Platform
+	Labels     map[string]string
+	Raw        []byte
+}
+
+// PlatformNames returns the platforms as "linux/amd64, linux/arm64".
+func (m *Manifest) PlatformNames() string {
+	names := make([]string, 0, len(m.Platforms))
+	for _, p := range m.Platforms {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Manifest fetches the manifest of repo:ref. For multi-platform indexes
+// every platform manifest is resolved to compute sizes; labels and
+// creation date come from the first platform.
+func (c *Client) Manifest(repo, ref string) (*Manifest, error) {
+	raw, err := c.fetchManifest(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	if raw.SchemaVersion == 1 {
+		return nil, fmt.Errorf("schema1 manifests are not supported")
+	}
+
+	m := &Manifest{
+		Repository: repo,
+		Reference:  ref,
+		Digest:     raw.digest,
+		MediaType:  raw.MediaType,
+		Raw:        raw.body,
+	}
+
+	if !raw.isIndex() {
+		cfg, err := c.fetchConfig(repo, raw.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		m.Size = raw.size()
+		m.Created = cfg.Created
+		m.Labels = cfg.Config.Labels
+		m.Platforms = []Platform{{Name: platformName(cfg.OS, cfg.Architecture, cfg.Variant), Digest: raw.digest, Size: m.Size}}
+		return m, nil
+	}
+
+	type result struct {
+		platform Platform
+		child    rawManifest
+		err      error
+	}
+
+	var children []descriptor
+	for _, d := range raw.Manifests {
+		// Skip buildkit attestation manifests (unknown/unknown).
+		if d.Platform != nil && d.Platform.OS == "unknown" {
+			continue
+		}
+		children = append(children, d)
+	}
+
+	results := make([]result, len(children))
+	var wg sync.WaitGroup
+	for i, d := range children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := ""
+			if d.Platform != nil {
+				name = platformName(d.Platform.OS, d.Platform.Architecture, d.Platform.Variant)
+			}
+			child, err := c.fetchManifest(repo, d.Digest)
+			results[i] = result{
+				platform: Platform{Name: name, Digest: d.Digest, Size: child.size()},
+				child:    child,
+				err:      err,
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+		m.Platforms = append(m.Platforms, r.platform)
+		m.Size += r.platform.Size
+	}
+
+	if len(results) > 0 {
+		if cfg, err := c.fetchConfig(repo, results[0].child.Config.Digest); err == nil {
+			m.Created = cfg.Created
+			m.Labels = cfg.Config.Labels
+		}
+	}
+	return m, nil
+}
+
+func platformName(os, arch, variant string) string {
+	name := os + "/" + arch
+	if variant != "" {
+		name += "/" + variant
+	}
+	return name
+}
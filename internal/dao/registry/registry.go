@@ -0,0 +1,232 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+// Bounded fan-out when resolving per-repository / per-tag details.
+const maxConcurrentRequests = 8
+
+// Listings are cached briefly so the view refresh ticker does not hammer
+// the registry with one manifest request per tag every few seconds.
+const listCacheTTL = 15 * time.Second
+
+type cachedList struct {
+	at  time.Time
+	res []common.Resource
+}
+
+func (c *Client) cachedList(key string, fetch func() ([]common.Resource, error)) ([]common.Resource, error) {
+	c.mu.Lock()
+	if entry, ok := c.lists[key]; ok && time.Since(entry.at) < listCacheTTL {
+		c.mu.Unlock()
+		return entry.res, nil
+	}
+	c.mu.Unlock()
+
+	res, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.lists[key] = cachedList{at: time.Now(), res: res}
+	c.mu.Unlock()
+	return res, nil
+}
+
+// Invalidate drops cached listings so the next fetch hits the registry.
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	c.lists = make(map[string]cachedList)
+	c.mu.Unlock()
+}
+
+// Repository Model
+type Repository struct {
+	Name string
+	Tags int
+}
+
+func (r Repository) GetID() string { return r.Name }
+func (r Repository) GetCells() []string {
+	tags := fmt.Sprintf("%d", r.Tags)
+	if r.Tags < 0 {
+		tags = "-"
+	}
+	return []string{r.Name, tags}
+}
+
+func (r Repository) GetStatusColor() (tcell.Color, tcell.Color) {
+	if r.Tags == 0 {
+		return styles.ColorStatusGray, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (r Repository) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "repository":
+		return r.Name
+	case "tags":
+		return r.GetCells()[1]
+	}
+	return ""
+}
+
+func (r Repository) GetDefaultColumn() string {
+	return "Repository"
+}
+
+func (r Repository) GetDefaultSortColumn() string {
+	return "Repository"
+}
+
+// Tag Model
+type Tag struct {
+	Repository string
+	Name       string
+	Digest     string
+	Platforms  string
+	Size       string
+	Created    string
+	Error      string
+}
+
+func (t Tag) GetID() string { return t.Repository + ":" + t.Name }
+func (t Tag) GetCells() []string {
+	digest := t.Digest
+	if len(digest) > 19 {
+		digest = digest[:19]
+	}
+	if t.Error != "" {
+		return []string{t.Name, "-", t.Error, "-", "-"}
+	}
+	return []string{t.Name, digest, t.Platforms, t.Size, t.Created}
+}
+
+func (t Tag) GetStatusColor() (tcell.Color, tcell.Color) {
+	if t.Error != "" {
+		return styles.ColorStatusRed, styles.ColorBlack
+	}
+	return styles.ColorIdle, styles.ColorBlack
+}
+
+func (t Tag) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "tag":
+		return t.Name
+	case "digest":
+		return t.Digest
+	case "platforms":
+		return t.Platforms
+	case "size":
+		return t.Size
+	case "created":
+		return t.Created
+	}
+	return ""
+}
+
+func (t Tag) GetDefaultColumn() string {
+	return "Tag"
+}
+
+func (t Tag) GetDefaultSortColumn() string {
+	return "Tag"
+}
+
+// ListRepositories returns the catalog along with the tag count of each
+// repository.
+func (c *Client) ListRepositories() ([]common.Resource, error) {
+	return c.cachedList("", c.listRepositories)
+}
+
+func (c *Client) listRepositories() ([]common.Resource, error) {
+	names, err := c.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]Repository, len(names))
+	sem := make(chan struct{}, maxConcurrentRequests)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			count := -1
+			if tags, err := c.Tags(name); err == nil {
+				count = len(tags)
+			}
+			repos[i] = Repository{Name: name, Tags: count}
+		}()
+	}
+	wg.Wait()
+
+	res := make([]common.Resource, 0, len(repos))
+	for _, r := range repos {
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// ListTags returns the tags of repo with their manifest summary.
+func (c *Client) ListTags(repo string) ([]common.Resource, error) {
+	return c.cachedList(repo, func() ([]common.Resource, error) {
+		return c.listTags(repo)
+	})
+}
+
+func (c *Client) listTags(repo string) ([]common.Resource, error) {
+	names, err := c.Tags(repo)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	tags := make([]Tag, len(names))
+	sem := make(chan struct{}, maxConcurrentRequests)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			tag := Tag{Repository: repo, Name: name}
+			m, err := c.Manifest(repo, name)
+			if err != nil {
+				tag.Error = err.Error()
+			} else {
+				tag.Digest = m.Digest
+				tag.Platforms = m.PlatformNames()
+				tag.Size = common.FormatBytes(m.Size)
+				tag.Created = "-"
+				if !m.Created.IsZero() {
+					tag.Created = common.FormatTime(m.Created.Unix())
+				}
+			}
+			tags[i] = tag
+		}()
+	}
+	wg.Wait()
+
+	res := make([]common.Resource, 0, len(tags))
+	for _, t := range tags {
+		res = append(res, t)
+	}
+	return res, nil
+}
@@ -32,12 +32,14 @@ type Service struct {
 	ID       string
 	Name     string
 	Image    string
+	ImageRef string
 	Mode     string
 	Replicas string
 	Stack    string
 	Ports    string
 	Created  string
 	Updated  string
+	Update   string
 }
 
 func (s Service) GetID() string { return s.ID }
@@ -46,7 +48,7 @@ func (s Service) GetCells() []string {
 	if len(id) > 12 {
 		id = id[:12]
 	}
-	return []string{id, s.Name, s.Image, s.Mode, s.Replicas, s.Ports, s.Created, s.Updated}
+	return []string{id, s.Name, s.Image, s.Mode, s.Replicas, s.Ports, s.Created, s.Updated, s.Update}
 }
 
 func (s Service) GetStatusColor() (tcell.Color, tcell.Color) {
@@ -89,6 +91,8 @@ func (s Service) GetColumnValue(column string) string {
 		return s.Created
 	case "updated":
 		return s.Updated
+	case "update":
+		return s.Update
 	}
 	return ""
 }
@@ -161,6 +165,7 @@ func (m *Manager) List() ([]common.Resource, error) {
 			ID:       s.ID,
 			Name:     s.Spec.Name,
 			Image:    imageName,
+			ImageRef: s.Spec.TaskTemplate.ContainerSpec.Image,
 			Mode:     mode,
 			Replicas: replicas,
 			Stack:    s.Spec.Labels["com.docker.stack.namespace"],
@@ -0,0 +1,177 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/dao/docker/network"
+)
+
+// NetworkTopology is how the containers of the context are wired together:
+// the networks they share and the ports they publish on the host.
+type NetworkTopology struct {
+	Project    string // compose project the graph is restricted to, if any
+	Networks   []TopologyNetwork
+	Containers []TopologyContainer
+}
+
+// TopologyNetwork is a network and the containers attached to it.
+type TopologyNetwork struct {
+	ID       string
+	Name     string
+	Driver   string
+	Subnet   string
+	Internal bool
+	Members  []TopologyMember
+}
+
+// TopologyMember is a container on a network.
+type TopologyMember struct {
+	Container string
+	IPv4      string
+	IPv6      string
+	Aliases   []string
+	// Bridges lists the other networks of the container, through which it
+	// can relay between them.
+	Bridges []string
+}
+
+// TopologyContainer is a container with its networks and published ports.
+type TopologyContainer struct {
+	ID          string
+	Name        string
+	Project     string
+	Running     bool
+	NetworkMode string
+	Networks    []string
+	Ports       []TopologyPort
+}
+
+// TopologyPort is a container port published on the host.
+type TopologyPort struct {
+	HostIPs       []string
+	HostPort      uint16
+	ContainerPort uint16
+	Proto         string
+}
+
+func (p TopologyPort) Host() string {
+	return fmt.Sprintf("%s:%d", strings.Join(p.HostIPs, ","), p.HostPort)
+}
+
+// NetworkTopology builds the graph of the context, or of one compose
+// project. The network membership of each container comes from the
+// container info cache, refreshed from the container list on the way.
+func (d *DockerClient) NetworkTopology(project string) (*NetworkTopology, error) {
+	containers, err := d.Cli.ContainerList(d.Ctx, dcontainer.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	topology := &NetworkTopology{Project: project}
+	networks := make(map[string]*TopologyNetwork)
+	for _, c := range containers {
+		if project != "" && c.Labels["com.docker.compose.project"] != project {
+			continue
+		}
+
+		name := c.ID[:12]
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		// The list carries what ListNetworksForContainer reads from the
+		// cache: refresh the entry so the graph is current.
+		info := newContainerInfo(c)
+		d.cacheMu.Lock()
+		d.containerInfoMap[c.ID] = info
+		d.cacheMu.Unlock()
+
+		attached, err := d.ListNetworksForContainer(c.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		tc := TopologyContainer{
+			ID:          c.ID,
+			Name:        name,
+			Project:     c.Labels["com.docker.compose.project"],
+			Running:     c.State == "running",
+			NetworkMode: c.HostConfig.NetworkMode,
+			Ports:       topologyPorts(c.Ports),
+		}
+		for _, r := range attached {
+			n, ok := r.(network.Network)
+			if !ok {
+				continue
+			}
+			tc.Networks = append(tc.Networks, n.Name)
+			if networks[n.ID] == nil {
+				networks[n.ID] = &TopologyNetwork{
+					ID:       n.ID,
+					Name:     n.Name,
+					Driver:   n.Driver,
+					Subnet:   n.Subnet,
+					Internal: n.Internal == "Yes",
+				}
+			}
+			ep := info.Endpoints[n.ID]
+			networks[n.ID].Members = append(networks[n.ID].Members, TopologyMember{
+				Container: name,
+				IPv4:      ep.IPv4,
+				IPv6:      ep.IPv6,
+				Aliases:   common.UserAliases(ep.Aliases, name, c.ID),
+			})
+		}
+		sort.Strings(tc.Networks)
+		topology.Containers = append(topology.Containers, tc)
+	}
+
+	byName := make(map[string]TopologyContainer, len(topology.Containers))
+	for _, c := range topology.Containers {
+		byName[c.Name] = c
+	}
+	for _, n := range networks {
+		sort.Slice(n.Members, func(i, j int) bool { return n.Members[i].Container < n.Members[j].Container })
+		for i, m := range n.Members {
+			for _, other := range byName[m.Container].Networks {
+				if other != n.Name {
+					n.Members[i].Bridges = append(n.Members[i].Bridges, other)
+				}
+			}
+		}
+		topology.Networks = append(topology.Networks, *n)
+	}
+	sort.Slice(topology.Networks, func(i, j int) bool { return topology.Networks[i].Name < topology.Networks[j].Name })
+	sort.Slice(topology.Containers, func(i, j int) bool { return topology.Containers[i].Name < topology.Containers[j].Name })
+
+	return topology, nil
+}
+
+// topologyPorts keeps the published ports, merging the IPv4 and IPv6
+// bindings of the same port.
+func topologyPorts(ports []dcontainer.Port) []TopologyPort {
+	var res []TopologyPort
+	index := make(map[string]int)
+	for _, p := range ports {
+		if p.PublicPort == 0 {
+			continue
+		}
+		ip := p.IP
+		if ip == "" {
+			ip = "0.0.0.0"
+		}
+		key := fmt.Sprintf("%d/%d/%s", p.PublicPort, p.PrivatePort, p.Type)
+		if i, ok := index[key]; ok {
+			res[i].HostIPs = append(res[i].HostIPs, ip)
+			continue
+		}
+		index[key] = len(res)
+		res = append(res, TopologyPort{HostIPs: []string{ip}, HostPort: p.PublicPort, ContainerPort: p.PrivatePort, Proto: p.Type})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].HostPort < res[j].HostPort })
+	return res
+}
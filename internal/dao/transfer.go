@@ -0,0 +1,258 @@
+package dao
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// TransferProgress reports the number of bytes streamed so far.
+type TransferProgress func(written int64)
+
+// progressReader counts bytes flowing through r and reports them.
+type progressReader struct {
+	r        io.Reader
+	written  int64
+	progress TransferProgress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.written += int64(n)
+		if p.progress != nil {
+			p.progress(p.written)
+		}
+	}
+	return n, err
+}
+
+// progressWriter counts bytes written through w and reports them.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	progress TransferProgress
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if n > 0 {
+		p.written += int64(n)
+		if p.progress != nil {
+			p.progress(p.written)
+		}
+	}
+	return n, err
+}
+
+// openTransferClient opens a dedicated client for contextName without the
+// API timeout: archive streams (image save/load, volume copies) routinely
+// outlive apiServerTimeout.
+func (d *DockerClient) openTransferClient(contextName string) (*DockerClient, error) {
+	if contextName == "" || contextName == "env" {
+		contextName = "default"
+	}
+	return newDockerClient(contextName, 0, "", false)
+}
+
+// ImageSize returns the size of an image as reported by the daemon,
+// used as an estimate of its `docker save` archive size.
+func (d *DockerClient) ImageSize(ref string) int64 {
+	info, err := d.Cli.ImageInspect(d.Ctx, ref)
+	if err != nil {
+		return 0
+	}
+	return info.Size
+}
+
+// SaveImages writes refs as a tar archive into w.
+func (d *DockerClient) SaveImages(refs []string, w io.Writer, progress TransferProgress) error {
+	src, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(src.Image.Save(refs, pw))
+	}()
+
+	_, err = io.Copy(w, &progressReader{r: pr, progress: progress})
+	pr.Close()
+	return err
+}
+
+// LoadImages imports a tar archive produced by SaveImages.
+func (d *DockerClient) LoadImages(r io.Reader, progress TransferProgress) ([]string, error) {
+	dst, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+
+	return dst.Image.Load(&progressReader{r: r, progress: progress})
+}
+
+// CopyImagesToContext streams refs from the current daemon straight into
+// the daemon of targetContext, without staging the archive on disk.
+func (d *DockerClient) CopyImagesToContext(refs []string, targetContext string, progress TransferProgress) ([]string, error) {
+	if targetContext == d.ContextName {
+		return nil, fmt.Errorf("target context is the current context")
+	}
+
+	src, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	dst, err := d.openTransferClient(targetContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to context '%s': %v", targetContext, err)
+	}
+	defer dst.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(src.Image.Save(refs, pw))
+	}()
+
+	loaded, err := dst.Image.Load(&progressReader{r: pr, progress: progress})
+	pr.CloseWithError(io.ErrClosedPipe)
+	return loaded, err
+}
+
+// BackupVolume writes the content of a volume as a tar archive into w,
+// gzipped when compress is set. image is the helper container image.
+func (d *DockerClient) BackupVolume(name, image string, w io.Writer, compress bool, progress TransferProgress) error {
+	src, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if !compress {
+		return src.Volume.Backup(name, image, &progressWriter{w: w, progress: progress})
+	}
+
+	gz := gzip.NewWriter(w)
+	err = src.Volume.Backup(name, image, &progressWriter{w: gz, progress: progress})
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// RestoreVolume extracts a tar archive, gzipped or not, into a volume that
+// is created if needed. With empty set the volume is emptied first. A volume
+// created by a failed restore is removed.
+func (d *DockerClient) RestoreVolume(name, image string, r io.Reader, empty bool, progress TransferProgress) error {
+	dst, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	exists, err := dst.Volume.Exists(name)
+	if err != nil {
+		return err
+	}
+
+	err = dst.Volume.Restore(name, image, &progressReader{r: r, progress: progress}, empty)
+	if err != nil && !exists {
+		_ = dst.Volume.Remove(name, true)
+	}
+	return err
+}
+
+// CloneVolume copies a volume into a new volume on the current daemon.
+func (d *DockerClient) CloneVolume(source, target, image string) error {
+	cli, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	return cli.Volume.Clone(source, target, image)
+}
+
+// CopyVolumeToContext streams a volume from the current daemon into a new
+// volume of the same name on targetContext, then compares the checksums
+// of both copies. It returns the checksum both sides agree on.
+func (d *DockerClient) CopyVolumeToContext(name, image, targetContext string, progress TransferProgress) (string, error) {
+	if targetContext == d.ContextName {
+		return "", fmt.Errorf("target context is the current context")
+	}
+
+	src, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := d.openTransferClient(targetContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to context '%s': %v", targetContext, err)
+	}
+	defer dst.Close()
+
+	exists, err := dst.Volume.Exists(name)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("volume %s already exists on %s", name, targetContext)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(src.Volume.Backup(name, image, pw))
+	}()
+
+	err = dst.Volume.Restore(name, image, &progressReader{r: pr, progress: progress}, false)
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		_ = dst.Volume.Remove(name, true)
+		return "", err
+	}
+
+	srcSum, err := src.Volume.Checksum(name, image)
+	if err != nil {
+		return "", fmt.Errorf("copied, but failed to checksum the source: %v", err)
+	}
+	dstSum, err := dst.Volume.Checksum(name, image)
+	if err != nil {
+		return "", fmt.Errorf("copied, but failed to checksum the copy: %v", err)
+	}
+	if srcSum != dstSum {
+		return "", fmt.Errorf("checksum mismatch: %.12s here, %.12s on %s", srcSum, dstSum, targetContext)
+	}
+	return srcSum, nil
+}
+
+// DownloadVolumeFile writes a file of a volume into w, or a directory as a
+// tar archive.
+func (d *DockerClient) DownloadVolumeFile(volume, file, image string, w io.Writer, progress TransferProgress) error {
+	src, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = src.Volume.Download(volume, file, image, &progressWriter{w: w, progress: progress})
+	return err
+}
+
+// UploadVolumeFile copies a local file or directory into a directory of a
+// volume.
+func (d *DockerClient) UploadVolumeFile(volume, dir, image, localPath string) error {
+	dst, err := d.openTransferClient(d.ContextName)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	defer d.Volume.ForgetFiles(volume)
+
+	return dst.Volume.Upload(volume, dir, image, localPath)
+}
@@ -0,0 +1,362 @@
+package imagecheck
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/jr-k/d4s/internal/config"
+	"github.com/jr-k/d4s/internal/dao"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+type Status int
+
+const (
+	StatusUnknown  Status = iota // not checked yet, or excluded
+	StatusUpToDate               // local digest matches the registry
+	StatusOutdated               // registry has a newer manifest for the tag
+	StatusLocal                  // no repo digest: built locally, never pulled
+	StatusError                  // registry unreachable, auth failure, ...
+)
+
+// Result is the outcome of comparing one reference with its registry.
+type Result struct {
+	Ref          string
+	RemoteDigest string
+	Status       Status
+	Err          string
+	CheckedAt    time.Time
+}
+
+// Label renders the status for the UPDATE column.
+func (r Result) Label() string {
+	switch r.Status {
+	case StatusUpToDate:
+		return fmt.Sprintf("[%s]up-to-date[-]", styles.TagDim)
+	case StatusOutdated:
+		return fmt.Sprintf("[%s]outdated[-]", styles.ColorStatusOrange.String())
+	case StatusLocal:
+		return fmt.Sprintf("[%s]local[-]", styles.TagDim)
+	case StatusError:
+		return fmt.Sprintf("[%s]error[-]", styles.TagError)
+	}
+	return ""
+}
+
+// Checker periodically compares local images (and swarm service images)
+// with the current manifest digest of their tag in the registry.
+type Checker struct {
+	cfg func() config.UpdateCheckConfig
+
+	mu       sync.RWMutex
+	images   map[string]Result // image ID -> result
+	refIDs   map[string]string // normalized ref -> local image ID
+	services map[string]Result // service image spec -> result
+	clients  map[string]*dao.RegistryClient
+
+	trigger chan struct{}
+	stop    chan struct{}
+	running bool
+	checked func()
+}
+
+func NewChecker(cfg func() config.UpdateCheckConfig) *Checker {
+	return &Checker{
+		cfg:      cfg,
+		images:   make(map[string]Result),
+		refIDs:   make(map[string]string),
+		services: make(map[string]Result),
+		clients:  make(map[string]*dao.RegistryClient),
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Start runs the check loop against whatever client docker returns at
+// each tick (it changes on context switch). onChecked is called after
+// every completed pass.
+func (c *Checker) Start(docker func() *dao.DockerClient, onChecked func()) {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = true
+	c.stop = make(chan struct{})
+	c.checked = onChecked
+	stop := c.stop
+	c.mu.Unlock()
+
+	go func() {
+		// Let the first views load before hitting registries.
+		timer := time.NewTimer(5 * time.Second)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+			case <-c.trigger:
+			}
+
+			cfg := c.cfg()
+			if cfg.Enabled {
+				if d := docker(); d != nil {
+					c.check(d, cfg)
+				}
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(cfg.GetInterval())
+		}
+	}()
+}
+
+// Shutdown stops the check loop.
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running {
+		close(c.stop)
+		c.running = false
+	}
+}
+
+// CheckNow schedules an immediate pass.
+func (c *Checker) CheckNow() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// ForImage returns the result for a local image ID.
+func (c *Checker) ForImage(imageID string) (Result, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.images[strings.TrimPrefix(imageID, "sha256:")]
+	return r, ok
+}
+
+// ForContainer returns the result for a container created from ref. A
+// container still running an older image than the one ref now points to
+// locally is outdated even if the local tag is current.
+func (c *Checker) ForContainer(ref, imageID string) (Result, bool) {
+	imageID = strings.TrimPrefix(imageID, "sha256:")
+
+	c.mu.RLock()
+	localID, known := c.refIDs[normalize(ref)]
+	c.mu.RUnlock()
+
+	if known && localID != imageID {
+		return Result{Ref: ref, Status: StatusOutdated}, true
+	}
+	return c.ForImage(imageID)
+}
+
+// ForService returns the result for a service image spec
+// ("nginx:latest@sha256:...").
+func (c *Checker) ForService(spec string) (Result, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.services[spec]
+	return r, ok
+}
+
+func (c *Checker) check(docker *dao.DockerClient, cfg config.UpdateCheckConfig) {
+	remote := make(map[string]Result) // normalized ref -> registry digest lookup
+
+	lookup := func(ref string) (reference.NamedTagged, Result, bool) {
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil {
+			return nil, Result{}, false
+		}
+		tagged, ok := reference.TagNameOnly(named).(reference.NamedTagged)
+		if !ok || !c.included(cfg, reference.FamiliarString(tagged)) {
+			return nil, Result{}, false
+		}
+
+		key := tagged.String()
+		if r, ok := remote[key]; ok {
+			return tagged, r, true
+		}
+		r := c.remoteDigest(tagged)
+		remote[key] = r
+		return tagged, r, true
+	}
+
+	if infos, err := docker.ListImageRepoInfo(); err == nil {
+		images := make(map[string]Result)
+		refIDs := make(map[string]string)
+
+		for _, info := range infos {
+			for _, tag := range info.RepoTags {
+				tagged, r, ok := lookup(tag)
+				if !ok {
+					continue
+				}
+				refIDs[tagged.String()] = info.ID
+
+				switch {
+				case r.Status == StatusError:
+				case !hasDigestFor(info.RepoDigests, tagged.Name()):
+					r.Status = StatusLocal
+				case containsDigest(info.RepoDigests, tagged.Name(), r.RemoteDigest):
+					r.Status = StatusUpToDate
+				default:
+					r.Status = StatusOutdated
+				}
+				r.Ref = reference.FamiliarString(tagged)
+
+				// An image can carry several tags: report the worst.
+				if prev, ok := images[info.ID]; !ok || rank(r.Status) > rank(prev.Status) {
+					images[info.ID] = r
+				}
+			}
+		}
+
+		c.mu.Lock()
+		c.images = images
+		c.refIDs = refIDs
+		c.mu.Unlock()
+	}
+
+	if svcs, err := docker.ListServices(); err == nil {
+		services := make(map[string]Result)
+		for _, res := range svcs {
+			svc, ok := res.(dao.Service)
+			if !ok || svc.ImageRef == "" {
+				continue
+			}
+			tagged, r, ok := lookup(svc.ImageRef)
+			if !ok {
+				continue
+			}
+
+			if r.Status != StatusError {
+				r.Status = StatusUnknown
+				if named, err := reference.ParseNormalizedNamed(svc.ImageRef); err == nil {
+					if digested, ok := named.(reference.Digested); ok {
+						r.Status = StatusOutdated
+						if digested.Digest().String() == r.RemoteDigest {
+							r.Status = StatusUpToDate
+						}
+					}
+				}
+			}
+			r.Ref = reference.FamiliarString(tagged)
+			services[svc.ImageRef] = r
+		}
+
+		c.mu.Lock()
+		c.services = services
+		c.mu.Unlock()
+	}
+
+	c.mu.RLock()
+	onChecked := c.checked
+	c.mu.RUnlock()
+	if onChecked != nil {
+		onChecked()
+	}
+}
+
+func (c *Checker) remoteDigest(tagged reference.NamedTagged) Result {
+	r := Result{CheckedAt: time.Now()}
+
+	domain := reference.Domain(tagged)
+	c.mu.Lock()
+	client, ok := c.clients[domain]
+	if !ok {
+		var err error
+		client, err = dao.NewRegistryClient(domain, false)
+		if err != nil {
+			c.mu.Unlock()
+			r.Status, r.Err = StatusError, err.Error()
+			return r
+		}
+		c.clients[domain] = client
+	}
+	c.mu.Unlock()
+
+	digest, err := client.Digest(reference.Path(tagged), tagged.Tag())
+	if err != nil {
+		r.Status, r.Err = StatusError, err.Error()
+		return r
+	}
+	r.RemoteDigest = digest
+	return r
+}
+
+func (c *Checker) included(cfg config.UpdateCheckConfig, ref string) bool {
+	for _, p := range cfg.Exclude {
+		if matchPattern(p, ref) {
+			return false
+		}
+	}
+	if len(cfg.Include) == 0 {
+		return true
+	}
+	for _, p := range cfg.Include {
+		if matchPattern(p, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a glob where "*" spans any characters, slashes
+// included ("*:latest", "ghcr.io/acme/*").
+func matchPattern(pattern, ref string) bool {
+	expr := "^" + strings.ReplaceAll(strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*"), `\?`, ".") + "$"
+	re, err := regexp.Compile(expr)
+	return err == nil && re.MatchString(ref)
+}
+
+func normalize(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+func hasDigestFor(repoDigests []string, name string) bool {
+	for _, rd := range repoDigests {
+		if n, _, ok := strings.Cut(rd, "@"); ok && normalize(n) == normalize(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDigest(repoDigests []string, name, digest string) bool {
+	for _, rd := range repoDigests {
+		if n, d, ok := strings.Cut(rd, "@"); ok && d == digest && normalize(n) == normalize(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func rank(s Status) int {
+	switch s {
+	case StatusOutdated:
+		return 4
+	case StatusError:
+		return 3
+	case StatusUpToDate:
+		return 2
+	case StatusLocal:
+		return 1
+	}
+	return 0
+}
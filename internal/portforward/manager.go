@@ -54,7 +54,7 @@ func (pf PortForward) GetCells() []string {
 		pf.ContainerName,
 		fmt.Sprintf("localhost:%d", pf.LocalPort),
 		fmt.Sprintf("%s:%d", remoteIP, remotePort),
-		formatAge(pf.CreatedAt),
+		common.FormatAge(pf.CreatedAt),
 	}
 }
 
@@ -82,7 +82,7 @@ func (pf PortForward) GetColumnValue(column string) string {
 		remoteIP, remotePort := pf.remoteTarget()
 		return fmt.Sprintf("%s:%d", remoteIP, remotePort)
 	case "age":
-		return formatAge(pf.CreatedAt)
+		return common.FormatAge(pf.CreatedAt)
 	}
 	return ""
 }
@@ -204,17 +204,3 @@ func (m *Manager) Shutdown() {
 	}
 	m.forwards = make(map[string]*PortForward)
 }
-
-func formatAge(t time.Time) string {
-	d := time.Since(t)
-	switch {
-	case d < time.Minute:
-		return fmt.Sprintf("%ds", int(d.Seconds()))
-	case d < time.Hour:
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	case d < 24*time.Hour:
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	default:
-		return fmt.Sprintf("%dd", int(d.Hours()/24))
-	}
-}
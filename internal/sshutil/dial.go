@@ -0,0 +1,106 @@
+package sshutil
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/connhelper/ssh"
+	"github.com/jr-k/d4s/internal/secrets"
+)
+
+// DockerDialer dials the daemon of an ssh:// context through `docker
+// system dial-stdio`, like the connection helper of the docker CLI, but
+// with the askpass environment of the context set on each ssh command
+// instead of on the process: several contexts can then be connected at
+// once (e.g. copies between contexts) without their secrets mixing.
+func DockerDialer(contextName, daemonURL string, sshFlags []string) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	u, err := url.Parse(daemonURL)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := ssh.NewSpec(u)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := append([]string{}, sshFlags...)
+	if !strings.Contains(strings.Join(flags, ""), "ConnectTimeout") {
+		flags = append(flags, "-o ConnectTimeout=30")
+	}
+	flags = append(flags, "-T")
+
+	remote := []string{"docker", "system", "dial-stdio"}
+	if strings.Trim(sp.Path, "/") != "" {
+		remote = []string{"docker", "--host=unix://" + sp.Path, "system", "dial-stdio"}
+	}
+	args, err := sp.Command(flags, remote...)
+	if err != nil {
+		return nil, err
+	}
+
+	env := append(os.Environ(), secrets.AskpassEnv(contextName)...)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialCommand(env, "ssh", args...)
+	}, nil
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a command.
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	closeOnce sync.Once
+}
+
+func dialCommand(env []string, name string, args ...string) (net.Conn, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+// CloseWrite half-closes the connection, for hijacked streams.
+func (c *commandConn) CloseWrite() error { return c.stdin.Close() }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		if c.cmd.Process != nil {
+			_ = c.cmd.Process.Kill()
+		}
+		_ = c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr{} }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr{} }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string { return "command" }
+func (commandAddr) String() string  { return "ssh" }
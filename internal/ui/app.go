@@ -12,8 +12,10 @@ import (
 	"runtime/debug"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/capture"
 	"github.com/jr-k/d4s/internal/config"
 	"github.com/jr-k/d4s/internal/dao"
+	"github.com/jr-k/d4s/internal/imagecheck"
 	"github.com/jr-k/d4s/internal/portforward"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/command"
@@ -23,21 +25,27 @@ import (
 	"github.com/jr-k/d4s/internal/ui/dialogs"
 	"github.com/jr-k/d4s/internal/ui/styles"
 	"github.com/jr-k/d4s/internal/ui/views/aliases"
+	"github.com/jr-k/d4s/internal/ui/views/captures"
 	"github.com/jr-k/d4s/internal/ui/views/compose"
 	"github.com/jr-k/d4s/internal/ui/views/configs"
 	"github.com/jr-k/d4s/internal/ui/views/containers"
 	"github.com/jr-k/d4s/internal/ui/views/contexts"
+	"github.com/jr-k/d4s/internal/ui/views/df"
 	"github.com/jr-k/d4s/internal/ui/views/images"
+	"github.com/jr-k/d4s/internal/ui/views/jobs"
 	"github.com/jr-k/d4s/internal/ui/views/networks"
 	"github.com/jr-k/d4s/internal/ui/views/nodes"
 	"github.com/jr-k/d4s/internal/ui/views/plugins"
 	"github.com/jr-k/d4s/internal/ui/views/portforwards"
+	"github.com/jr-k/d4s/internal/ui/views/registry"
 	"github.com/jr-k/d4s/internal/ui/views/secrets"
 	"github.com/jr-k/d4s/internal/ui/views/services"
 	"github.com/jr-k/d4s/internal/ui/views/stacks"
 	"github.com/jr-k/d4s/internal/ui/views/tasks"
 	"github.com/jr-k/d4s/internal/ui/views/volumes"
+	"github.com/jr-k/d4s/internal/ui/views/watches"
 	"github.com/jr-k/d4s/internal/updater"
+	"github.com/jr-k/d4s/internal/watch"
 	"github.com/rivo/tview"
 )
 
@@ -48,6 +56,9 @@ type App struct {
 	dockerMx     sync.RWMutex
 	Cfg          *config.Config
 	PortForwards *portforward.Manager
+	Watches      *watch.Manager
+	Captures     *capture.Manager
+	ImageCheck   *imagecheck.Checker
 
 	// Components
 	Layout  *tview.Flex
@@ -129,6 +140,7 @@ func NewApp(contextName string, cfg *config.Config) (*App, error) {
 			return nil, fmt.Errorf("failed to init docker client: %w (original error: %v)", fallbackErr, dockerErr)
 		}
 	}
+	docker.SetComposeWorkspaces(cfg.D4S.Compose.Workspaces, cfg.D4S.Compose.GetScanDepth())
 
 	screen, err := tcell.NewScreen()
 	if err != nil {
@@ -149,10 +161,16 @@ func NewApp(contextName string, cfg *config.Config) (*App, error) {
 		Docker:       docker,
 		Cfg:          cfg,
 		PortForwards: portforward.NewManager(),
+		Watches:      watch.NewManager(),
+		Captures:     capture.NewManager(),
 		Views:        make(map[string]*view.ResourceView),
 		Pages:        tview.NewPages(),
 	}
 
+	app.ImageCheck = imagecheck.NewChecker(func() config.UpdateCheckConfig {
+		return app.Cfg.D4S.UpdateCheck
+	})
+
 	app.initUI()
 
 	if dockerErr != nil {
@@ -187,6 +205,20 @@ func (a *App) Run() error {
 	shellImage := a.Cfg.D4S.ShellPod.Image
 	go common.DockerCommand(a, "pull", shellImage).Run()
 
+	// Compare local image digests with their registries in background
+	a.ImageCheck.Start(a.GetDocker, func() {
+		a.SafeQueueUpdateDraw(func() {
+			a.RefreshCurrentView()
+		})
+	})
+	defer a.ImageCheck.Shutdown()
+
+	// Compose watches are child processes: they must not outlive d4s
+	defer a.Watches.Shutdown()
+
+	// Captures run helper containers: stop them, the files are kept
+	defer a.Captures.Shutdown()
+
 	// Preload all views data in background for instant navigation
 	a.preloadViews()
 
@@ -254,20 +286,25 @@ func (a *App) StopAutoRefresh() {
 
 var configurableViewKeys = map[string]struct{}{
 	"aliases":      {},
+	"captures":     {},
 	"compose":      {},
 	"configmaps":   {},
 	"containers":   {},
 	"contexts":     {},
+	"df":           {},
 	"images":       {},
+	"jobs":         {},
 	"networks":     {},
 	"nodes":        {},
 	"plugins":      {},
 	"portforwards": {},
+	"registry":     {},
 	"secrets":      {},
 	"services":     {},
 	"stacks":       {},
 	"tasks":        {},
 	"volumes":      {},
+	"watches":      {},
 }
 
 func (a *App) configureViewColumns(key string, resourceView *view.ResourceView, headers []string, knownHeaders ...[]string) {
@@ -329,7 +366,6 @@ func (a *App) initUI() {
 	vImages.FetchFunc = images.Fetch
 	vImages.InspectFunc = images.Inspect
 	vImages.RemoveFunc = images.Remove
-	vImages.PruneFunc = images.Prune
 	a.configureViewColumns("images", vImages, images.Headers)
 
 	// Default Sort: Containers DESC
@@ -343,11 +379,12 @@ func (a *App) initUI() {
 
 	// Volumes
 	vVolumes := view.NewResourceView(a, styles.TitleVolumes)
-	vVolumes.ShortcutsFunc = volumes.GetShortcuts
+	vVolumes.ShortcutsFunc = func() []string {
+		return volumes.GetShortcuts(a)
+	}
 	vVolumes.FetchWithHeadersFunc = volumes.Fetch
 	vVolumes.InspectFunc = volumes.Inspect
 	vVolumes.RemoveFunc = volumes.Remove
-	vVolumes.PruneFunc = volumes.Prune
 	a.configureViewColumns("volumes", vVolumes, volumes.Headers, volumes.AllHeaders)
 	vVolumes.PinnedSortColumn = "ANON"
 	vVolumes.PinnedSortAsc = true
@@ -358,12 +395,13 @@ func (a *App) initUI() {
 
 	// Networks
 	vNetworks := view.NewResourceView(a, styles.TitleNetworks)
-	vNetworks.ShortcutsFunc = networks.GetShortcuts
-	vNetworks.FetchFunc = networks.Fetch
+	vNetworks.ShortcutsFunc = func() []string {
+		return networks.GetShortcuts(a)
+	}
+	vNetworks.FetchWithHeadersFunc = networks.Fetch
 	vNetworks.InspectFunc = networks.Inspect
 	vNetworks.RemoveFunc = networks.Remove
-	vNetworks.PruneFunc = networks.Prune
-	a.configureViewColumns("networks", vNetworks, networks.Headers)
+	a.configureViewColumns("networks", vNetworks, networks.Headers, networks.AllHeaders)
 	vNetworks.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
 		return networks.InputHandler(vNetworks, event)
 	}
@@ -395,10 +433,12 @@ func (a *App) initUI() {
 
 	// Compose
 	vCompose := view.NewResourceView(a, styles.TitleCompose)
-	vCompose.ShortcutsFunc = compose.GetShortcuts
-	vCompose.FetchFunc = compose.Fetch
+	vCompose.ShortcutsFunc = func() []string {
+		return compose.GetShortcuts(vCompose)
+	}
+	vCompose.FetchWithHeadersFunc = compose.Fetch
 	vCompose.InspectFunc = compose.Inspect
-	a.configureViewColumns("compose", vCompose, compose.Headers)
+	a.configureViewColumns("compose", vCompose, compose.Headers, compose.AllHeaders)
 	vCompose.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
 		return compose.InputHandler(vCompose, event)
 	}
@@ -494,6 +534,60 @@ func (a *App) initUI() {
 	}
 	a.Views[styles.TitlePortForwards] = vPortForwards
 
+	// Compose jobs
+	vJobs := view.NewResourceView(a, styles.TitleJobs)
+	vJobs.ShortcutsFunc = jobs.GetShortcuts
+	vJobs.FetchFunc = jobs.Fetch
+	vJobs.RemoveFunc = jobs.Remove
+	a.configureViewColumns("jobs", vJobs, jobs.Headers)
+	vJobs.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
+		return jobs.InputHandler(vJobs, event)
+	}
+	a.Views[styles.TitleJobs] = vJobs
+
+	// Compose watches
+	vWatches := view.NewResourceView(a, styles.TitleWatches)
+	vWatches.ShortcutsFunc = watches.GetShortcuts
+	vWatches.FetchFunc = watches.Fetch
+	a.configureViewColumns("watches", vWatches, watches.Headers)
+	vWatches.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
+		return watches.InputHandler(vWatches, event)
+	}
+	a.Views[styles.TitleWatches] = vWatches
+
+	// Packet captures
+	vCaptures := view.NewResourceView(a, styles.TitleCaptures)
+	vCaptures.ShortcutsFunc = captures.GetShortcuts
+	vCaptures.FetchFunc = captures.Fetch
+	a.configureViewColumns("captures", vCaptures, captures.Headers)
+	vCaptures.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
+		return captures.InputHandler(vCaptures, event)
+	}
+	a.Views[styles.TitleCaptures] = vCaptures
+
+	// Registry
+	vRegistry := view.NewResourceView(a, styles.TitleRegistry)
+	vRegistry.ShortcutsFunc = registry.GetShortcuts
+	vRegistry.FetchWithHeadersFunc = registry.Fetch
+	vRegistry.InspectFunc = registry.Inspect
+	a.configureViewColumns("registry", vRegistry, registry.Headers, registry.AllHeaders)
+	vRegistry.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
+		return registry.InputHandler(vRegistry, event)
+	}
+	a.Views[styles.TitleRegistry] = vRegistry
+
+	// Disk Usage
+	vDiskUsage := view.NewResourceView(a, styles.TitleDiskUsage)
+	vDiskUsage.ShortcutsFunc = df.GetShortcuts
+	vDiskUsage.FetchWithHeadersFunc = df.Fetch
+	a.configureViewColumns("df", vDiskUsage, df.Headers, df.AllHeaders)
+	vDiskUsage.InitialSortColumn = "SIZE"
+	vDiskUsage.SortAsc = false
+	vDiskUsage.InputHandler = func(event *tcell.EventKey) *tcell.EventKey {
+		return df.InputHandler(vDiskUsage, event)
+	}
+	a.Views[styles.TitleDiskUsage] = vDiskUsage
+
 	a.warnUnknownViewConfigs()
 
 	for title, view := range a.Views {
@@ -548,7 +642,7 @@ func (a *App) initUI() {
 		// Don't intercept global keys if a modal/dialog is open
 		frontPage, _ := a.Pages.GetFrontPage()
 		switch frontPage {
-		case "input", "confirm", "form", "picker", "env_editor", "secret_editor", "mount_editor", "mount_type_picker", "textview", "result", "portforward":
+		case "input", "confirm", "form", "picker", "env_editor", "secret_editor", "mount_editor", "mount_type_picker", "textview", "result", "portforward", "prune_preview":
 			return event
 		}
 
@@ -687,6 +781,18 @@ func (a *App) GetPortForwardManager() *portforward.Manager {
 	return a.PortForwards
 }
 
+func (a *App) GetWatchManager() *watch.Manager {
+	return a.Watches
+}
+
+func (a *App) GetCaptureManager() *capture.Manager {
+	return a.Captures
+}
+
+func (a *App) GetImageChecker() *imagecheck.Checker {
+	return a.ImageCheck
+}
+
 func (a *App) GetConfig() *config.Config {
 	return a.Cfg
 }
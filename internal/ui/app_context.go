@@ -103,6 +103,7 @@ func (a *App) ReloadContext(contextName string) {
 			})
 			return
 		}
+		newDocker.SetComposeWorkspaces(a.Cfg.D4S.Compose.Workspaces, a.Cfg.D4S.Compose.GetScanDepth())
 
 		a.TviewApp.QueueUpdateDraw(func() {
 			if a.contextSwitchGen.Load() != switchGen {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/jr-k/d4s/internal/ui/views/networks"
 )
 
 func (a *App) ActivateCmd(initial string) {
@@ -20,6 +21,16 @@ func (a *App) ExecuteCmd(cmd string) {
 		a.SwitchTo(title)
 	}
 
+	// Commands taking an argument
+	if fields := strings.Fields(cmd); len(fields) > 0 {
+		switch fields[0] {
+		case "topo", "topology":
+			// Optional compose project to restrict the graph to
+			networks.Topology(a, strings.Join(fields[1:], " "))
+			return
+		}
+	}
+
 	switch cmd {
 	case "q", "quit":
 		a.TviewApp.Stop()
@@ -53,6 +64,16 @@ func (a *App) ExecuteCmd(cmd string) {
 		switchToRoot(styles.TitlePlugins)
 	case "w", "pf", "portforward", "portforwards":
 		switchToRoot(styles.TitlePortForwards)
+	case "j", "job", "jobs":
+		switchToRoot(styles.TitleJobs)
+	case "cw", "watch", "watches":
+		switchToRoot(styles.TitleWatches)
+	case "cap", "capture", "captures":
+		switchToRoot(styles.TitleCaptures)
+	case "reg", "registry", "registries":
+		switchToRoot(styles.TitleRegistry)
+	case "df", "du", "diskusage":
+		switchToRoot(styles.TitleDiskUsage)
 	case "h", "help", "?":
 		a.Pages.AddPage("help", a.Help, true, true)
 	default:
@@ -108,7 +108,7 @@ func (a *App) RefreshCurrentView() {
 	// Modal check logic needs specific naming convention or check
 	switch page {
 	case "help", "logs", "confirm", "result", "input", "textview",
-		"form", "picker", "portforward", "env_editor":
+		"form", "picker", "portforward", "env_editor", "prune_preview":
 		return
 	}
 
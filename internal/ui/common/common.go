@@ -6,10 +6,14 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/capture"
 	"github.com/jr-k/d4s/internal/config"
 	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/imagecheck"
 	"github.com/jr-k/d4s/internal/portforward"
 	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/jr-k/d4s/internal/watch"
 	"github.com/rivo/tview"
 )
 
@@ -39,6 +43,7 @@ type AppController interface {
 	PerformAction(action func(id string) error, actionName string, color tcell.Color)
 	GetActionState(viewName string, id string) (string, tcell.Color, bool)
 	InspectCurrentSelection()
+	CopyToClipboard(text string) error
 
 	// State
 	IsReadOnly() bool
@@ -82,6 +87,15 @@ type AppController interface {
 	// Port-Forward Management
 	GetPortForwardManager() *portforward.Manager
 
+	// Compose watch sessions
+	GetWatchManager() *watch.Manager
+
+	// Packet captures
+	GetCaptureManager() *capture.Manager
+
+	// Image update checks (local vs registry digest)
+	GetImageChecker() *imagecheck.Checker
+
 	// Refactoring: Auto Refresh Control
 	StartAutoRefresh()
 	StopAutoRefresh()
@@ -107,3 +121,23 @@ func DockerCommand(app AppController, args ...string) *exec.Cmd {
 	}
 	return exec.Command("docker", cmdArgs...)
 }
+
+// TransferProgress returns a throttled callback reporting streamed bytes
+// in the flash bar, e.g. "copying nginx:latest... 12 MiB / ~80 MiB".
+func TransferProgress(app AppController, label string, total int64) dao.TransferProgress {
+	var last time.Time
+	return func(written int64) {
+		if time.Since(last) < 250*time.Millisecond {
+			return
+		}
+		last = time.Now()
+
+		msg := fmt.Sprintf("%s... %s", label, daocommon.FormatBytes(written))
+		if total > 0 {
+			msg += " / ~" + daocommon.FormatBytes(total)
+		}
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			app.AppendFlashPending(msg, 30*time.Second)
+		})
+	}
+}
@@ -120,6 +120,10 @@ var availableCommands = []string{
 	"contexts",
 	"plugins",
 	"portforwards",
+	"captures",
+	"registry",
+	"df",
+	"topology",
 	"help",
 	"aliases",
 	"q",
@@ -0,0 +1,341 @@
+package inspect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/gdamore/tcell/v2"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/rivo/tview"
+)
+
+// OutputInspector follows the output of a command run (compose up, build,
+// down...) and keeps showing it once the run is over.
+type OutputInspector struct {
+	App        common.AppController
+	Flex       *tview.Flex
+	HeaderView *tview.TextView
+	TextView   *tview.TextView
+	Action     string
+	Subject    string
+	Run        *daocommon.CmdRun
+
+	// Settings
+	AutoScroll bool
+	Fullscreen bool
+	Wrap       bool
+	filter     string
+
+	lines []string
+	stop  chan struct{}
+}
+
+// Ensure implementation
+var _ common.Inspector = (*OutputInspector)(nil)
+
+func NewOutputInspector(action, subject string, run *daocommon.CmdRun) *OutputInspector {
+	return &OutputInspector{
+		Action:     action,
+		Subject:    subject,
+		Run:        run,
+		AutoScroll: true,
+	}
+}
+
+func (i *OutputInspector) GetID() string {
+	return "inspect"
+}
+
+func (i *OutputInspector) GetPrimitive() tview.Primitive {
+	return i.Flex
+}
+
+func (i *OutputInspector) GetTitle() string {
+	return FormatInspectorTitle(i.Action, i.Subject, i.runState(), i.filter, 0, 0)
+}
+
+// runState is the run status shown in the title, e.g. "running 12s".
+func (i *OutputInspector) runState() string {
+	done, finished, err := i.Run.State()
+	switch {
+	case !done:
+		return fmt.Sprintf("[%s]running %s", styles.TagAccent, time.Since(i.Run.Started).Round(time.Second))
+	case err == daocommon.ErrCanceled:
+		return fmt.Sprintf("[%s]canceled", styles.TagDim)
+	case err != nil:
+		return fmt.Sprintf("[%s]failed", styles.TagError)
+	default:
+		return fmt.Sprintf("[%s]done in %s", styles.TagInfo, finished.Sub(i.Run.Started).Round(time.Second))
+	}
+}
+
+func (i *OutputInspector) GetStatus() string {
+	fmtStatus := func(label string, active bool) string {
+		c := fmt.Sprintf("[%s]Off[-]", styles.TagDim)
+		if active {
+			c = fmt.Sprintf("[%s]On[-]", styles.TagInfo)
+		}
+		return fmt.Sprintf("[%s]%s:[-]%s", styles.TagSCKey, label, c)
+	}
+
+	parts := []string{}
+	parts = append(parts, fmtStatus("[::b]Autoscroll[::-]", i.AutoScroll))
+	parts = append(parts, fmtStatus("[::b]Fullscreen[::-]", i.Fullscreen))
+	parts = append(parts, fmtStatus("[::b]Wrap[::-]", i.Wrap))
+
+	return strings.Join(parts, "     ")
+}
+
+func (i *OutputInspector) GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("x", "Cancel"),
+		common.FormatSCHeader("c", "Copy"),
+		common.FormatSCHeader("s", "Toggle AutoScroll"),
+		common.FormatSCHeader("f", "Toggle FullScreen"),
+		common.FormatSCHeader("w", "Toggle Wrap"),
+	}
+}
+
+func (i *OutputInspector) OnMount(app common.AppController) {
+	i.App = app
+
+	i.HeaderView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter).
+		SetWrap(false).
+		SetText(i.GetStatus())
+	i.HeaderView.SetBackgroundColor(styles.ColorBlack)
+
+	i.TextView = tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(i.Wrap).
+		SetWrap(i.Wrap).
+		SetTextColor(styles.ColorIdle)
+
+	i.TextView.SetChangedFunc(func() {
+		if i.AutoScroll {
+			i.TextView.ScrollToEnd()
+		}
+	})
+	i.TextView.SetBackgroundColor(styles.ColorBlack)
+
+	i.Flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(i.HeaderView, 1, 1, false).
+		AddItem(i.TextView, 0, 1, true)
+
+	i.Flex.SetBorder(true).
+		SetTitle(i.GetTitle()).
+		SetTitleColor(styles.ColorTitle).
+		SetBorderColor(styles.ColorIdle).
+		SetBackgroundColor(styles.ColorBlack).
+		SetBorderPadding(0, 0, 0, 0)
+
+	i.stop = make(chan struct{})
+	go i.follow(i.stop)
+}
+
+// OnUnmount stops following the output; the run itself goes on.
+func (i *OutputInspector) OnUnmount() {
+	if i.stop != nil {
+		close(i.stop)
+		i.stop = nil
+	}
+	if i.Fullscreen {
+		i.App.SetFullscreen(false)
+	}
+}
+
+func (i *OutputInspector) ApplyFilter(filter string) {
+	i.filter = filter
+	i.updateTitle()
+	i.render()
+}
+
+func (i *OutputInspector) InputHandler(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEsc {
+		i.App.CloseInspector()
+		return nil
+	}
+
+	if event.Rune() == '/' {
+		i.App.ActivateCmd("/")
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'x':
+		if done, _, _ := i.Run.State(); done {
+			i.App.AppendFlashError("nothing to cancel: the run is over")
+			return nil
+		}
+		i.Run.Cancel()
+		i.App.AppendFlashPending(fmt.Sprintf("canceling %s...", i.Subject))
+	case 's':
+		i.AutoScroll = !i.AutoScroll
+		i.updateTitle()
+		if i.AutoScroll && i.TextView != nil {
+			i.TextView.ScrollToEnd()
+		}
+	case 'f':
+		i.Fullscreen = !i.Fullscreen
+		i.App.SetFullscreen(i.Fullscreen)
+		if i.Flex != nil {
+			i.Flex.SetBorder(!i.Fullscreen)
+		}
+		i.updateTitle()
+	case 'w':
+		i.Wrap = !i.Wrap
+		i.updateTitle()
+		if i.TextView != nil {
+			i.TextView.SetWordWrap(i.Wrap)
+			i.TextView.SetWrap(i.Wrap)
+		}
+	case 'c':
+		content := i.Run.Output()
+		if err := clipboard.WriteAll(content); err != nil {
+			i.App.AppendFlashError(fmt.Sprintf("%v", err))
+		} else {
+			i.App.AppendFlashSuccess(fmt.Sprintf("copied %d bytes", len(content)))
+		}
+	}
+
+	return event
+}
+
+// follow appends the new output as it comes, in batches, until the run is
+// over or the inspector is closed.
+func (i *OutputInspector) follow(stop chan struct{}) {
+	offset := 0
+	for {
+		lines, next, updated := i.Run.Since(offset)
+		offset = next
+
+		i.App.GetTviewApp().QueueUpdateDraw(func() {
+			i.append(lines)
+			i.updateTitle()
+		})
+		if updated == nil {
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-updated:
+		case <-time.After(time.Second): // refresh the elapsed time
+		}
+
+		// Progress output comes in bursts: batch it
+		select {
+		case <-stop:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (i *OutputInspector) append(lines []string) {
+	if i.TextView == nil || len(lines) == 0 {
+		return
+	}
+	i.lines = append(i.lines, lines...)
+
+	var sb strings.Builder
+	for _, line := range lines {
+		if i.matches(line) {
+			sb.WriteString(highlightOutputLine(line))
+			sb.WriteByte('\n')
+		}
+	}
+	fmt.Fprint(i.TextView, sb.String())
+}
+
+func (i *OutputInspector) render() {
+	if i.TextView == nil {
+		return
+	}
+	lines := i.lines
+	i.lines = nil
+	i.TextView.Clear()
+	i.append(lines)
+}
+
+func (i *OutputInspector) matches(line string) bool {
+	return i.filter == "" || strings.Contains(strings.ToLower(line), strings.ToLower(i.filter))
+}
+
+func (i *OutputInspector) updateTitle() {
+	if i.Flex != nil {
+		i.Flex.SetTitle(i.GetTitle())
+	}
+	if i.HeaderView != nil {
+		i.HeaderView.SetText(i.GetStatus())
+	}
+}
+
+// Buildkit steps, e.g. "#5 [2/4] RUN npm ci".
+var buildStepRe = regexp.MustCompile(`^#\d+ `)
+
+// highlightOutputLine colors a line of docker/compose output: commands,
+// errors, warnings, pull and build progress, and finished steps.
+func highlightOutputLine(line string) string {
+	escaped := tview.Escape(line)
+	trimmed := strings.TrimSpace(line)
+	lower := strings.ToLower(trimmed)
+
+	// The state is the last word of compose progress lines,
+	// e.g. " ✔ Container app-web-1  Started".
+	fields := strings.Fields(trimmed)
+	state := ""
+	if len(fields) > 0 {
+		state = fields[len(fields)-1]
+	}
+
+	color := ""
+	switch {
+	case strings.HasPrefix(line, "$ "):
+		return fmt.Sprintf("[%s::b]%s[-::-]", styles.TagAccent, escaped)
+	case strings.Contains(lower, "error") || strings.Contains(lower, "failed") ||
+		strings.HasPrefix(trimmed, "✘") || strings.HasPrefix(lower, "fatal"):
+		color = styles.TagError
+	case strings.HasPrefix(lower, "warn") || strings.Contains(lower, "warning"):
+		color = styles.TagAccent
+	case strings.Contains(trimmed, "Pull complete") || strings.Contains(trimmed, "Download complete") ||
+		strings.HasSuffix(trimmed, " DONE") || strings.HasSuffix(trimmed, " CACHED"):
+		color = styles.TagInfo
+	case strings.HasPrefix(trimmed, "✔"):
+		color = styles.TagInfo
+	case buildStepRe.MatchString(trimmed):
+		color = styles.TagCyan
+	}
+
+	if color == "" {
+		switch state {
+		case "Started", "Created", "Built", "Pulled", "Healthy", "Running", "Recreated", "Removed", "Stopped", "Exited":
+			color = styles.TagInfo
+		case "Waiting", "Creating", "Starting", "Recreate", "Recreating", "Stopping", "Removing":
+			color = styles.TagCyan
+		}
+	}
+	// Layer and build progress end with sizes or timings, not a state
+	if color == "" {
+		for _, word := range []string{"Pulling", "Downloading", "Extracting", "Verifying", "Building", "Exporting"} {
+			if strings.Contains(trimmed, word) {
+				color = styles.TagCyan
+				break
+			}
+		}
+	}
+
+	if color == "" {
+		return escaped
+	}
+	return fmt.Sprintf("[%s]%s[-]", color, escaped)
+}
@@ -1,8 +1,15 @@
 package inspect
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
 	"github.com/gdamore/tcell/v2"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
 	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/dialogs"
 	"github.com/jr-k/d4s/internal/ui/styles"
 	"github.com/rivo/tview"
 )
@@ -16,6 +23,10 @@ type TextInspector struct {
 	Subject string
 	Content string
 	Lang    string
+
+	// SaveName, when set, enables saving the content to a file of that
+	// name (in the current directory by default).
+	SaveName string
 }
 
 // Ensure TextInspector implements common.Inspector
@@ -30,6 +41,14 @@ func NewTextInspector(action, subject string, content string, lang string) *Text
 	}
 }
 
+// NewTextInspectorWithSave is a TextInspector whose content can be saved
+// to a file, fileName being the suggested name.
+func NewTextInspectorWithSave(action, subject, content, lang, fileName string) *TextInspector {
+	i := NewTextInspector(action, subject, content, lang)
+	i.SaveName = fileName
+	return i
+}
+
 func (i *TextInspector) GetID() string {
 	return "inspect"
 }
@@ -44,12 +63,17 @@ func (i *TextInspector) GetTitle() string {
 }
 
 func (i *TextInspector) GetShortcuts() []string {
-	return []string{
+	shortcuts := []string{
 		common.FormatSCHeader("esc", "Close"),
 		common.FormatSCHeader("c", "Copy"),
+	}
+	if i.SaveName != "" {
+		shortcuts = append(shortcuts, common.FormatSCHeader("ctrl-s", "Save"))
+	}
+	return append(shortcuts,
 		common.FormatSCHeader("/", "Search"),
 		common.FormatSCHeader("n/p", "Next/Prev"),
-	}
+	)
 }
 
 func (i *TextInspector) OnMount(app common.AppController) {
@@ -78,6 +102,16 @@ func (i *TextInspector) ApplyFilter(filter string) {
 }
 
 func (i *TextInspector) InputHandler(event *tcell.EventKey) *tcell.EventKey {
+	// Leave the keys to the save dialogs while they are open
+	if page, _ := i.App.GetPages().GetFrontPage(); page != "inspect" {
+		return event
+	}
+
+	if event.Key() == tcell.KeyCtrlS && i.SaveName != "" {
+		i.save()
+		return nil
+	}
+
 	if event.Key() == tcell.KeyEsc {
 		i.App.CloseInspector()
 		return nil
@@ -89,3 +123,23 @@ func (i *TextInspector) InputHandler(event *tcell.EventKey) *tcell.EventKey {
 	
 	return event
 }
+
+// save asks where to write the content and confirms before overwriting.
+func (i *TextInspector) save() {
+	path := i.SaveName
+	if dir, err := os.Getwd(); err == nil {
+		path = filepath.Join(dir, i.SaveName)
+	}
+
+	dialogs.ShowInput(i.App, "Save", "Path:", path, func(text string) {
+		path := daocommon.ExpandHome(text)
+		write := func() {
+			if err := os.WriteFile(path, []byte(i.Content), 0o644); err != nil {
+				i.App.AppendFlashError(fmt.Sprintf("failed to save: %v", err))
+				return
+			}
+			i.App.AppendFlashSuccess(fmt.Sprintf("saved to %s", daocommon.ShortenPath(path)), 10*time.Second)
+		}
+		dialogs.ConfirmOverwrite(i.App, path, write)
+	})
+}
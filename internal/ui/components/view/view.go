@@ -104,7 +104,6 @@ type ResourceView struct {
 	FetchWithHeadersFunc     func(app common.AppController, view *ResourceView) ([]dao.Resource, []string, error)
 	InspectFunc              func(app common.AppController, id string)
 	RemoveFunc               func(id string, force bool, app common.AppController) error
-	PruneFunc                func(app common.AppController) error
 	highlightMu              sync.RWMutex
 	transientHighlights      map[string]highlightEntry
 	pendingHighlightRequests []highlightRequest
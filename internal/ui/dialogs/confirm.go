@@ -2,9 +2,11 @@ package dialogs
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/styles"
 	"github.com/rivo/tview"
@@ -209,3 +211,14 @@ func ShowConfirmation(app common.AppController, actionName, item string, onConfi
 	tviewApp.SetFocus(input)
 	app.UpdateShortcuts()
 }
+
+// ConfirmOverwrite runs onConfirm, after a confirmation when path already exists.
+func ConfirmOverwrite(app common.AppController, path string, onConfirm func()) {
+	if _, err := os.Stat(path); err == nil {
+		ShowConfirmation(app, "OVERWRITE", daocommon.ShortenPath(path), func(_ bool) {
+			onConfirm()
+		})
+		return
+	}
+	onConfirm()
+}
@@ -0,0 +1,249 @@
+package dialogs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/rivo/tview"
+)
+
+// ShowPrunePreview lists what a prune of resource would remove (load) and
+// lets the user deselect items before the usual confirmation. The selected
+// items are then removed one by one, so exactly what was previewed goes.
+func ShowPrunePreview(app common.AppController, resource string, load func() ([]dao.PruneCandidate, error), remove func(id string) error) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	app.SetFlashPending(fmt.Sprintf("listing %s to prune...", resource))
+	app.RunInBackground(func() {
+		items, err := load()
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.SetFlashError(fmt.Sprintf("%v", err))
+				return
+			}
+			if len(items) == 0 {
+				app.SetFlashSuccess(fmt.Sprintf("nothing to prune: no unused %s", resource))
+				return
+			}
+			app.SetFlashText("")
+			showPruneList(app, resource, items, func(selected []dao.PruneCandidate) {
+				confirmPrune(app, resource, selected, remove)
+			})
+		})
+	})
+}
+
+func pruneTotal(items []dao.PruneCandidate) (int64, bool) {
+	var total int64
+	known := false
+	for _, item := range items {
+		if item.Size >= 0 {
+			total += item.Size
+			known = true
+		}
+	}
+	return total, known
+}
+
+func pruneSummary(resource string, items []dao.PruneCandidate) string {
+	total, known := pruneTotal(items)
+	if !known {
+		return fmt.Sprintf("%d %s", len(items), resource)
+	}
+	return fmt.Sprintf("%d %s, reclaiming %s", len(items), resource, daocommon.FormatBytes(total))
+}
+
+func confirmPrune(app common.AppController, resource string, selected []dao.PruneCandidate, remove func(id string) error) {
+	if len(selected) == 0 {
+		app.SetFlashError("nothing selected")
+		return
+	}
+
+	ShowConfirmation(app, "PRUNE", pruneSummary(resource, selected), func(force bool) {
+		app.SetFlashPending(fmt.Sprintf("pruning %s...", resource))
+		app.RunInBackground(func() {
+			var errs []string
+			var removed []dao.PruneCandidate
+			for _, item := range selected {
+				if err := remove(item.ID); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", item.Name, err))
+					continue
+				}
+				removed = append(removed, item)
+			}
+
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if len(errs) > 0 {
+					app.SetFlashError(fmt.Sprintf("%d/%d %s failed to prune", len(errs), len(selected), resource))
+					ShowResultModal(app, "prune", len(removed), errs)
+				} else {
+					app.SetFlashSuccess("pruned " + pruneSummary(resource, removed))
+				}
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+func showPruneList(app common.AppController, resource string, items []dao.PruneCandidate, onConfirm func(selected []dao.PruneCandidate)) {
+	dialogWidth := 110
+	dialogHeight := 10 + len(items)
+	if dialogHeight > 26 {
+		dialogHeight = 26
+	}
+
+	pages := app.GetPages()
+	tviewApp := app.GetTviewApp()
+
+	selections := make([]bool, len(items))
+	for i := range selections {
+		selections[i] = true
+	}
+	currentIndex := 0
+
+	nameWidth := 12
+	for _, item := range items {
+		nameWidth = max(nameWidth, len(item.Name))
+	}
+	nameWidth = min(nameWidth, 48)
+
+	selected := func() []dao.PruneCandidate {
+		var res []dao.PruneCandidate
+		for i, item := range items {
+			if selections[i] {
+				res = append(res, item)
+			}
+		}
+		return res
+	}
+
+	summaryView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter)
+	summaryView.SetBackgroundColor(styles.ColorBlack)
+
+	hintView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextAlign(tview.AlignCenter).
+		SetText(fmt.Sprintf("[%s]space[%s] toggle  [%s]a[%s] all/none  [%s]enter[%s] prune  [%s]esc[%s] cancel",
+			styles.TagAccent, styles.TagDim, styles.TagAccent, styles.TagDim, styles.TagAccent, styles.TagDim, styles.TagAccent, styles.TagDim))
+	hintView.SetBackgroundColor(styles.ColorBlack)
+
+	list := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	list.SetBackgroundColor(styles.ColorBlack)
+
+	updateList := func() {
+		sel := selected()
+		summaryView.SetText(fmt.Sprintf("[%s::b]%d of %d selected[-::-] [%s]— %s", styles.TagPink, len(sel), len(items), styles.TagFg, pruneSummary(resource, sel)))
+
+		var sb strings.Builder
+		for i, item := range items {
+			checkbox := "[ ]"
+			if selections[i] {
+				checkbox = "[✔]"
+			}
+
+			color := fmt.Sprintf("[%s]", styles.TagFg)
+			prefix := "  "
+			if i == currentIndex {
+				color = fmt.Sprintf("[%s]", styles.TagAccent)
+				prefix = "> "
+			}
+
+			name := item.Name
+			if len(name) > nameWidth {
+				name = name[:nameWidth-1] + "…"
+			}
+			size := "-"
+			if item.Size >= 0 {
+				size = daocommon.FormatBytes(item.Size)
+			}
+			fmt.Fprintf(&sb, "%s%s%s %-*s %10s  [%s]%s[-]\n", color, prefix, tview.Escape(checkbox), nameWidth, tview.Escape(name), size, styles.TagDim, tview.Escape(item.Detail))
+		}
+		list.SetText(sb.String())
+		list.ScrollTo(max(currentIndex-(dialogHeight-10)/2, 0), 0)
+	}
+	updateList()
+
+	content := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tview.NewBox().SetBackgroundColor(styles.ColorBlack), 1, 0, false).
+		AddItem(summaryView, 1, 0, false).
+		AddItem(tview.NewBox().SetBackgroundColor(styles.ColorBlack), 1, 0, false).
+		AddItem(list, 0, 1, true).
+		AddItem(hintView, 1, 0, false).
+		AddItem(tview.NewBox().SetBackgroundColor(styles.ColorBlack), 1, 0, false)
+
+	content.SetBorder(true).
+		SetTitle(fmt.Sprintf("[%s::b]<Prune %s: preview>[-::-]", styles.TagCyan, resource)).
+		SetTitleColor(styles.ColorTitle).
+		SetBorderColor(styles.ColorMenuKey).
+		SetBackgroundColor(styles.ColorBlack)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(content, dialogHeight, 1, true).
+			AddItem(nil, 0, 1, false), dialogWidth, 1, true).
+		AddItem(nil, 0, 1, false)
+
+	closeModal := func() {
+		pages.RemovePage("prune_preview")
+		tviewApp.SetFocus(pages)
+		app.UpdateShortcuts()
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			closeModal()
+			return nil
+		case tcell.KeyEnter:
+			closeModal()
+			onConfirm(selected())
+			return nil
+		case tcell.KeyUp:
+			if currentIndex > 0 {
+				currentIndex--
+				updateList()
+			}
+			return nil
+		case tcell.KeyDown:
+			if currentIndex < len(items)-1 {
+				currentIndex++
+				updateList()
+			}
+			return nil
+		}
+
+		switch event.Rune() {
+		case ' ':
+			selections[currentIndex] = !selections[currentIndex]
+			updateList()
+			return nil
+		case 'a':
+			all := len(selected()) < len(items)
+			for i := range selections {
+				selections[i] = all
+			}
+			updateList()
+			return nil
+		}
+		return event
+	})
+
+	pages.AddPage("prune_preview", modal, true, true)
+	tviewApp.SetFocus(list)
+	app.UpdateShortcuts()
+}
@@ -141,6 +141,11 @@ const (
 	TitleContexts     = "Contexts"
 	TitlePlugins      = "Plugins"
 	TitlePortForwards = "PortForwards"
+	TitleWatches      = "Watches"
+	TitleCaptures     = "Captures"
+	TitleJobs         = "Jobs"
+	TitleRegistry     = "Registry"
+	TitleDiskUsage    = "DiskUsage"
 )
 
 // invertColor inverts a tcell.Color by flipping its lightness while preserving hue and saturation.
@@ -73,6 +73,11 @@ func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, erro
 		{Title: styles.TitlePlugins, Resource: "plugins", Group: "docker", Shortcuts: []string{"g", "pl", "plugin", "plugins"}},
 		{Title: styles.TitleCompose, Resource: "compose", Group: "compose", Shortcuts: []string{"p", "cp", "compose", "project", "projects"}},
 		{Title: styles.TitlePortForwards, Resource: "portforwards", Group: "internal", Shortcuts: []string{"w", "pf", "portforward", "portforwards"}},
+		{Title: styles.TitleJobs, Resource: "jobs", Group: "compose", Shortcuts: []string{"j", "job", "jobs"}},
+		{Title: styles.TitleWatches, Resource: "watches", Group: "compose", Shortcuts: []string{"cw", "watch", "watches"}},
+		{Title: styles.TitleCaptures, Resource: "captures", Group: "internal", Shortcuts: []string{"cap", "capture", "captures"}},
+		{Title: styles.TitleRegistry, Resource: "registry", Group: "docker", Shortcuts: []string{"reg", "registry", "registries"}},
+		{Title: styles.TitleDiskUsage, Resource: "df", Group: "docker", Shortcuts: []string{"df", "du", "diskusage"}},
 	}
 
 	var resources []dao.Resource
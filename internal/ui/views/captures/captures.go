@@ -0,0 +1,150 @@
+package captures
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/capture"
+	"github.com/jr-k/d4s/internal/config"
+	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/components/inspect"
+	"github.com/jr-k/d4s/internal/ui/components/view"
+	"github.com/jr-k/d4s/internal/ui/dialogs"
+)
+
+var Headers = []string{"STATUS", "CONTEXT", "CONTAINER", "FILTER", "LIMIT", "STATE", "PACKETS", "SIZE", "FILE", "AGE"}
+
+func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
+	return app.GetCaptureManager().List(), nil
+}
+
+func GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("enter", "Details"),
+		common.FormatSCHeader("ctrl-k", "Stop"),
+		common.FormatSCHeader("ctrl-d", "Delete"),
+	}
+}
+
+func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+
+	switch event.Key() {
+	case tcell.KeyCtrlD:
+		DeleteAction(app, v)
+		return nil
+	case tcell.KeyCtrlK:
+		StopAction(app, v)
+		return nil
+	case tcell.KeyEnter:
+		ShowDetails(app, v)
+		return nil
+	}
+
+	return event
+}
+
+func selected(v *view.ResourceView) (capture.Session, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return capture.Session{}, false
+	}
+	s, ok := v.Data[row-1].(capture.Session)
+	return s, ok
+}
+
+// ShowDetails shows where a capture is written and what tcpdump reported.
+func ShowDetails(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "File:      %s\n", s.Path)
+	fmt.Fprintf(&sb, "Context:   %s\n", s.ContextName)
+	fmt.Fprintf(&sb, "Container: %s\n", s.Container)
+	fmt.Fprintf(&sb, "Filter:    %s\n", daocommon.Dash(s.Filter))
+	fmt.Fprintf(&sb, "Limit:     %s\n", daocommon.Dash(s.Limit))
+	fmt.Fprintf(&sb, "State:     %s\n", s.StatusText())
+	fmt.Fprintf(&sb, "Packets:   %d\n", s.Packets())
+	fmt.Fprintf(&sb, "Size:      %s\n", daocommon.FormatBytes(s.Size()))
+	fmt.Fprintf(&sb, "Started:   %s\n", s.CreatedAt.Format("2006-01-02 15:04:05"))
+	if output := s.Output(); len(output) > 0 {
+		sb.WriteString("\ntcpdump:\n")
+		for _, line := range output {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+
+	app.OpenInspector(inspect.NewTextInspector("Capture", s.Container, sb.String(), "text"))
+}
+
+func StopAction(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+	if s.Status() != capture.StatusRunning {
+		app.AppendFlashError(fmt.Sprintf("capture of %s is not running", s.Container))
+		return
+	}
+
+	app.GetCaptureManager().Stop(s.ID)
+	app.AppendFlashSuccess(fmt.Sprintf("stopped capture of %s", s.Container))
+	app.RefreshCurrentView()
+}
+
+// DeleteAction stops the selected capture and deletes its file.
+func DeleteAction(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+
+	dialogs.ShowConfirmation(app, "DELETE", fmt.Sprintf("[yellow]%s", s.Path), func(force bool) {
+		app.SetFlashPending(fmt.Sprintf("deleting capture of %s...", s.Container))
+		app.RunInBackground(func() {
+			err := app.GetCaptureManager().Remove(s.ID)
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to delete capture: %v", err))
+				} else {
+					app.AppendFlashSuccess(fmt.Sprintf("deleted capture of %s", s.Container))
+				}
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+// Start captures the packets of a container into a pcap file of the
+// captures directory, in the background. With a zero duration and count,
+// the capture runs until it is stopped.
+func Start(app common.AppController, id, name, filter string, duration time.Duration, count int) {
+	var limits []string
+	if duration > 0 {
+		limits = append(limits, duration.String())
+	}
+	if count > 0 {
+		limits = append(limits, fmt.Sprintf("%d packets", count))
+	}
+
+	docker := app.GetDocker()
+	image := app.GetConfig().D4S.GetDebugImage()
+	session, err := app.GetCaptureManager().Start(docker.ContextName, name, filter, strings.Join(limits, ", "), config.CapturesDir(), duration,
+		func(ctx context.Context, w io.Writer, status func(string)) error {
+			return docker.ContainerCapture(ctx, id, image, filter, count, w, status)
+		})
+	if err != nil {
+		app.AppendFlashError(fmt.Sprintf("failed to capture: %v", err))
+		return
+	}
+	app.AppendFlashSuccess(fmt.Sprintf("capturing %s to %s", name, session.Path))
+}
@@ -4,30 +4,90 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao"
 	daoCommon "github.com/jr-k/d4s/internal/dao/common"
 	daoCompose "github.com/jr-k/d4s/internal/dao/compose"
-	"github.com/jr-k/d4s/internal/sshutil"
 	"github.com/jr-k/d4s/internal/portforward"
+	"github.com/jr-k/d4s/internal/sshutil"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/inspect"
 	"github.com/jr-k/d4s/internal/ui/components/view"
 	"github.com/jr-k/d4s/internal/ui/dialogs"
 	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/jr-k/d4s/internal/ui/views/watches"
+	"github.com/jr-k/d4s/internal/watch"
+	"github.com/rivo/tview"
 )
 
-var Headers = []string{"PROJECT", "READY", "STATUS", "CONFIG FILES"}
+var Headers = []string{"PROJECT", "READY", "STATUS", "DRIFT", "WATCH", "CONFIG FILES"}
+var ServiceHeaders = []string{"SERVICE", "READY", "DRIFT", "IMAGE", "PORTS", "BUILD", "PROFILES"}
+var AllHeaders = []string{"PROJECT", "SERVICE", "READY", "STATUS", "DRIFT", "WATCH", "IMAGE", "PORTS", "BUILD", "PROFILES", "CONFIG FILES"}
+
+// projectWithWatch adds the state of the project's compose watch, if any.
+type projectWithWatch struct {
+	daoCompose.ComposeProject
+	watch string
+}
+
+func (p projectWithWatch) GetCells() []string {
+	cells := p.ComposeProject.GetCells()
+	// Insert WATCH before CONFIG FILES
+	result := make([]string, 0, len(cells)+1)
+	result = append(result, cells[:4]...)
+	result = append(result, p.watch)
+	return append(result, cells[4:]...)
+}
+
+func (p projectWithWatch) GetColumnValue(column string) string {
+	if strings.EqualFold(column, "watch") {
+		return p.watch
+	}
+	return p.ComposeProject.GetColumnValue(column)
+}
+
+// asProject unwraps a row of the projects list.
+func asProject(res dao.Resource) (daoCompose.ComposeProject, bool) {
+	if p, ok := res.(projectWithWatch); ok {
+		return p.ComposeProject, true
+	}
+	cp, ok := res.(daoCompose.ComposeProject)
+	return cp, ok
+}
+
+func wrapWithWatch(data []dao.Resource, app common.AppController) []dao.Resource {
+	mgr := app.GetWatchManager()
+	contextName := app.GetDocker().ContextName
+	for i, res := range data {
+		if cp, ok := res.(daoCompose.ComposeProject); ok {
+			state := ""
+			if s := mgr.Get(watch.SessionID(contextName, cp.Name)); s != nil {
+				state = s.StatusText()
+			}
+			data[i] = projectWithWatch{ComposeProject: cp, watch: state}
+		}
+	}
+	return data
+}
+
+func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, []string, error) {
+	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == "compose-services" {
+		data, err := app.GetDocker().ListComposeServices(scope.Value)
+		return data, ServiceHeaders, err
+	}
 
-func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
 	data, err := app.GetDocker().ListCompose()
 	if err != nil {
-		return nil, err
+		return nil, Headers, err
 	}
 
-	scope := app.GetActiveScope()
 	if scope != nil {
 		if scope.Type == "container" {
 			var scopedData []dao.Resource
@@ -38,14 +98,28 @@ func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, erro
 					}
 				}
 			}
-			return scopedData, nil
+			return wrapWithWatch(scopedData, app), Headers, nil
 		}
 	}
 
-	return data, nil
+	return wrapWithWatch(data, app), Headers, nil
+}
+
+// servicesScope returns the project whose services are listed, if any.
+func servicesScope(app common.AppController) (string, bool) {
+	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == "compose-services" {
+		return scope.Value, true
+	}
+	return "", false
 }
 
 func Inspect(app common.AppController, id string) {
+	if project, ok := servicesScope(app); ok {
+		InspectService(app, project, id)
+		return
+	}
+
 	inspector := inspect.NewTextInspector("Describe compose", id, fmt.Sprintf(" [%s]Loading compose...\n", styles.TagAccent), "yaml")
 	app.OpenInspector(inspector)
 
@@ -78,16 +152,197 @@ func Inspect(app common.AppController, id string) {
 	})
 }
 
-func GetShortcuts() []string {
+// InspectService shows the resolved definition of a single service.
+func InspectService(app common.AppController, project, service string) {
+	inspector := inspect.NewTextInspector("Describe service", fmt.Sprintf("%s@%s", service, project), fmt.Sprintf(" [%s]Loading service...\n", styles.TagAccent), "yaml")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().DescribeComposeService(project, service)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(content, "yaml")
+		})
+	})
+}
+
+// DriftDiff shows how the containers of a project (or of one service)
+// differ from the compose files on disk.
+func DriftDiff(app common.AppController, project, service string) {
+	subject := project
+	if service != "" {
+		subject = fmt.Sprintf("%s@%s", service, project)
+	}
+	inspector := inspect.NewTextInspector("Drift", subject, fmt.Sprintf(" [%s]Resolving compose files...\n", styles.TagAccent), "diff")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().ComposeDriftReport(project, service)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(content, "diff")
+		})
+	})
+}
+
+// Topology shows the services of a project as a graph: startup waves
+// with their depends_on edges, then the networks and named volumes they
+// share. Nodes are colored by the state of their containers.
+func Topology(app common.AppController, project string) {
+	inspector := inspect.NewTextInspector("Topology", project, fmt.Sprintf(" [%s]Resolving compose files...\n", styles.TagAccent), "text")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		topology, err := app.GetDocker().ComposeTopology(project)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(renderTopology(topology), "text")
+		})
+	})
+}
+
+func renderTopology(t *dao.ComposeTopology) string {
+	var sb strings.Builder
+	waves := 0
+	for _, s := range t.Services {
+		waves = max(waves, s.Wave+1)
+	}
+	fmt.Fprintf(&sb, "\n [%s::b]%s[-::-] [%s]%d services in %d startup waves · %d networks · %d named volumes[-]\n",
+		styles.TagPink, tview.Escape(t.Project), styles.TagDim, len(t.Services), waves, len(t.Networks), len(t.Volumes))
+
+	fmt.Fprintf(&sb, "\n [%s::b]STARTUP ORDER[-::-] [%s](depends_on)[-]\n", styles.TagCyan, styles.TagDim)
+	wave := -2
+	for _, s := range t.Services {
+		if s.Wave != wave {
+			wave = s.Wave
+			if wave < 0 {
+				fmt.Fprintf(&sb, "\n [%s]cycle[-]\n", styles.TagError)
+			} else {
+				fmt.Fprintf(&sb, "\n [%s]wave %d[-]\n", styles.TagDim, wave)
+			}
+		}
+
+		state := s.State
+		if s.State == daoCompose.StateExited {
+			state = fmt.Sprintf("exited (%d)", s.ExitCode)
+		}
+		fmt.Fprintf(&sb, "   [%s]●[-] [%s::b]%s[-::-]  [%s]%s %d/%d[-]\n",
+			topologyStateColor(s), styles.TagFg, tview.Escape(s.Name), styles.TagDim, state, s.Running, s.Desired)
+
+		if s.NetworkMode != "" {
+			fmt.Fprintf(&sb, "      [%s]⇢ network_mode %s[-]\n", styles.TagDim, tview.Escape(s.NetworkMode))
+		}
+		for i, d := range s.DependsOn {
+			branch := "├─▶"
+			if i == len(s.DependsOn)-1 {
+				branch = "└─▶"
+			}
+			condition := d.Condition
+			if !d.Required {
+				condition += ", optional"
+			}
+			fmt.Fprintf(&sb, "      [%s]%s[-] %s [%s]%s[-]", styles.TagDim, branch, tview.Escape(d.Service), styles.TagDim, condition)
+			if d.Problem != "" {
+				fmt.Fprintf(&sb, "  [%s]✘ %s[-]", styles.TagError, d.Problem)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	writeLinks := func(title, symbol string, links []dao.ComposeTopologyLink) {
+		if len(links) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n [%s::b]%s[-::-]\n\n", styles.TagCyan, title)
+		for _, l := range links {
+			name := tview.Escape(l.Name)
+			color := styles.TagDim
+			if l.Shared() {
+				color = styles.TagFg
+			}
+			if l.External {
+				name += " (external)"
+			}
+			hub := fmt.Sprintf("   %s %s ", symbol, name)
+			pad := strings.Repeat(" ", len([]rune(hub))+1)
+			for i, svc := range l.Services {
+				switch {
+				case len(l.Services) == 1:
+					fmt.Fprintf(&sb, "[%s]%s───[-] %s\n", color, hub, tview.Escape(svc))
+				case i == 0:
+					fmt.Fprintf(&sb, "[%s]%s─┬─[-] %s\n", color, hub, tview.Escape(svc))
+				case i == len(l.Services)-1:
+					fmt.Fprintf(&sb, "[%s]%s└─[-] %s\n", color, pad, tview.Escape(svc))
+				default:
+					fmt.Fprintf(&sb, "[%s]%s├─[-] %s\n", color, pad, tview.Escape(svc))
+				}
+			}
+		}
+	}
+	writeLinks("NETWORKS", "◆", t.Networks)
+	writeLinks("NAMED VOLUMES", "▣", t.Volumes)
+
+	return sb.String()
+}
+
+func topologyStateColor(s daoCompose.TopologyService) string {
+	switch s.State {
+	case daoCompose.StateRunning, daoCompose.StateHealthy:
+		return styles.TagInfo
+	case daoCompose.StateStarting, daoCompose.StateCreated, daoCompose.StatePaused:
+		return styles.TagAccent
+	case daoCompose.StateRestarting, daoCompose.StateUnhealthy:
+		return styles.TagError
+	case daoCompose.StateExited:
+		if s.ExitCode != 0 {
+			return styles.TagError
+		}
+	}
+	return styles.TagDim
+}
+
+func GetShortcuts(v *view.ResourceView) []string {
+	if _, ok := servicesScope(v.App); ok {
+		return []string{
+			common.FormatSCHeader("enter", "Containers"),
+			common.FormatSCHeader("d", "Describe"),
+			common.FormatSCHeader("s", "Scale"),
+			common.FormatSCHeader("r", "Restart"),
+			common.FormatSCHeader("p", "Pull"),
+			common.FormatSCHeader("b", "Build"),
+			common.FormatSCHeader("x", "Drift Diff"),
+			common.FormatSCHeader("t", "Topology"),
+			common.FormatSCHeader("shift-s", "Start"),
+			common.FormatSCHeader("shift-r", "Recreate"),
+			common.FormatSCHeader("ctrl-k", "Stop"),
+		}
+	}
+
 	return []string{
 		common.FormatSCHeader("enter", "Containers"),
+		common.FormatSCHeader("s", "Services"),
 		common.FormatSCHeader("l", "Logs"),
 		common.FormatSCHeader("f", "Show PortForward"),
 		common.FormatSCHeader("d", "Describe"),
 		common.FormatSCHeader("e", "Edit"),
 		common.FormatSCHeader("r", "(Re)Start"),
 		common.FormatSCHeader("b", "Build"),
+		common.FormatSCHeader("x", "Drift Diff"),
+		common.FormatSCHeader("o", "Last Output"),
+		common.FormatSCHeader("t", "Topology"),
 		common.FormatSCHeader("shift-f", "Port-Forward"),
+		common.FormatSCHeader("w", "Watch"),
+		common.FormatSCHeader("shift-w", "Show Watches"),
+		common.FormatSCHeader("shift-j", "Jobs"),
 		common.FormatSCHeader("shift-r", "(Re)Deploy"),
 		common.FormatSCHeader("ctrl-d", "Delete"),
 		common.FormatSCHeader("ctrl-k", "Stop"),
@@ -96,6 +351,10 @@ func GetShortcuts() []string {
 
 func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	app := v.App
+	if project, ok := servicesScope(app); ok {
+		return serviceInputHandler(v, project, event)
+	}
+
 	if event.Key() == tcell.KeyCtrlK {
 		StopAction(app, v)
 		return nil
@@ -105,6 +364,9 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 		return nil
 	}
 	switch event.Rune() {
+	case 's':
+		NavigateToServices(app, v)
+		return nil
 	case 'l':
 		Logs(app, v)
 		return nil
@@ -129,6 +391,28 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	case 'b':
 		BuildAction(app, v)
 		return nil
+	case 'x':
+		if id, err := v.GetSelectedID(); err == nil {
+			DriftDiff(app, id, "")
+		}
+		return nil
+	case 'o':
+		LastOutput(app, v)
+		return nil
+	case 't':
+		if id, err := v.GetSelectedID(); err == nil {
+			Topology(app, id)
+		}
+		return nil
+	case 'w':
+		WatchAction(app, v)
+		return nil
+	case 'W':
+		ShowWatches(app, v)
+		return nil
+	case 'J':
+		ShowJobs(app, v)
+		return nil
 	}
 	
 	if event.Key() == tcell.KeyEnter {
@@ -156,7 +440,7 @@ func NavigateToContainers(app common.AppController, v *view.ResourceView) {
 		
 		// Try to get config file path
 		label := projName
-		if cp, ok := res.(daoCompose.ComposeProject); ok {
+		if cp, ok := asProject(res); ok {
 			if cp.ConfigFiles != "" {
 				label = cp.ConfigFiles
 			}
@@ -175,6 +459,139 @@ func NavigateToContainers(app common.AppController, v *view.ResourceView) {
 	}
 }
 
+func serviceInputHandler(v *view.ResourceView, project string, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+	switch event.Key() {
+	case tcell.KeyEnter:
+		NavigateToServiceContainers(app, v, project)
+		return nil
+	case tcell.KeyCtrlK:
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().StopComposeService(project, id)
+		}, "stopping", styles.ColorStatusRed)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'd':
+		app.InspectCurrentSelection()
+		return nil
+	case 's':
+		ScaleServiceAction(app, v, project)
+		return nil
+	case 'S':
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().StartComposeService(project, id)
+		}, "starting", styles.ColorStatusOrange)
+		return nil
+	case 'r':
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().RestartComposeService(project, id)
+		}, "restarting", styles.ColorStatusOrange)
+		return nil
+	case 'R':
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().RecreateComposeService(project, id)
+		}, "recreating", styles.ColorStatusMagenta)
+		return nil
+	case 'p':
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().PullComposeService(project, id)
+		}, "pulling", styles.ColorStatusBlue)
+		return nil
+	case 'b':
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().BuildComposeService(project, id)
+		}, "building", styles.ColorStatusMagenta)
+		return nil
+	case 'x':
+		if id, err := v.GetSelectedID(); err == nil {
+			DriftDiff(app, project, id)
+		}
+		return nil
+	case 't':
+		Topology(app, project)
+		return nil
+	}
+	return event
+}
+
+// NavigateToServices drills down into the services of the selected project.
+func NavigateToServices(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "compose-services",
+		Value:      id,
+		Label:      id,
+		OriginView: styles.TitleCompose,
+		Parent:     app.GetActiveScope(),
+	})
+	app.SwitchTo(styles.TitleCompose)
+}
+
+func NavigateToServiceContainers(app common.AppController, v *view.ResourceView, project string) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "compose-service",
+		Value:      project + "/" + id,
+		Label:      fmt.Sprintf("%s/%s", project, id),
+		OriginView: styles.TitleCompose,
+		Parent:     app.GetActiveScope(),
+	})
+	app.SwitchTo(styles.TitleContainers)
+}
+
+func ScaleServiceAction(app common.AppController, v *view.ResourceView, project string) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	row, _ := v.Table.GetSelection()
+	if row < 1 || row > len(v.Data) {
+		return
+	}
+	svc, ok := v.Data[row-1].(dao.ComposeService)
+	if !ok {
+		return
+	}
+
+	current := ""
+	if svc.Desired >= 0 {
+		current = strconv.Itoa(svc.Desired)
+	}
+
+	dialogs.ShowInput(app, "Scale Service", "Replicas:", current, func(text string) {
+		replicas, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil || replicas < 0 {
+			app.SetFlashError("invalid number")
+			return
+		}
+
+		app.SetFlashPending(fmt.Sprintf("scaling %s to %d...", svc.Name, replicas))
+
+		app.RunInBackground(func() {
+			err := app.GetDocker().ScaleComposeService(project, svc.Name, replicas)
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.SetFlashError(fmt.Sprintf("%v", err))
+				} else {
+					app.SetFlashSuccess(fmt.Sprintf("service scaled to %d", replicas))
+					app.RefreshCurrentView()
+				}
+			})
+		})
+	})
+}
+
 func StopAction(app common.AppController, v *view.ResourceView) {
 	app.PerformAction(func(id string) error {
 		return app.GetDocker().StopComposeProject(id)
@@ -185,7 +602,7 @@ func EditAction(app common.AppController, v *view.ResourceView) {
 	row, _ := v.Table.GetSelection()
 	if row > 0 && row <= len(v.Data) {
 		res := v.Data[row-1]
-		if cp, ok := res.(daoCompose.ComposeProject); ok {
+		if cp, ok := asProject(res); ok {
 			if len(cp.ConfigPaths) == 0 {
 				app.SetFlashError("no config file for this project")
 				return
@@ -245,26 +662,184 @@ func EditAction(app common.AppController, v *view.ResourceView) {
 		if app.GetScreen() != nil {
 			app.GetScreen().Sync()
 		}
+
+		// Drift and services must reflect the edited file
+		app.GetDocker().InvalidateComposeModel(cp.Name)
 		}
 	}
 }
 
 func UpAction(app common.AppController, v *view.ResourceView) {
-	app.PerformAction(func(id string) error {
-		return app.GetDocker().UpComposeProject(id)
-	}, "restarting", styles.ColorStatusOrange)
+	chooseUpOptions(app, v, "Up", func(opts dao.ComposeUpOptions) {
+		runAction(app, "Up", "restarting", styles.ColorStatusOrange, func(id string) (*dao.CmdRun, error) {
+			return app.GetDocker().UpComposeProject(id, opts)
+		})
+	})
 }
 
 func RedeployAction(app common.AppController, v *view.ResourceView) {
-	app.PerformAction(func(id string) error {
-		return app.GetDocker().RedeployComposeProject(id)
-	}, "redeploying", styles.ColorStatusMagenta)
+	chooseUpOptions(app, v, "Redeploy", func(opts dao.ComposeUpOptions) {
+		runAction(app, "Redeploy", "redeploying", styles.ColorStatusMagenta, func(id string) (*dao.CmdRun, error) {
+			return app.GetDocker().RedeployComposeProject(id, opts)
+		})
+	})
 }
 
 func BuildAction(app common.AppController, v *view.ResourceView) {
+	chooseUpOptions(app, v, "Build", func(opts dao.ComposeUpOptions) {
+		runAction(app, "Build", "building", styles.ColorStatusMagenta, func(id string) (*dao.CmdRun, error) {
+			return app.GetDocker().BuildComposeProject(id, opts)
+		})
+	})
+}
+
+// runAction starts a compose run for each selected project, one after the
+// other, and follows the output of the first one in the output inspector.
+func runAction(app common.AppController, action, actionName string, color tcell.Color, start func(id string) (*dao.CmdRun, error)) {
+	var opened sync.Once
 	app.PerformAction(func(id string) error {
-		return app.GetDocker().BuildComposeProject(id)
-	}, "building", styles.ColorStatusMagenta)
+		run, err := start(id)
+		if err != nil {
+			return err
+		}
+		opened.Do(func() {
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				app.OpenInspector(inspect.NewOutputInspector(action, id, run))
+			})
+		})
+		return run.Wait()
+	}, actionName, color)
+}
+
+// LastOutput reopens the output of the last up/build/down of the selected
+// project, whether it is still running or not.
+func LastOutput(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+	run := app.GetDocker().ComposeLastRun(id)
+	if run == nil {
+		app.AppendFlashError(fmt.Sprintf("nothing was run for %s since d4s started", id))
+		return
+	}
+	action, _, _ := strings.Cut(run.Title, " ")
+	app.OpenInspector(inspect.NewOutputInspector(action, id, run))
+}
+
+// chooseUpOptions asks which profiles, env files, overrides and flags to
+// bring a project up with: one of the recent combinations, or a new one
+// from the options form. Several selected projects use their defaults.
+func chooseUpOptions(app common.AppController, v *view.ResourceView, action string, onChosen func(opts dao.ComposeUpOptions)) {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+	if len(ids) > 1 {
+		onChosen(dao.ComposeUpOptions{})
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	project := ids[0]
+	title := fmt.Sprintf("%s: %s", action, project)
+	dialogs.ShowPickerLoading(app, title)
+
+	app.RunInBackground(func() {
+		choices, err := app.GetDocker().ComposeUpChoices(project)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.GetPages().RemovePage("picker")
+				app.AppendFlashError(fmt.Sprintf("%v", err))
+				return
+			}
+
+			if len(choices.Recent) == 0 {
+				app.GetPages().RemovePage("picker")
+				showUpOptionsForm(app, action, project, choices, dao.ComposeUpOptions{}, onChosen)
+				return
+			}
+
+			var items []dialogs.PickerItem
+			for i, opts := range choices.Recent {
+				items = append(items, dialogs.PickerItem{
+					Label:       opts.String(),
+					Description: "recent",
+					Value:       strconv.Itoa(i),
+				})
+			}
+			items = append(items,
+				dialogs.PickerItem{Label: "Defaults", Description: "project files only", Value: "defaults"},
+				dialogs.PickerItem{Label: "Other options...", Description: "profiles, env files, overrides, flags", Value: "custom"},
+			)
+
+			dialogs.ShowPicker(app, title, items, func(value string) {
+				switch value {
+				case "defaults":
+					onChosen(dao.ComposeUpOptions{})
+				case "custom":
+					showUpOptionsForm(app, action, project, choices, choices.Recent[0], onChosen)
+				default:
+					i, _ := strconv.Atoi(value)
+					onChosen(choices.Recent[i])
+				}
+			})
+		})
+	})
+}
+
+func showUpOptionsForm(app common.AppController, action, project string, choices *dao.ComposeUpChoices, initial dao.ComposeUpOptions, onChosen func(opts dao.ComposeUpOptions)) {
+	checked := func(values []string, v string) string {
+		return strconv.FormatBool(slices.Contains(values, v))
+	}
+
+	var fields []dialogs.FormField
+	for _, p := range choices.Profiles {
+		fields = append(fields, dialogs.FormField{Name: "profile:" + p, Label: "Profile " + p, Type: dialogs.FieldTypeCheckbox, Default: checked(initial.Profiles, p)})
+	}
+	for _, f := range choices.EnvFiles {
+		fields = append(fields, dialogs.FormField{Name: "env:" + f, Label: "Env file " + filepath.Base(f), Type: dialogs.FieldTypeCheckbox, Default: checked(initial.EnvFiles, f)})
+	}
+	for _, f := range choices.Overrides {
+		fields = append(fields, dialogs.FormField{Name: "override:" + f, Label: "Add " + filepath.Base(f), Type: dialogs.FieldTypeCheckbox, Default: checked(initial.Overrides, f)})
+	}
+	fields = append(fields,
+		dialogs.FormField{Name: "pull", Label: "Pull policy", Type: dialogs.FieldTypeInput, Default: initial.Pull, Placeholder: strings.Join(daoCompose.PullPolicies, ", ")},
+		dialogs.FormField{Name: "removeOrphans", Label: "Remove orphans", Type: dialogs.FieldTypeCheckbox, Default: strconv.FormatBool(initial.RemoveOrphans)},
+		dialogs.FormField{Name: "noDeps", Label: "No deps", Type: dialogs.FieldTypeCheckbox, Default: strconv.FormatBool(initial.NoDeps)},
+	)
+
+	description := fmt.Sprintf("docker compose up options for %s", project)
+	dialogs.ShowFormWithDescription(app, action+" Options", description, fields, func(result dialogs.FormResult) {
+		var opts dao.ComposeUpOptions
+		for _, p := range choices.Profiles {
+			if result["profile:"+p] == "true" {
+				opts.Profiles = append(opts.Profiles, p)
+			}
+		}
+		for _, f := range choices.EnvFiles {
+			if result["env:"+f] == "true" {
+				opts.EnvFiles = append(opts.EnvFiles, f)
+			}
+		}
+		for _, f := range choices.Overrides {
+			if result["override:"+f] == "true" {
+				opts.Overrides = append(opts.Overrides, f)
+			}
+		}
+		opts.Pull = strings.TrimSpace(result["pull"])
+		if opts.Pull != "" && !slices.Contains(daoCompose.PullPolicies, opts.Pull) {
+			app.SetFlashError(fmt.Sprintf("invalid pull policy %q", opts.Pull))
+			return
+		}
+		opts.RemoveOrphans = result["removeOrphans"] == "true"
+		opts.NoDeps = result["noDeps"] == "true"
+
+		onChosen(opts)
+	})
 }
 
 func DeleteAction(app common.AppController, v *view.ResourceView) {
@@ -294,18 +869,10 @@ func DeleteAction(app common.AppController, v *view.ResourceView) {
 			}
 		}()
 
-		// Define the multi-item deletion task
-		batchDeleteAction := func(_ string) error {
-			for _, id := range ids {
-				if err := Remove(id, force, app); err != nil {
-					return err // Halts and displays error if one fails
-				}
-			}
-			return nil
-		}
-
-		// Perform the action with the consistent styling
-		app.PerformAction(batchDeleteAction, "deleting", styles.ColorStatusRed)
+		// Take the projects down one after the other, following the first
+		runAction(app, "Down", "deleting", styles.ColorStatusRed, func(id string) (*dao.CmdRun, error) {
+			return Remove(id, force, app)
+		})
 	})
 }
 
@@ -313,10 +880,60 @@ func Stop(app common.AppController, id string) error {
 	return app.GetDocker().StopComposeProject(id)
 }
 
-func Remove(id string, force bool, app common.AppController) error {
+func Remove(id string, force bool, app common.AppController) (*dao.CmdRun, error) {
 	return app.GetDocker().DownComposeProject(id)
 }
 
+// WatchAction starts `docker compose watch` for the selected project, or
+// opens the output of its watch when it already runs.
+func WatchAction(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	s := app.GetWatchManager().Get(watch.SessionID(app.GetDocker().ContextName, id))
+	if s != nil && s.Status() == watch.StatusRunning {
+		app.OpenInspector(inspect.NewOutputInspector("Watch", id, s.Run()))
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+	watches.Start(app, id)
+}
+
+func ShowWatches(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "compose",
+		Value:      id,
+		Label:      id,
+		OriginView: styles.TitleCompose,
+	})
+	app.SwitchTo(styles.TitleWatches)
+}
+
+func ShowJobs(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "compose",
+		Value:      id,
+		Label:      id,
+		OriginView: styles.TitleCompose,
+	})
+	app.SwitchTo(styles.TitleJobs)
+}
+
 func ShowPortForwards(app common.AppController, v *view.ResourceView) {
 	if !app.GetDocker().IsSSHContext() {
 		app.AppendFlashError("port-forward is only available on SSH contexts")
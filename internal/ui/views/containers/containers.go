@@ -2,28 +2,36 @@ package containers
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao"
+	daoCompose "github.com/jr-k/d4s/internal/dao/compose"
 	"github.com/jr-k/d4s/internal/portforward"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/inspect"
 	"github.com/jr-k/d4s/internal/ui/components/view"
 	"github.com/jr-k/d4s/internal/ui/dialogs"
 	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/jr-k/d4s/internal/ui/views/captures"
+	"github.com/jr-k/d4s/internal/ui/views/compose"
 )
 
-var Headers = []string{"ID", "NAME", "IMAGE", "STATUS", "HEALTH", "CPU", "MEM", "AGE", "PF", "IP", "PORTS", "COMPOSE", "CMD", "CREATED"}
+var Headers = []string{"ID", "NAME", "IMAGE", "STATUS", "HEALTH", "CPU", "MEM", "AGE", "PF", "IP", "PORTS", "COMPOSE", "CMD", "CREATED", "UPDATE", "DRIFT"}
 
 type containerWithPF struct {
 	dao.Container
-	pf string
+	pf     string
+	update string
+	drift  string
 }
 
 func (c containerWithPF) GetCells() []string {
@@ -33,12 +41,17 @@ func (c containerWithPF) GetCells() []string {
 	result = append(result, cells[:8]...)
 	result = append(result, c.pf)
 	result = append(result, cells[8:]...)
-	return result
+	return append(result, c.update, c.drift)
 }
 
 func (c containerWithPF) GetColumnValue(column string) string {
-	if strings.ToLower(column) == "pf" {
+	switch strings.ToLower(column) {
+	case "pf":
 		return c.pf
+	case "update":
+		return c.update
+	case "drift":
+		return c.drift
 	}
 	return c.Container.GetColumnValue(column)
 }
@@ -85,6 +98,17 @@ func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, erro
 				}
 			}
 			return wrapWithPF(scopedData, app), nil
+		} else if scope.Type == "compose-service" {
+			project, service, _ := strings.Cut(scope.Value, "/")
+			var scopedData []dao.Resource
+			for _, res := range data {
+				if c, ok := res.(dao.Container); ok {
+					if c.ProjectName == project && c.ComposeService == service {
+						scopedData = append(scopedData, res)
+					}
+				}
+			}
+			return wrapWithPF(scopedData, app), nil
 		} else if scope.Type == "service" {
 			var scopedData []dao.Resource
 			for _, res := range data {
@@ -142,13 +166,37 @@ func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, erro
 
 func wrapWithPF(data []dao.Resource, app common.AppController) []dao.Resource {
 	pfMgr := app.GetPortForwardManager()
+	checker := app.GetImageChecker()
+	// Containers share images and projects: look each one up once
+	updates := make(map[string]string)
+	drifts := make(map[string]daoCompose.DriftCheck)
 	for i, res := range data {
 		if c, ok := res.(dao.Container); ok {
 			pf := ""
 			if pfMgr.GetForContainer(c.ID) != nil {
 				pf = "●"
 			}
-			data[i] = containerWithPF{Container: c, pf: pf}
+			imageKey := c.Image + "@" + c.ImageID
+			update, seen := updates[imageKey]
+			if !seen {
+				if result, ok := checker.ForContainer(c.Image, c.ImageID); ok {
+					update = result.Label()
+				}
+				updates[imageKey] = update
+			}
+			// Running containers created from an older version of their
+			// compose service (a redeploy would recreate them)
+			drift := ""
+			if c.ProjectName != "" {
+				check, seen := drifts[c.ProjectName]
+				if !seen {
+					check = app.GetDocker().ComposeProjectDrift(c.ProjectName)
+					drifts[c.ProjectName] = check
+				}
+				drifted, err := check.Drifted(c.Labels)
+				drift = daoCompose.DriftLabel(drifted && c.State == "running", err)
+			}
+			data[i] = containerWithPF{Container: c, pf: pf, update: update, drift: drift}
 		}
 	}
 	return data
@@ -161,16 +209,23 @@ func GetShortcuts() []string {
 		common.FormatSCHeader("f", "Show PortForward"),
 		common.FormatSCHeader("i", "Image"),
 		common.FormatSCHeader("d", "Describe"),
+		common.FormatSCHeader("shift-d", "Run Command"),
+		common.FormatSCHeader("y", "Export Compose"),
 		common.FormatSCHeader("e", "Env"),
 		common.FormatSCHeader("t", "Stats"),
 		common.FormatSCHeader("m", "Monitor"),
 		common.FormatSCHeader("v", "Volumes"),
 		common.FormatSCHeader("n", "Networks"),
 		common.FormatSCHeader("p", "Project"),
+		common.FormatSCHeader("x", "Drift Diff"),
 		common.FormatSCHeader("r", "(Re)Start"),
+		common.FormatSCHeader("shift-u", "Pull & Recreate"),
 		common.FormatSCHeader("shift-f", "Port-Forward"),
 		common.FormatSCHeader("shift-p", "Prune"),
 		common.FormatSCHeader("shift-s", "Root Shell"),
+		common.FormatSCHeader("b", "Debug"),
+		common.FormatSCHeader("a", "Diagnostics"),
+		common.FormatSCHeader("w", "Capture"),
 		common.FormatSCHeader("shift-n", "Attach Network"),
 		common.FormatSCHeader("ctrl-k", "Stop"),
 		common.FormatSCHeader("ctrl-d", "Delete"),
@@ -224,6 +279,9 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	case 'p':
 		Project(app, v)
 		return nil
+	case 'x':
+		DriftDiff(app, v)
+		return nil
 	case 'i':
 		InspectImage(app, v)
 		return nil
@@ -241,12 +299,33 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 			Shell(app, id, true)
 		}
 		return nil
+	case 'a':
+		Diagnose(app, v)
+		return nil
+	case 'w':
+		CaptureAction(app, v)
+		return nil
+	case 'b':
+		id, err := v.GetSelectedID()
+		if err == nil {
+			Debug(app, id)
+		}
+		return nil
 	case 'd':
 		Describe(app, v)
 		return nil
+	case 'D':
+		RunCommand(app, v)
+		return nil
+	case 'y':
+		ExportCompose(app, v)
+		return nil
 	case 'r':
 		RestartOrStart(app, v)
 		return nil
+	case 'U':
+		PullRecreateAction(app, v)
+		return nil
 	case 'P':
 		PruneAction(app)
 		return nil
@@ -255,6 +334,44 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	return event
 }
 
+// PullRecreateAction pulls the image reference of the selected containers
+// and recreates them with the same configuration on the fresh image.
+func PullRecreateAction(app common.AppController, v *view.ResourceView) {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	refs := make(map[string]string)
+	names := make(map[string]string)
+	for _, item := range v.Data {
+		if c, ok := asContainer(item); ok {
+			refs[c.ID] = c.Image
+			names[c.ID] = c.Names
+		}
+	}
+
+	label := fmt.Sprintf("%d items", len(ids))
+	if len(ids) == 1 {
+		label = fmt.Sprintf("%s ([%s]%s[yellow])", names[ids[0]], styles.TagCyan, refs[ids[0]])
+	}
+
+	dialogs.ShowConfirmation(app, "PULL & RECREATE", label, func(_ bool) {
+		app.PerformAction(func(id string) error {
+			docker := app.GetDocker()
+			ref := refs[id]
+			if ref != "" && !strings.HasPrefix(ref, "sha256:") {
+				if err := docker.PullImage(ref); err != nil {
+					return fmt.Errorf("pull %s: %v", ref, err)
+				}
+			}
+			_, err := docker.RecreateContainer(id)
+			app.GetImageChecker().CheckNow()
+			return err
+		}, "recreating", styles.ColorStatusOrange)
+	})
+}
+
 func DeleteAction(app common.AppController, v *view.ResourceView) {
 	ids, err := v.GetSelectedIDs()
 	if err != nil {
@@ -428,6 +545,59 @@ func Describe(app common.AppController, v *view.ResourceView) {
 	app.OpenInspector(inspect.NewTextInspector("Describe container", subject, content, "json"))
 }
 
+// RunCommand rebuilds the `docker run` command line of the selected
+// container, copies it and shows it.
+func RunCommand(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+	subject := resolveContainerSubject(v, id)
+
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().ContainerRunCommand(id)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.AppendFlashError(fmt.Sprintf("%v", err))
+				return
+			}
+			if err := app.CopyToClipboard(content); err != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to copy: %v", err))
+			} else {
+				app.AppendFlashSuccess("docker run command copied")
+			}
+			app.OpenInspector(inspect.NewTextInspector("Run command", subject, content, "bash"))
+		})
+	})
+}
+
+// ExportCompose turns the selected containers into a compose file, one
+// service each, to bring hand-run containers under compose.
+func ExportCompose(app common.AppController, v *view.ResourceView) {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("%d containers", len(ids))
+	if len(ids) == 1 {
+		subject = resolveContainerSubject(v, ids[0])
+	}
+
+	app.AppendFlashPending("exporting compose file...")
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().ExportContainersCompose(ids)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to export: %v", err))
+				return
+			}
+			app.AppendFlashSuccess("compose file ready")
+			app.OpenInspector(inspect.NewTextInspectorWithSave("Compose export", subject, content, "yaml", "docker-compose.yml"))
+		})
+	})
+}
+
 func Shell(app common.AppController, id string, asRoot bool) {
 	// Stop any background refresh to prevent UI updates interfering with the shell
 	app.StopAutoRefresh()
@@ -523,6 +693,203 @@ func Shell(app common.AppController, id string, asRoot bool) {
 	}
 }
 
+// Debug opens a shell in a throwaway container sharing the namespaces and
+// volumes of the target, for images without a shell. The debug container
+// is removed on exit.
+func Debug(app common.AppController, id string) {
+	image := app.GetConfig().D4S.GetDebugImage()
+	args, notes, err := app.GetDocker().ContainerDebugRun(id, image)
+	if err != nil {
+		app.AppendFlashError(fmt.Sprintf("failed to debug: %v", err))
+		return
+	}
+	name := args[slices.Index(args, "--name")+1]
+
+	app.StopAutoRefresh()
+	app.SetPaused(true)
+
+	defer func() {
+		app.SetPaused(false)
+		app.StartAutoRefresh()
+	}()
+
+	app.GetTviewApp().Suspend(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Shell panic: %v\n", r)
+			}
+		}()
+
+		fmt.Print("\033[H\033[2J")
+
+		// Kill any leftover debug containers from previous sessions
+		if out, _ := common.DockerCommand(app, "ps", "-aq", "--filter", "name="+dao.ContainerDebugPrefix).Output(); len(out) > 0 {
+			ids := strings.Fields(strings.TrimSpace(string(out)))
+			if len(ids) > 0 {
+				fmt.Printf("Cleaning up %d previous debug container(s)...\n", len(ids))
+				common.DockerCommand(app, append([]string{"rm", "-f"}, ids...)...).Run()
+			}
+		}
+
+		fmt.Printf("Debugging %s from a %s container (CTRL+D or 'exit' to return)...\n", id, image)
+		for _, note := range notes {
+			fmt.Printf("Note: %s\n", note)
+		}
+
+		cmd := common.DockerCommand(app, args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		// Swallow signals so d4s doesn't die on CTRL+C.
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		defer func() {
+			signal.Stop(sigChan)
+			close(sigChan)
+		}()
+		go func() {
+			for range sigChan {
+			}
+		}()
+
+		err := cmd.Run()
+		go common.DockerCommand(app, "rm", "-f", name).Run()
+
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				code := exitErr.ExitCode()
+				if code == 130 || code == 137 || code == 0 || code == -1 {
+					return
+				}
+			}
+			fmt.Printf("Error: %v\nPress Enter to continue...", err)
+			fmt.Scanln()
+		}
+	})
+
+	// Fix race conditions/glitches where screen isn't fully restored
+	if app.GetScreen() != nil {
+		app.GetScreen().Sync()
+	}
+}
+
+// Diagnose offers network diagnostics run from inside the network namespace
+// of the selected container, so the target's image needs no tools.
+func Diagnose(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	items := []dialogs.PickerItem{
+		{Label: "DNS Lookup", Description: "Resolve a name", Value: dao.DiagnoseDNS},
+		{Label: "TCP Connect", Description: "Open a connection to host:port", Value: dao.DiagnoseTCP},
+		{Label: "HTTP GET", Description: "Status and timings of a URL", Value: dao.DiagnoseHTTP},
+		{Label: "Listening Sockets", Description: "Open ports and their processes", Value: dao.DiagnoseSockets},
+	}
+	dialogs.ShowPicker(app, "Diagnostics", items, func(kind string) {
+		switch kind {
+		case dao.DiagnoseDNS:
+			dialogs.ShowInput(app, "DNS Lookup", "Name:", "", func(text string) {
+				if name := strings.TrimSpace(text); name != "" {
+					runDiagnostic(app, id, "DNS Lookup", kind, name)
+				}
+			})
+		case dao.DiagnoseTCP:
+			dialogs.ShowInput(app, "TCP Connect", "Host:port:", "", func(text string) {
+				host, port, err := net.SplitHostPort(strings.TrimSpace(text))
+				if err != nil || host == "" {
+					app.AppendFlashError(fmt.Sprintf("invalid address %q: expected host:port", text))
+					return
+				}
+				runDiagnostic(app, id, "TCP Connect", kind, host, port)
+			})
+		case dao.DiagnoseHTTP:
+			dialogs.ShowInput(app, "HTTP GET", "URL:", "http://", func(text string) {
+				url := strings.TrimSpace(text)
+				if url == "" || url == "http://" {
+					return
+				}
+				if !strings.Contains(url, "://") {
+					url = "http://" + url
+				}
+				runDiagnostic(app, id, "HTTP GET", kind, url)
+			})
+		case dao.DiagnoseSockets:
+			runDiagnostic(app, id, "Sockets", kind)
+		}
+	})
+}
+
+// CaptureAction asks for a BPF filter and limits, then captures the packets
+// of the selected container into a local pcap file listed in :captures.
+func CaptureAction(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+	name := id
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	row, _ := v.Table.GetSelection()
+	if row > 0 && row-1 < len(v.Data) {
+		if c, ok := asContainer(v.Data[row-1]); ok {
+			name = strings.TrimPrefix(c.Names, "/")
+		}
+	}
+
+	fields := []dialogs.FormField{
+		{Name: "filter", Label: "BPF filter", Type: dialogs.FieldTypeInput, Placeholder: "tcp port 80"},
+		{Name: "duration", Label: "Duration", Type: dialogs.FieldTypeInput, Default: "1m", Placeholder: "until stopped"},
+		{Name: "count", Label: "Packet limit", Type: dialogs.FieldTypeInput, Placeholder: "unlimited"},
+	}
+	dialogs.ShowFormWithDescription(app, "Capture", fmt.Sprintf("Capture the packets of %s", name), fields, func(result dialogs.FormResult) {
+		var duration time.Duration
+		if text := strings.TrimSpace(result["duration"]); text != "" {
+			d, err := time.ParseDuration(text)
+			if err != nil || d < 0 {
+				app.AppendFlashError(fmt.Sprintf("invalid duration %q", text))
+				return
+			}
+			duration = d
+		}
+		count := 0
+		if text := strings.TrimSpace(result["count"]); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil || n < 0 {
+				app.AppendFlashError(fmt.Sprintf("invalid packet limit %q", text))
+				return
+			}
+			count = n
+		}
+		captures.Start(app, id, name, strings.TrimSpace(result["filter"]), duration, count)
+	})
+}
+
+func runDiagnostic(app common.AppController, id, action, kind string, args ...string) {
+	subject := id
+	if len(subject) > 12 {
+		subject = subject[:12]
+	}
+	inspector := inspect.NewTextInspector(action, subject, fmt.Sprintf(" [%s]Running diagnostic...\n", styles.TagAccent), "text")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		report, err := app.GetDocker().ContainerDiagnose(id, app.GetConfig().D4S.GetDebugImage(), kind, args...)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Content = report
+			inspector.Viewer.Update(report, "text")
+		})
+	})
+}
+
 func InspectImage(app common.AppController, v *view.ResourceView) {
 	id, err := v.GetSelectedID()
 	if err != nil {
@@ -557,6 +924,28 @@ func InspectImage(app common.AppController, v *view.ResourceView) {
 	app.SwitchTo(styles.TitleImages)
 }
 
+// DriftDiff shows how the selected compose container differs from its
+// service in the compose files.
+func DriftDiff(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	var c dao.Container
+	for _, res := range v.Data {
+		if res.GetID() == id {
+			c, _ = asContainer(res)
+			break
+		}
+	}
+	if c.ProjectName == "" || c.ComposeService == "" {
+		app.SetFlashError("this container is not part of a compose project")
+		return
+	}
+	compose.DriftDiff(app, c.ProjectName, c.ComposeService)
+}
+
 func Project(app common.AppController, v *view.ResourceView) {
 	id, err := v.GetSelectedID()
 	if err != nil {
@@ -621,27 +1010,14 @@ func StopAction(app common.AppController, v *view.ResourceView) {
 	}, "stopping", styles.ColorStatusRed)
 }
 
+// PruneAction previews the containers a prune would remove and removes the
+// ones left selected.
 func PruneAction(app common.AppController) {
-	dialogs.ShowConfirmation(app, "PRUNE", "Containers", func(force bool) {
-		app.SetFlashPending("pruning containers...")
-		app.RunInBackground(func() {
-			err := Prune(app)
-			app.GetTviewApp().QueueUpdateDraw(func() {
-				if err != nil {
-					app.SetFlashError(fmt.Sprintf("%v", err))
-				} else {
-					app.SetFlashSuccess("pruned containers")
-					app.RefreshCurrentView()
-				}
-			})
-		})
+	dialogs.ShowPrunePreview(app, "containers", app.GetDocker().PruneContainersCandidates, func(id string) error {
+		return app.GetDocker().RemoveContainer(id, false)
 	})
 }
 
-func Prune(app common.AppController) error {
-	return app.GetDocker().PruneContainers()
-}
-
 func Remove(id string, force bool, app common.AppController) error {
 	return app.GetDocker().RemoveContainer(id, force)
 }
@@ -740,7 +1116,7 @@ func NetworksPicker(app common.AppController, v *view.ResourceView) {
 			var errs []string
 
 			for _, netID := range toConnect {
-				if err := app.GetDocker().ConnectNetwork(netID, id); err != nil {
+				if err := app.GetDocker().ConnectNetwork(netID, id, nil); err != nil {
 					errs = append(errs, fmt.Sprintf("connect %s: %v", netID, err))
 				}
 			}
@@ -2,11 +2,11 @@ package contexts
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
 	"github.com/jr-k/d4s/internal/secrets"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/inspect"
@@ -363,7 +363,7 @@ func showSSHEditForm(app common.AppController, id, currentDesc, currentEndpoint,
 
 		creds := secrets.SSHCredentials{
 			AuthType:   authType,
-			KeyPath:    expandHome(strings.TrimSpace(result["key"])),
+			KeyPath:    daocommon.ExpandHome(strings.TrimSpace(result["key"])),
 			Passphrase: result["passphrase"],
 			Password:   result["password"],
 		}
@@ -455,7 +455,7 @@ func showSSHForm(app common.AppController, authType string) {
 
 		creds := secrets.SSHCredentials{
 			AuthType:   authType,
-			KeyPath:    expandHome(strings.TrimSpace(result["key"])),
+			KeyPath:    daocommon.ExpandHome(strings.TrimSpace(result["key"])),
 			Passphrase: result["passphrase"],
 			Password:   result["password"],
 		}
@@ -488,15 +488,6 @@ func showSSHForm(app common.AppController, authType string) {
 	})
 }
 
-func expandHome(path string) string {
-	if strings.HasPrefix(path, "~/") {
-		if home, err := os.UserHomeDir(); err == nil {
-			return home + path[1:]
-		}
-	}
-	return path
-}
-
 func Inspect(app common.AppController, id string) {
 	inspector := inspect.NewTextInspector("Describe context", id, fmt.Sprintf(" [%s]Loading context...\n", styles.TagAccent), "json")
 	app.OpenInspector(inspector)
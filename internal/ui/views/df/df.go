@@ -0,0 +1,196 @@
+package df
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/dao/docker/system"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/components/view"
+	"github.com/jr-k/d4s/internal/ui/dialogs"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+var Headers = []string{"TYPE", "TOTAL", "ACTIVE", "SIZE", "RECLAIMABLE"}
+var ImageHeaders = []string{"ID", "TAGS", "SIZE", "SHARED", "UNIQUE", "CONTAINERS", "CREATED"}
+var ContainerHeaders = []string{"ID", "NAME", "IMAGE", "STATE", "SIZE", "VIRTUAL", "CREATED"}
+var VolumeHeaders = []string{"NAME", "DRIVER", "SIZE", "LINKS", "CREATED"}
+var CacheHeaders = []string{"ID", "TYPE", "SIZE", "SHARED", "IN USE", "LAST USED", "USAGE", "DESCRIPTION"}
+var AllHeaders = uniqueHeaders(Headers, ImageHeaders, ContainerHeaders, VolumeHeaders, CacheHeaders)
+
+var kindTitles = map[string]string{
+	system.KindImages:     "images",
+	system.KindContainers: "containers",
+	system.KindVolumes:    "volumes",
+	system.KindBuildCache: "build cache",
+}
+
+func uniqueHeaders(sets ...[]string) []string {
+	seen := make(map[string]bool)
+	var res []string
+	for _, set := range sets {
+		for _, h := range set {
+			if !seen[h] {
+				seen[h] = true
+				res = append(res, h)
+			}
+		}
+	}
+	return res
+}
+
+func Fetch(app common.AppController, _ *view.ResourceView) ([]dao.Resource, []string, error) {
+	du, err := app.GetDocker().DiskUsage()
+	if err != nil {
+		return nil, Headers, err
+	}
+
+	scope := app.GetActiveScope()
+	if scope == nil || scope.Type != "df" {
+		return du.Summary(), Headers, nil
+	}
+
+	switch scope.Value {
+	case system.KindImages:
+		return du.Images(), ImageHeaders, nil
+	case system.KindContainers:
+		return du.Containers(), ContainerHeaders, nil
+	case system.KindVolumes:
+		return du.Volumes(), VolumeHeaders, nil
+	case system.KindBuildCache:
+		return du.BuildCache(), CacheHeaders, nil
+	}
+	return du.Summary(), Headers, nil
+}
+
+func GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("enter", "Details"),
+		common.FormatSCHeader("ctrl-r", "Reload"),
+		common.FormatSCHeader("shift-p", "Prune Build Cache"),
+	}
+}
+
+func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+	switch event.Key() {
+	case tcell.KeyEnter:
+		EnterAction(app, v)
+		return nil
+	case tcell.KeyCtrlR:
+		ReloadAction(app)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'P':
+		PruneBuildCacheAction(app)
+		return nil
+	}
+	return event
+}
+
+// EnterAction drills down from a summary row into the items of that type.
+func EnterAction(app common.AppController, v *view.ResourceView) {
+	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == "df" {
+		return
+	}
+
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+	title, ok := kindTitles[id]
+	if !ok {
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "df",
+		Value:      id,
+		Label:      title,
+		OriginView: styles.TitleDiskUsage,
+		Parent:     scope,
+	})
+	app.SwitchTo(styles.TitleDiskUsage)
+}
+
+// ReloadAction forces a fresh DiskUsage snapshot (the cached one is
+// only refreshed every 30s).
+func ReloadAction(app common.AppController) {
+	app.SetFlashPending("computing disk usage...")
+	app.RunInBackground(func() {
+		_, err := app.GetDocker().RefreshDiskUsage()
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.SetFlashError(fmt.Sprintf("%v", err))
+				return
+			}
+			app.SetFlashSuccess("disk usage refreshed")
+			app.RefreshCurrentView()
+		})
+	})
+}
+
+func PruneBuildCacheAction(app common.AppController) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	fields := []dialogs.FormField{
+		{Name: "until", Label: "Unused for", Type: dialogs.FieldTypeInput, Placeholder: "e.g. 24h, 7d (empty: any age)"},
+		{Name: "all", Label: "All (not only unreferenced)", Type: dialogs.FieldTypeCheckbox, Default: "false"},
+	}
+
+	dialogs.ShowFormWithDescription(app, "Prune Build Cache", "Remove build cache records", fields, func(result dialogs.FormResult) {
+		until, err := parseAge(result["until"])
+		if err != nil {
+			app.SetFlashError(fmt.Sprintf("invalid age: %v", err))
+			return
+		}
+		all := result["all"] == "true"
+
+		app.SetFlashPending("pruning build cache...")
+		app.RunInBackground(func() {
+			reclaimed, count, err := app.GetDocker().PruneBuildCache(all, until)
+			if err == nil {
+				_, err = app.GetDocker().RefreshDiskUsage()
+			}
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.SetFlashError(fmt.Sprintf("%v", err))
+					return
+				}
+				app.SetFlashSuccess(fmt.Sprintf("pruned %d build cache records, reclaimed %s", count, daocommon.FormatBytes(int64(reclaimed))))
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+// parseAge validates an age for the "until" prune filter, accepting days
+// ("7d") on top of Go durations.
+func parseAge(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%q", s)
+		}
+		return (time.Duration(n) * 24 * time.Hour).String(), nil
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return "", fmt.Errorf("%q", s)
+	}
+	return s, nil
+}
@@ -5,12 +5,15 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/inspect"
 	"github.com/jr-k/d4s/internal/ui/components/view"
@@ -18,13 +21,14 @@ import (
 	"github.com/jr-k/d4s/internal/ui/styles"
 )
 
-var Headers = []string{"ID", "TAGS", "SIZE", "CONTAINERS", "CREATED"}
+var Headers = []string{"ID", "TAGS", "SIZE", "CONTAINERS", "CREATED", "UPDATE"}
 
 func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
 	images, err := app.GetDocker().ListImages()
 	if err != nil {
 		return nil, err
 	}
+	images = withUpdateStatus(app, images)
 
 	scope := app.GetActiveScope()
 	if scope != nil && scope.Type == "image" {
@@ -44,12 +48,33 @@ func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, erro
 	return images, nil
 }
 
+// withUpdateStatus fills the UPDATE column from the background checker.
+// The listing may be a shared cache slice, so results go into a copy.
+func withUpdateStatus(app common.AppController, images []dao.Resource) []dao.Resource {
+	checker := app.GetImageChecker()
+	res := make([]dao.Resource, 0, len(images))
+	for _, r := range images {
+		if img, ok := r.(dao.Image); ok {
+			if result, ok := checker.ForImage(img.ID); ok {
+				img.Update = result.Label()
+			}
+			r = img
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
 func GetShortcuts() []string {
 	return []string{
 		common.FormatSCHeader("enter", "Containers"),
 		common.FormatSCHeader("d", "Describe"),
 		common.FormatSCHeader("v", "Dive"),
 		common.FormatSCHeader("r", "Pull"),
+		common.FormatSCHeader("s", "Save"),
+		common.FormatSCHeader("l", "Load"),
+		common.FormatSCHeader("t", "Copy to Context"),
+		common.FormatSCHeader("shift-u", "Check Updates"),
 		common.FormatSCHeader("shift-p", "Prune"),
 		common.FormatSCHeader("ctrl-d", "Delete"),
 	}
@@ -68,6 +93,19 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	case 'r':
 		PullAction(app, v)
 		return nil
+	case 's':
+		SaveAction(app, v)
+		return nil
+	case 'l':
+		LoadAction(app)
+		return nil
+	case 't':
+		CopyToContextAction(app, v)
+		return nil
+	case 'U':
+		app.GetImageChecker().CheckNow()
+		app.SetFlashPending("checking image updates...")
+		return nil
 	case 'P':
 		PruneAction(app)
 		return nil
@@ -120,20 +158,11 @@ func EnterAction(app common.AppController, v *view.ResourceView) {
 	app.SwitchTo(styles.TitleContainers)
 }
 
+// PruneAction previews the images a prune would remove and removes the
+// ones left selected.
 func PruneAction(app common.AppController) {
-	dialogs.ShowConfirmation(app, "PRUNE", "Images", func(force bool) {
-		app.SetFlashPending("pruning images...")
-		app.RunInBackground(func() {
-			err := Prune(app)
-			app.GetTviewApp().QueueUpdateDraw(func() {
-				if err != nil {
-					app.SetFlashError(fmt.Sprintf("%v", err))
-				} else {
-					app.SetFlashSuccess("pruned images")
-					app.RefreshCurrentView()
-				}
-			})
-		})
+	dialogs.ShowPrunePreview(app, "images", app.GetDocker().PruneImagesCandidates, func(id string) error {
+		return app.GetDocker().RemoveImage(id, false)
 	})
 }
 
@@ -182,6 +211,217 @@ func PullAction(app common.AppController, v *view.ResourceView) {
 	}
 }
 
+// selectedRefs resolves the selected rows into references usable by
+// save/load: the repo tag when there is one (so it survives the round
+// trip), the image ID otherwise.
+func selectedRefs(v *view.ResourceView) []string {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return nil
+	}
+
+	idMap := make(map[string]bool)
+	for _, id := range ids {
+		idMap[id] = true
+	}
+
+	var refs []string
+	for _, item := range v.Data {
+		if !idMap[item.GetID()] {
+			continue
+		}
+		img, ok := item.(dao.Image)
+		if !ok {
+			continue
+		}
+		ref := img.ID
+		if img.RepoTag != "" && img.RepoTag != "<none>" {
+			ref = img.RepoTag
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// refsSize estimates the archive size of refs. It inspects each image, so
+// it runs in the background.
+func refsSize(app common.AppController, refs []string) int64 {
+	var total int64
+	for _, ref := range refs {
+		total += app.GetDocker().ImageSize(ref)
+	}
+	return total
+}
+
+func refsLabel(refs []string) string {
+	if len(refs) == 1 {
+		return refs[0]
+	}
+	return fmt.Sprintf("%d images", len(refs))
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func SaveAction(app common.AppController, v *view.ResourceView) {
+	refs := selectedRefs(v)
+	if len(refs) == 0 {
+		return
+	}
+
+	name := "images"
+	if len(refs) == 1 {
+		name = strings.Trim(unsafeFileChars.ReplaceAllString(refs[0], "_"), "_")
+	}
+
+	dialogs.ShowInput(app, "Save Image", "Path:", fmt.Sprintf("./%s.tar", name), func(text string) {
+		path := daocommon.ExpandHome(strings.TrimSpace(text))
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		dialogs.ConfirmOverwrite(app, path, func() {
+			label := refsLabel(refs)
+			app.SetFlashPending(fmt.Sprintf("saving %s...", label))
+			app.RunInBackground(func() {
+				f, err := os.Create(path)
+				if err != nil {
+					app.GetTviewApp().QueueUpdateDraw(func() {
+						app.AppendFlashError(fmt.Sprintf("failed to create archive: %v", err))
+					})
+					return
+				}
+
+				total := refsSize(app, refs)
+				saveErr := app.GetDocker().SaveImages(refs, f, common.TransferProgress(app, "saving "+label, total))
+				closeErr := f.Close()
+				if saveErr == nil {
+					saveErr = closeErr
+				}
+				if saveErr != nil {
+					os.Remove(path)
+				}
+
+				app.GetTviewApp().QueueUpdateDraw(func() {
+					if saveErr != nil {
+						app.AppendFlashError(fmt.Sprintf("failed to save %s: %v", label, saveErr))
+						return
+					}
+					app.AppendFlashSuccess(fmt.Sprintf("%s saved to %s", label, daocommon.ShortenPath(path)), 10*time.Second)
+				})
+			})
+		})
+	})
+}
+
+func LoadAction(app common.AppController) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	dialogs.ShowInput(app, "Load Image", "Path:", "./", func(text string) {
+		path := daocommon.ExpandHome(strings.TrimSpace(text))
+		if path == "" {
+			return
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			app.AppendFlashError(fmt.Sprintf("failed to open archive: %v", err))
+			return
+		}
+
+		var total int64
+		if info, err := f.Stat(); err == nil {
+			total = info.Size()
+		}
+
+		label := filepath.Base(path)
+		app.SetFlashPending(fmt.Sprintf("loading %s...", label))
+		app.RunInBackground(func() {
+			defer f.Close()
+			loaded, err := app.GetDocker().LoadImages(f, common.TransferProgress(app, "loading "+label, total))
+
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to load %s: %v", label, err))
+					return
+				}
+				app.AppendFlashSuccess(fmt.Sprintf("loaded %s", loadedLabel(loaded, label)), 10*time.Second)
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+func loadedLabel(loaded []string, fallback string) string {
+	switch len(loaded) {
+	case 0:
+		return fallback
+	case 1:
+		return loaded[0]
+	}
+	return fmt.Sprintf("%d images", len(loaded))
+}
+
+func CopyToContextAction(app common.AppController, v *view.ResourceView) {
+	refs := selectedRefs(v)
+	if len(refs) == 0 {
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	contexts, err := dao.ListContexts()
+	if err != nil {
+		app.AppendFlashError(fmt.Sprintf("failed to load docker contexts: %v", err))
+		return
+	}
+
+	current := app.GetDocker().ContextName
+	var items []dialogs.PickerItem
+	for _, ctx := range contexts {
+		if ctx.Name == current {
+			continue
+		}
+		description := ctx.DockerEndpoint
+		if description == "" {
+			description = ctx.Description
+		}
+		items = append(items, dialogs.PickerItem{
+			Label:       ctx.Name,
+			Description: description,
+			Value:       ctx.Name,
+		})
+	}
+	if len(items) == 0 {
+		app.AppendFlashError("no other docker context to copy to")
+		return
+	}
+
+	label := refsLabel(refs)
+	dialogs.ShowPicker(app, "Copy to Context: "+label, items, func(target string) {
+		app.SetFlashPending(fmt.Sprintf("copying %s to %s...", label, target))
+		app.RunInBackground(func() {
+			progress := common.TransferProgress(app, fmt.Sprintf("copying %s to %s", label, target), refsSize(app, refs))
+			loaded, err := app.GetDocker().CopyImagesToContext(refs, target, progress)
+
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to copy %s to %s: %v", label, target, err))
+					return
+				}
+				app.AppendFlashSuccess(fmt.Sprintf("copied %s to %s", loadedLabel(loaded, label), target), 10*time.Second)
+			})
+		})
+	})
+}
+
 func Inspect(app common.AppController, id string) {
 	subject := id
 	if len(id) > 12 {
@@ -252,10 +492,6 @@ func DeleteAction(app common.AppController, v *view.ResourceView) {
 	})
 }
 
-func Prune(app common.AppController) error {
-	return app.GetDocker().PruneImages()
-}
-
 func Remove(id string, force bool, app common.AppController) error {
 	return app.GetDocker().RemoveImage(id, force)
 }
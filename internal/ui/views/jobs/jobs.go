@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/components/inspect"
+	"github.com/jr-k/d4s/internal/ui/components/view"
+	"github.com/jr-k/d4s/internal/ui/dialogs"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+var Headers = []string{"PROJECT", "SERVICE", "NAME", "KIND", "STATE", "EXIT", "STARTED", "FINISHED", "DURATION"}
+
+// Scope type of the failed-only filter.
+const scopeFailed = "jobs-failed"
+
+func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
+	data, err := app.GetDocker().ListComposeJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	project, failedOnly := "", false
+	for s := app.GetActiveScope(); s != nil; s = s.Parent {
+		switch s.Type {
+		case "compose":
+			if project == "" {
+				project = s.Value
+			}
+		case scopeFailed:
+			failedOnly = true
+		}
+	}
+	if project == "" && !failedOnly {
+		return data, nil
+	}
+
+	var filtered []dao.Resource
+	for _, res := range data {
+		job, ok := res.(dao.ComposeJob)
+		if !ok {
+			continue
+		}
+		if project != "" && job.Project != project {
+			continue
+		}
+		if failedOnly && !job.Failed() {
+			continue
+		}
+		filtered = append(filtered, res)
+	}
+	return filtered, nil
+}
+
+func GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("l", "Logs"),
+		common.FormatSCHeader("d", "Describe"),
+		common.FormatSCHeader("r", "Rerun"),
+		common.FormatSCHeader("f", "Failed Only"),
+		common.FormatSCHeader("ctrl-d", "Delete"),
+	}
+}
+
+func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+
+	if event.Key() == tcell.KeyCtrlD {
+		DeleteAction(app, v)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'l':
+		Logs(app, v)
+		return nil
+	case 'd':
+		app.InspectCurrentSelection()
+		return nil
+	case 'r':
+		RerunAction(app, v)
+		return nil
+	case 'f':
+		FailedOnly(app)
+		return nil
+	}
+	return event
+}
+
+func selected(v *view.ResourceView) (dao.ComposeJob, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return dao.ComposeJob{}, false
+	}
+	job, ok := v.Data[row-1].(dao.ComposeJob)
+	return job, ok
+}
+
+func Logs(app common.AppController, v *view.ResourceView) {
+	if job, ok := selected(v); ok {
+		app.OpenInspector(inspect.NewLogInspectorWithConfig(job.ID, job.Name, "container", app.GetConfig().D4S.Logger))
+	}
+}
+
+// RerunAction runs the selected job again and follows its output.
+func RerunAction(app common.AppController, v *view.ResourceView) {
+	job, ok := selected(v)
+	if !ok {
+		return
+	}
+	if job.Running() {
+		app.AppendFlashError(fmt.Sprintf("%s is still running", job.Name))
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	app.AppendFlashPending(fmt.Sprintf("rerunning %s...", job.Name))
+	app.RunInBackground(func() {
+		run, err := app.GetDocker().RerunComposeJob(job.ID)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to rerun %s: %v", job.Name, err))
+				return
+			}
+			app.OpenInspector(inspect.NewOutputInspector("Rerun", fmt.Sprintf("%s@%s", job.Service, job.Project), run))
+		})
+	})
+}
+
+// FailedOnly narrows the list to the jobs that exited with an error; Esc
+// shows them all again.
+func FailedOnly(app common.AppController) {
+	for s := app.GetActiveScope(); s != nil; s = s.Parent {
+		if s.Type == scopeFailed {
+			app.AppendFlash("showing failed jobs only: esc to show all")
+			return
+		}
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       scopeFailed,
+		Value:      "failed",
+		Label:      "failed",
+		OriginView: styles.TitleJobs,
+	})
+	app.SwitchTo(styles.TitleJobs)
+}
+
+func DeleteAction(app common.AppController, v *view.ResourceView) {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	label := fmt.Sprintf("%d items", len(ids))
+	if len(ids) == 1 {
+		if job, ok := selected(v); ok && job.ID == ids[0] {
+			label = job.Name
+		}
+	}
+
+	dialogs.ShowConfirmation(app, "DELETE", label, func(_ bool) {
+		app.PerformAction(func(id string) error {
+			return Remove(id, true, app)
+		}, "deleting", styles.ColorStatusRed)
+	})
+}
+
+func Remove(id string, force bool, app common.AppController) error {
+	return app.GetDocker().RemoveContainer(id, force)
+}
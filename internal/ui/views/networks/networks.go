@@ -2,28 +2,85 @@ package networks
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/jr-k/d4s/internal/dao"
+	daoCommon "github.com/jr-k/d4s/internal/dao/common"
 	"github.com/jr-k/d4s/internal/ui/common"
 	"github.com/jr-k/d4s/internal/ui/components/inspect"
 	"github.com/jr-k/d4s/internal/ui/components/view"
 	"github.com/jr-k/d4s/internal/ui/dialogs"
 	"github.com/jr-k/d4s/internal/ui/styles"
+	"github.com/rivo/tview"
 )
 
 var Headers = []string{"ID", "NAME", "DRIVER", "SCOPE", "CONTAINERS", "CREATED", "INTERNAL", "SUBNET"}
+var EndpointHeaders = []string{"NAME", "KIND", "IPV4", "IPV6", "MAC", "ALIASES", "DNS NAMES", "PROBLEM"}
+var AllHeaders = append(append([]string(nil), Headers...), "KIND", "IPV4", "IPV6", "MAC", "ALIASES", "DNS NAMES", "PROBLEM")
 
-func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
+// Scope type of the endpoints of a network, valued with its ID.
+const endpointsScope = "network-endpoints"
+
+func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, []string, error) {
 	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == endpointsScope {
+		data, err := app.GetDocker().ListNetworkEndpoints(scope.Value)
+		return data, EndpointHeaders, err
+	}
 	if scope != nil && scope.Type == "container" {
-		return app.GetDocker().ListNetworksForContainer(scope.Value)
+		data, err := app.GetDocker().ListNetworksForContainer(scope.Value)
+		return data, Headers, err
+	}
+	data, err := app.GetDocker().ListNetworks()
+	return data, Headers, err
+}
+
+func endpointsNetwork(app common.AppController) (*common.Scope, bool) {
+	scope := app.GetActiveScope()
+	if scope == nil || scope.Type != endpointsScope {
+		return nil, false
+	}
+	return scope, true
+}
+
+// selectedAttachments returns the selected endpoints, or the one under the
+// cursor when none is selected.
+func selectedAttachments(v *view.ResourceView) []dao.NetworkAttachment {
+	ids, err := v.GetSelectedIDs()
+	if err != nil {
+		return nil
+	}
+	idMap := make(map[string]bool)
+	for _, id := range ids {
+		idMap[id] = true
 	}
-	return app.GetDocker().ListNetworks()
+
+	var attachments []dao.NetworkAttachment
+	for _, item := range v.Data {
+		if a, ok := item.(dao.NetworkAttachment); ok && idMap[a.GetID()] {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+func selectedAttachment(v *view.ResourceView) (dao.NetworkAttachment, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return dao.NetworkAttachment{}, false
+	}
+	a, ok := v.Data[row-1].(dao.NetworkAttachment)
+	return a, ok
 }
 
 func Inspect(app common.AppController, id string) {
+	if _, ok := endpointsNetwork(app); ok {
+		inspectEndpoint(app, id)
+		return
+	}
+
 	subject := id
 	if len(id) > 12 {
 		subject = id[:12]
@@ -61,50 +118,326 @@ func Inspect(app common.AppController, id string) {
 	})
 }
 
-func GetShortcuts() []string {
+// Topology draws how the containers of the context, or of one compose
+// project, are wired: networks as hubs with their containers, containers
+// bridging several networks, and the ports published on the host.
+func Topology(app common.AppController, project string) {
+	subject := app.GetDocker().ContextName
+	if subject == "" {
+		subject = "default"
+	}
+	if project != "" {
+		subject = project + "@" + subject
+	}
+	inspector := inspect.NewTextInspector("Topology", subject, fmt.Sprintf(" [%s]Mapping networks...\n", styles.TagAccent), "text")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		topology, err := app.GetDocker().NetworkTopology(project)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(renderTopology(topology), "text")
+		})
+	})
+}
+
+func renderTopology(t *dao.NetworkTopology) string {
+	var sb strings.Builder
+
+	running := make(map[string]bool, len(t.Containers))
+	var bridges, isolated []dao.TopologyContainer
+	ports := 0
+	for _, c := range t.Containers {
+		running[c.Name] = c.Running
+		ports += len(c.Ports)
+		switch {
+		case len(c.Networks) > 1:
+			bridges = append(bridges, c)
+		case len(c.Networks) == 0:
+			isolated = append(isolated, c)
+		}
+	}
+
+	title := "all containers"
+	if t.Project != "" {
+		title = "project " + t.Project
+	}
+	fmt.Fprintf(&sb, "\n [%s::b]%s[-::-] [%s]%d networks · %d containers · %d bridges · %d published ports[-]\n",
+		styles.TagPink, tview.Escape(title), styles.TagDim, len(t.Networks), len(t.Containers), len(bridges), ports)
+
+	dot := func(name string) string {
+		if running[name] {
+			return fmt.Sprintf("[%s]●[-]", styles.TagInfo)
+		}
+		return fmt.Sprintf("[%s]○[-]", styles.TagDim)
+	}
+
+	if len(t.Networks) > 0 {
+		fmt.Fprintf(&sb, "\n [%s::b]NETWORKS[-::-]\n", styles.TagCyan)
+	}
+	for _, n := range t.Networks {
+		details := []string{n.Driver}
+		if n.Subnet != "" {
+			details = append(details, n.Subnet)
+		}
+		if n.Internal {
+			details = append(details, "internal")
+		}
+		fmt.Fprintf(&sb, "\n   [%s]◆[-] [%s::b]%s[-::-] [%s](%s)[-]\n",
+			styles.TagAccent, styles.TagFg, tview.Escape(n.Name), styles.TagDim, tview.Escape(strings.Join(details, ", ")))
+
+		width := 0
+		for _, m := range n.Members {
+			width = max(width, len([]rune(m.Container)))
+		}
+		for i, m := range n.Members {
+			branch := "├─"
+			if i == len(n.Members)-1 {
+				branch = "└─"
+			}
+			fmt.Fprintf(&sb, "     [%s]%s[-] %s %s", styles.TagDim, branch, dot(m.Container), tview.Escape(m.Container))
+			sb.WriteString(strings.Repeat(" ", width-len([]rune(m.Container))))
+
+			var addrs []string
+			if m.IPv4 != "" {
+				addrs = append(addrs, m.IPv4)
+			}
+			if m.IPv6 != "" {
+				addrs = append(addrs, m.IPv6)
+			}
+			if len(addrs) == 0 {
+				addrs = append(addrs, "no address")
+			}
+			fmt.Fprintf(&sb, "  %s", tview.Escape(strings.Join(addrs, " ")))
+			if len(m.Aliases) > 0 {
+				fmt.Fprintf(&sb, "  [%s]aka %s[-]", styles.TagDim, tview.Escape(strings.Join(m.Aliases, ", ")))
+			}
+			if len(m.Bridges) > 0 {
+				fmt.Fprintf(&sb, "  [%s]⇄ %s[-]", styles.TagAccent, tview.Escape(strings.Join(m.Bridges, ", ")))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(bridges) > 0 {
+		fmt.Fprintf(&sb, "\n [%s::b]BRIDGES[-::-] [%s](containers on several networks)[-]\n\n", styles.TagCyan, styles.TagDim)
+		for _, c := range bridges {
+			fmt.Fprintf(&sb, "   %s %s  [%s]%s[-]\n", dot(c.Name), tview.Escape(c.Name),
+				styles.TagAccent, tview.Escape(strings.Join(c.Networks, " ⇄ ")))
+		}
+	}
+
+	if ports > 0 {
+		fmt.Fprintf(&sb, "\n [%s::b]HOST PORTS[-::-]\n\n   [%s]⌂[-] [%s::b]host[-::-]\n", styles.TagCyan, styles.TagAccent, styles.TagFg)
+		var edges []string
+		for _, c := range t.Containers {
+			for _, p := range c.Ports {
+				edges = append(edges, fmt.Sprintf("%s ─▶ %s %s:%d/%s",
+					tview.Escape(p.Host()), dot(c.Name), tview.Escape(c.Name), p.ContainerPort, p.Proto))
+			}
+		}
+		for i, e := range edges {
+			branch := "├─"
+			if i == len(edges)-1 {
+				branch = "└─"
+			}
+			fmt.Fprintf(&sb, "     [%s]%s[-] %s\n", styles.TagDim, branch, e)
+		}
+	}
+
+	if len(isolated) > 0 {
+		fmt.Fprintf(&sb, "\n [%s::b]NO NETWORK[-::-]\n\n", styles.TagCyan)
+		for _, c := range isolated {
+			mode := c.NetworkMode
+			if mode == "" {
+				mode = "none"
+			}
+			fmt.Fprintf(&sb, "   %s %s  [%s]network mode %s[-]\n", dot(c.Name), tview.Escape(c.Name), styles.TagDim, tview.Escape(mode))
+		}
+	}
+
+	if len(t.Containers) == 0 {
+		fmt.Fprintf(&sb, "\n [%s]No containers.[-]\n", styles.TagDim)
+	}
+	return sb.String()
+}
+
+// inspectEndpoint describes the container behind an endpoint.
+func inspectEndpoint(app common.AppController, id string) {
+	subject := id
+	if len(id) > 12 {
+		subject = id[:12]
+	}
+	inspector := inspect.NewTextInspector("Describe container", subject, fmt.Sprintf(" [%s]Loading container...\n", styles.TagAccent), "json")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().Inspect("container", id)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(content, "json")
+		})
+	})
+}
+
+// ShowEndpoints lists the endpoints of a network in place of the networks.
+func ShowEndpoints(app common.AppController, net dao.Network) {
+	app.SetActiveScope(&common.Scope{
+		Type:       endpointsScope,
+		Value:      net.ID,
+		Label:      net.Name,
+		OriginView: styles.TitleNetworks,
+	})
+	app.SwitchTo(styles.TitleNetworks)
+}
+
+func endpointsInputHandler(v *view.ResourceView, scope *common.Scope, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+	if event.Key() == tcell.KeyCtrlD {
+		DisconnectAction(app, v, scope)
+		return nil
+	}
+	if event.Key() == tcell.KeyEnter || event.Rune() == 'd' {
+		if a, ok := selectedAttachment(v); ok {
+			if a.ContainerID == "" {
+				app.AppendFlashError(fmt.Sprintf("%s is a %s, not a container", a.Name, a.Kind))
+				return nil
+			}
+			inspectEndpoint(app, a.ContainerID)
+		}
+		return nil
+	}
+	switch event.Rune() {
+	case 'o':
+		app.SetActiveScope(&common.Scope{
+			Type:       "network",
+			Value:      scope.Value,
+			Label:      scope.Label,
+			OriginView: styles.TitleNetworks,
+		})
+		app.SwitchTo(styles.TitleContainers)
+		return nil
+	}
+	return event
+}
+
+// DisconnectAction removes the selected containers from the network shown.
+// Selected endpoints that are not containers are left alone.
+func DisconnectAction(app common.AppController, v *view.ResourceView, scope *common.Scope) {
+	attachments := selectedAttachments(v)
+	if len(attachments) == 0 {
+		return
+	}
+
+	byID := make(map[string]dao.NetworkAttachment)
+	var names []string
+	for _, a := range attachments {
+		if a.ContainerID == "" {
+			continue
+		}
+		byID[a.GetID()] = a
+		names = append(names, a.Name)
+	}
+	if len(byID) == 0 {
+		if a := attachments[0]; len(attachments) == 1 {
+			app.AppendFlashError(fmt.Sprintf("%s is a %s, not a container", a.Name, a.Kind))
+		} else {
+			app.AppendFlashError("no container selected")
+		}
+		return
+	}
+
+	label := fmt.Sprintf("%s from %s", strings.Join(names, ", "), scope.Label)
+	dialogs.ShowConfirmation(app, "DISCONNECT", label, func(_ bool) {
+		app.PerformAction(func(id string) error {
+			a, ok := byID[id]
+			if !ok {
+				return nil
+			}
+			return app.GetDocker().DisconnectNetwork(scope.Value, a.ContainerID)
+		}, "disconnecting", styles.ColorStatusRed)
+	})
+}
+
+func GetShortcuts(app common.AppController) []string {
+	if _, ok := endpointsNetwork(app); ok {
+		return []string{
+			common.FormatSCHeader("enter", "Describe"),
+			common.FormatSCHeader("o", "Containers"),
+			common.FormatSCHeader("esc", "Back"),
+			common.FormatSCHeader("ctrl-d", "Disconnect"),
+		}
+	}
+	add := common.FormatSCHeader("a", "Add")
+	if scope := app.GetActiveScope(); scope != nil && scope.Type == "container" {
+		add = common.FormatSCHeader("a", "Connect")
+	}
 	return []string{
 		common.FormatSCHeader("d", "Describe"),
-		common.FormatSCHeader("enter", "Containers"),
-		common.FormatSCHeader("a", "Add"),
+		common.FormatSCHeader("enter", "Endpoints"),
+		common.FormatSCHeader("o", "Containers"),
+		common.FormatSCHeader("t", "Topology"),
+		add,
 		common.FormatSCHeader("shift-p", "Prune"),
 		common.FormatSCHeader("ctrl-d", "Delete"),
 	}
 }
 
+func selectedNetwork(v *view.ResourceView) (dao.Network, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return dao.Network{}, false
+	}
+	net, ok := v.Data[row-1].(dao.Network)
+	return net, ok
+}
+
 func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	app := v.App
+	if scope, ok := endpointsNetwork(app); ok {
+		return endpointsInputHandler(v, scope, event)
+	}
 	if event.Key() == tcell.KeyCtrlD {
 		DeleteAction(app, v)
 		return nil
 	}
 	if event.Key() == tcell.KeyEnter {
-		row, _ := v.Table.GetSelection()
-		if row < 1 || row >= v.Table.GetRowCount() {
-			return nil
-		}
-		dataIndex := row - 1
-		if dataIndex < 0 || dataIndex >= len(v.Data) {
-			return nil
+		if net, ok := selectedNetwork(v); ok {
+			ShowEndpoints(app, net)
 		}
-		if net, ok := v.Data[dataIndex].(dao.Network); ok {
-			scope := &common.Scope{
+		return nil
+	}
+	switch event.Rune() {
+	case 'd':
+		app.InspectCurrentSelection()
+		return nil
+	case 'o':
+		if net, ok := selectedNetwork(v); ok {
+			app.SetActiveScope(&common.Scope{
 				Type:       "network",
 				Value:      net.ID,
 				Label:      net.Name,
 				OriginView: styles.TitleNetworks,
-			}
-			app.SetActiveScope(scope)
+			})
 			app.SwitchTo(styles.TitleContainers)
 		}
 		return nil
-	}
-	switch event.Rune() {
-	case 'd':
-		app.InspectCurrentSelection()
-		return nil
 	case 'a':
+		if scope := app.GetActiveScope(); scope != nil && scope.Type == "container" {
+			ConnectAction(app, scope.Value)
+			return nil
+		}
 		Create(app)
 		return nil
+	case 't':
+		Topology(app, "")
+		return nil
 	case 'P':
 		PruneAction(app)
 		return nil
@@ -112,20 +445,11 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	return event
 }
 
+// PruneAction previews the networks a prune would remove and removes the
+// ones left selected.
 func PruneAction(app common.AppController) {
-	dialogs.ShowConfirmation(app, "PRUNE", "Networks", func(force bool) {
-		app.SetFlashPending("pruning networks...")
-		app.RunInBackground(func() {
-			err := Prune(app)
-			app.GetTviewApp().QueueUpdateDraw(func() {
-				if err != nil {
-					app.SetFlashError(fmt.Sprintf("%v", err))
-				} else {
-					app.SetFlashSuccess("pruned networks")
-					app.RefreshCurrentView()
-				}
-			})
-		})
+	dialogs.ShowPrunePreview(app, "networks", app.GetDocker().PruneNetworksCandidates, func(id string) error {
+		return app.GetDocker().RemoveNetwork(id)
 	})
 }
 
@@ -159,29 +483,107 @@ func DeleteAction(app common.AppController, v *view.ResourceView) {
 	})
 }
 
-func Prune(app common.AppController) error {
-	return app.GetDocker().PruneNetworks()
-}
-
 func Remove(id string, force bool, app common.AppController) error {
 	return app.GetDocker().RemoveNetwork(id)
 }
 
+// Create asks for a driver, then for the addressing and options of the
+// network to create.
 func Create(app common.AppController) {
-	dialogs.ShowInput(app, "Create Network", "Network Name: ", "", func(text string) {
-		app.SetFlashPending(fmt.Sprintf("creating network %s...", text))
+	descriptions := map[string]string{
+		"bridge":  "Single host, NAT to the outside",
+		"overlay": "Multi-host, needs swarm mode",
+		"macvlan": "Containers get a MAC on a host interface",
+		"ipvlan":  "Containers share the MAC of a host interface",
+	}
+	var items []dialogs.PickerItem
+	for _, d := range dao.NetworkDrivers {
+		items = append(items, dialogs.PickerItem{Label: d, Description: descriptions[d], Value: d})
+	}
+	dialogs.ShowPicker(app, "Network Driver", items, func(driver string) {
+		createForm(app, driver)
+	})
+}
+
+func createForm(app common.AppController, driver string) {
+	fields := []dialogs.FormField{
+		{Name: "name", Label: "Name", Type: dialogs.FieldTypeInput},
+		{Name: "subnet", Label: "IPv4 subnet", Type: dialogs.FieldTypeInput, Placeholder: "172.30.0.0/16"},
+		{Name: "gateway", Label: "IPv4 gateway", Type: dialogs.FieldTypeInput, Placeholder: "172.30.0.1"},
+		{Name: "iprange", Label: "IPv4 IP range", Type: dialogs.FieldTypeInput, Placeholder: "172.30.5.0/24"},
+		{Name: "subnet6", Label: "IPv6 subnet", Type: dialogs.FieldTypeInput, Placeholder: "fd00:30::/64"},
+		{Name: "gateway6", Label: "IPv6 gateway", Type: dialogs.FieldTypeInput, Placeholder: "fd00:30::1"},
+		{Name: "iprange6", Label: "IPv6 IP range", Type: dialogs.FieldTypeInput, Placeholder: "fd00:30::/80"},
+	}
+	optionsHint := "key=value,..."
+	switch driver {
+	case "macvlan", "ipvlan":
+		fields = append(fields, dialogs.FormField{Name: "parent", Label: "Parent interface", Type: dialogs.FieldTypeInput, Placeholder: "eth0"})
+		optionsHint = driver + "_mode=..."
+	case "bridge":
+		optionsHint = "com.docker.network.bridge.name=br0"
+	case "overlay":
+		optionsHint = "encrypted=true"
+	}
+	fields = append(fields,
+		dialogs.FormField{Name: "options", Label: "Driver options", Type: dialogs.FieldTypeInput, Placeholder: optionsHint},
+		dialogs.FormField{Name: "labels", Label: "Labels", Type: dialogs.FieldTypeInput, Placeholder: "key=value,..."},
+		dialogs.FormField{Name: "internal", Label: "Internal", Type: dialogs.FieldTypeCheckbox, Default: "false"},
+		dialogs.FormField{Name: "attachable", Label: "Attachable", Type: dialogs.FieldTypeCheckbox, Default: fmt.Sprintf("%t", driver == "overlay")},
+	)
+
+	dialogs.ShowFormWithDescription(app, "Create Network", fmt.Sprintf("Driver: %s", driver), fields, func(result dialogs.FormResult) {
+		name := strings.TrimSpace(result["name"])
+		if name == "" {
+			app.SetFlashError("network name is required")
+			return
+		}
+		options, err := daoCommon.ParseKeyValues(result["options"])
+		if err != nil {
+			app.SetFlashError(fmt.Sprintf("driver options: %v", err))
+			return
+		}
+		if parent := strings.TrimSpace(result["parent"]); parent != "" {
+			options["parent"] = parent
+		}
+		labels, err := daoCommon.ParseKeyValues(result["labels"])
+		if err != nil {
+			app.SetFlashError(fmt.Sprintf("labels: %v", err))
+			return
+		}
+
+		spec := dao.NetworkSpec{
+			Name:   name,
+			Driver: driver,
+			IPv4: dao.NetworkAddressPool{
+				Subnet:  strings.TrimSpace(result["subnet"]),
+				Gateway: strings.TrimSpace(result["gateway"]),
+				IPRange: strings.TrimSpace(result["iprange"]),
+			},
+			IPv6: dao.NetworkAddressPool{
+				Subnet:  strings.TrimSpace(result["subnet6"]),
+				Gateway: strings.TrimSpace(result["gateway6"]),
+				IPRange: strings.TrimSpace(result["iprange6"]),
+			},
+			Internal:   result["internal"] == "true",
+			Attachable: result["attachable"] == "true",
+			Options:    options,
+			Labels:     labels,
+		}
+
+		app.SetFlashPending(fmt.Sprintf("creating network %s...", name))
 		app.RunInBackground(func() {
-			err := app.GetDocker().CreateNetwork(text)
+			err := app.GetDocker().CreateNetwork(spec)
 			app.GetTviewApp().QueueUpdateDraw(func() {
 				if err != nil {
 					app.SetFlashError(fmt.Sprintf("%v", err))
 				} else {
-					app.SetFlashSuccess(fmt.Sprintf("network %s created", text))
-					
+					app.SetFlashSuccess(fmt.Sprintf("network %s created", name))
+
 					// Highlight and Select the new resource
 					app.ScheduleViewHighlight(styles.TitleNetworks, func(res dao.Resource) bool {
 						net, ok := res.(dao.Network)
-						return ok && net.Name == text
+						return ok && net.Name == name
 					}, styles.ColorStatusGreen, styles.ColorStatusGreen, 2*time.Second)
 
 					app.RefreshCurrentView()
@@ -190,3 +592,74 @@ func Create(app common.AppController) {
 		})
 	})
 }
+
+// ConnectAction attaches the scoped container to another network, with an
+// optional static address and aliases.
+func ConnectAction(app common.AppController, containerID string) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	allNetworks, err := app.GetDocker().ListNetworks()
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+	currentNetworks, err := app.GetDocker().ListNetworksForContainer(containerID)
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+	attached := make(map[string]bool)
+	for _, res := range currentNetworks {
+		attached[res.GetID()] = true
+	}
+
+	var items []dialogs.PickerItem
+	names := make(map[string]string)
+	for _, res := range allNetworks {
+		n, ok := res.(dao.Network)
+		if !ok || attached[n.ID] {
+			continue
+		}
+		names[n.ID] = n.Name
+		items = append(items, dialogs.PickerItem{Label: n.Name, Description: strings.TrimSpace(n.Driver + " " + n.Subnet), Value: n.ID})
+	}
+	if len(items) == 0 {
+		app.AppendFlashError("the container is already on every network")
+		return
+	}
+
+	dialogs.ShowPicker(app, "Connect Network", items, func(netID string) {
+		fields := []dialogs.FormField{
+			{Name: "ipv4", Label: "IPv4 address", Type: dialogs.FieldTypeInput, Placeholder: "automatic"},
+			{Name: "ipv6", Label: "IPv6 address", Type: dialogs.FieldTypeInput, Placeholder: "automatic"},
+			{Name: "aliases", Label: "Aliases", Type: dialogs.FieldTypeInput, Placeholder: "db,db.internal"},
+		}
+		dialogs.ShowFormWithDescription(app, "Connect Network", fmt.Sprintf("Connect to %s", names[netID]), fields, func(result dialogs.FormResult) {
+			endpoint := &dao.NetworkEndpoint{
+				IPv4: strings.TrimSpace(result["ipv4"]),
+				IPv6: strings.TrimSpace(result["ipv6"]),
+			}
+			for _, a := range strings.Split(result["aliases"], ",") {
+				if a = strings.TrimSpace(a); a != "" {
+					endpoint.Aliases = append(endpoint.Aliases, a)
+				}
+			}
+
+			app.SetFlashPending(fmt.Sprintf("connecting to %s...", names[netID]))
+			app.RunInBackground(func() {
+				err := app.GetDocker().ConnectNetwork(netID, containerID, endpoint)
+				app.GetTviewApp().QueueUpdateDraw(func() {
+					if err != nil {
+						app.SetFlashError(fmt.Sprintf("%v", err))
+						return
+					}
+					app.SetFlashSuccess(fmt.Sprintf("connected to %s", names[netID]))
+					app.RefreshCurrentView()
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,432 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao"
+	daocommon "github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/components/inspect"
+	"github.com/jr-k/d4s/internal/ui/components/view"
+	"github.com/jr-k/d4s/internal/ui/dialogs"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+var Headers = []string{"REPOSITORY", "TAGS"}
+var TagHeaders = []string{"TAG", "DIGEST", "PLATFORMS", "SIZE", "CREATED"}
+var AllHeaders = append(append([]string(nil), Headers...), TagHeaders...)
+
+var (
+	clientMu  sync.Mutex
+	client    *dao.RegistryClient
+	clientKey string
+)
+
+// Client returns the registry client for the configured endpoint, rebuilt
+// when the endpoint changes.
+func Client(app common.AppController) (*dao.RegistryClient, error) {
+	cfg := app.GetConfig().D4S.Registry
+	key := fmt.Sprintf("%s|%t", cfg.Endpoint, cfg.Insecure)
+
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client != nil && clientKey == key {
+		return client, nil
+	}
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return nil, fmt.Errorf("no registry endpoint configured (set d4s.registry.endpoint in config.yaml)")
+	}
+
+	c, err := dao.NewRegistryClient(cfg.Endpoint, cfg.Insecure)
+	if err != nil {
+		return nil, err
+	}
+	client, clientKey = c, key
+	return client, nil
+}
+
+func Fetch(app common.AppController, _ *view.ResourceView) ([]dao.Resource, []string, error) {
+	c, err := Client(app)
+	if err != nil {
+		return nil, Headers, err
+	}
+
+	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == "registry" {
+		data, err := c.ListTags(scope.Value)
+		return data, TagHeaders, err
+	}
+
+	data, err := c.ListRepositories()
+	return data, Headers, err
+}
+
+func GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("enter", "Tags/Manifest"),
+		common.FormatSCHeader("d", "Manifest"),
+		common.FormatSCHeader("r", "Pull"),
+		common.FormatSCHeader("x", "Compare Local"),
+		common.FormatSCHeader("ctrl-r", "Reload"),
+		common.FormatSCHeader("ctrl-d", "Delete"),
+	}
+}
+
+func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+	switch event.Key() {
+	case tcell.KeyEnter:
+		EnterAction(app, v)
+		return nil
+	case tcell.KeyCtrlD:
+		DeleteAction(app, v)
+		return nil
+	case tcell.KeyCtrlR:
+		if c, err := Client(app); err == nil {
+			c.Invalidate()
+		}
+		app.RefreshCurrentView()
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'd':
+		app.InspectCurrentSelection()
+		return nil
+	case 'r':
+		PullAction(app, v)
+		return nil
+	case 'x':
+		CompareAction(app, v)
+		return nil
+	}
+	return event
+}
+
+func selectedTag(v *view.ResourceView) (dao.RegistryTag, bool) {
+	row, _ := v.Table.GetSelection()
+	if row < 1 || row-1 >= len(v.Data) {
+		return dao.RegistryTag{}, false
+	}
+	t, ok := v.Data[row-1].(dao.RegistryTag)
+	return t, ok
+}
+
+func selectedTags(v *view.ResourceView) []dao.RegistryTag {
+	ids, err := v.GetSelectedIDs()
+	if err != nil {
+		return nil
+	}
+	idMap := make(map[string]bool)
+	for _, id := range ids {
+		idMap[id] = true
+	}
+
+	var tags []dao.RegistryTag
+	for _, item := range v.Data {
+		if t, ok := item.(dao.RegistryTag); ok && idMap[t.GetID()] {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func EnterAction(app common.AppController, v *view.ResourceView) {
+	id, err := v.GetSelectedID()
+	if err != nil {
+		return
+	}
+
+	scope := app.GetActiveScope()
+	if scope != nil && scope.Type == "registry" {
+		Inspect(app, id)
+		return
+	}
+
+	app.SetActiveScope(&common.Scope{
+		Type:       "registry",
+		Value:      id,
+		Label:      id,
+		OriginView: styles.TitleRegistry,
+		Parent:     scope,
+	})
+	app.SwitchTo(styles.TitleRegistry)
+}
+
+// Inspect shows the manifest details of a tag ("repo:tag"); on a
+// repository row it lists the tags instead.
+func Inspect(app common.AppController, id string) {
+	repo, tag, ok := splitTagID(id)
+	if !ok {
+		return
+	}
+
+	c, err := Client(app)
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	inspector := inspect.NewTextInspector("Describe manifest", id, fmt.Sprintf(" [%s]Loading manifest...\n", styles.TagAccent), "yaml")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		m, err := c.Manifest(repo, tag)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Viewer.Update(formatManifest(c, m), "yaml")
+		})
+	})
+}
+
+// splitTagID splits a tag row ID ("repo:tag"). Repository rows have no tag.
+func splitTagID(id string) (string, string, bool) {
+	i := strings.LastIndex(id, ":")
+	if i < 0 || strings.Contains(id[i+1:], "/") {
+		return "", "", false
+	}
+	return id[:i], id[i+1:], true
+}
+
+func formatManifest(c *dao.RegistryClient, m *dao.RegistryManifest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "image: %s\n", c.ImageRef(m.Repository, m.Reference))
+	fmt.Fprintf(&sb, "digest: %s\n", m.Digest)
+	fmt.Fprintf(&sb, "mediaType: %s\n", m.MediaType)
+	fmt.Fprintf(&sb, "size: %s\n", daocommon.FormatBytes(m.Size))
+	if !m.Created.IsZero() {
+		fmt.Fprintf(&sb, "created: %s\n", m.Created.Local().Format(time.RFC3339))
+	}
+
+	sb.WriteString("platforms:\n")
+	for _, p := range m.Platforms {
+		name := p.Name
+		if name == "" {
+			name = "unknown"
+		}
+		fmt.Fprintf(&sb, "  - platform: %s\n    digest: %s\n    size: %s\n", name, p.Digest, daocommon.FormatBytes(p.Size))
+	}
+
+	if len(m.Labels) == 0 {
+		sb.WriteString("labels: {}\n")
+	} else {
+		sb.WriteString("labels:\n")
+		keys := make([]string, 0, len(m.Labels))
+		for k := range m.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "  %s: %q\n", k, m.Labels[k])
+		}
+	}
+	return sb.String()
+}
+
+// maxParallelPulls bounds the pulls PullAction runs at once.
+const maxParallelPulls = 3
+
+func PullAction(app common.AppController, v *view.ResourceView) {
+	tags := selectedTags(v)
+	if len(tags) == 0 {
+		return
+	}
+
+	c, err := Client(app)
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	queue := make(chan string, len(tags))
+	for _, t := range tags {
+		queue <- c.ImageRef(t.Repository, t.Name)
+	}
+	close(queue)
+
+	for range min(maxParallelPulls, len(tags)) {
+		app.RunInBackground(func() {
+			for ref := range queue {
+				err := app.GetDocker().PullImage(ref)
+				app.GetTviewApp().QueueUpdateDraw(func() {
+					if err != nil {
+						app.AppendFlashError(fmt.Sprintf("pull failed: %v", err))
+						return
+					}
+					app.AppendFlashSuccess(fmt.Sprintf("pulled %s", ref))
+				})
+			}
+		})
+	}
+	app.SetFlashPending(fmt.Sprintf("pulling %d image(s)...", len(tags)))
+}
+
+func DeleteAction(app common.AppController, v *view.ResourceView) {
+	tags := selectedTags(v)
+	if len(tags) == 0 {
+		return
+	}
+
+	c, err := Client(app)
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	byID := make(map[string]dao.RegistryTag)
+	for _, t := range tags {
+		byID[t.GetID()] = t
+	}
+
+	label := fmt.Sprintf("[yellow]%s", tags[0].GetID())
+	if len(tags) > 1 {
+		label = fmt.Sprintf("%d tags", len(tags))
+	}
+	// Deleting by digest removes every tag pointing to it: name the others.
+	if siblings := siblingTags(v, byID); len(siblings) > 0 {
+		label += fmt.Sprintf("\n[%s]also removes: [yellow]%s", styles.TagFg, strings.Join(siblings, ", "))
+	}
+
+	dialogs.ShowConfirmation(app, "DELETE", label, func(force bool) {
+		action := func(id string) error {
+			t, ok := byID[id]
+			if !ok {
+				return nil
+			}
+			if t.Digest == "" {
+				return fmt.Errorf("%s: unknown digest", id)
+			}
+			return c.Delete(t.Repository, t.Digest)
+		}
+		app.PerformAction(action, "deleting", styles.ColorStatusRed)
+	})
+}
+
+// siblingTags returns the listed tags that are not selected but share the
+// digest of a selected tag.
+func siblingTags(v *view.ResourceView, selected map[string]dao.RegistryTag) []string {
+	digests := make(map[string]bool)
+	for _, t := range selected {
+		if t.Digest != "" {
+			digests[t.Repository+"@"+t.Digest] = true
+		}
+	}
+
+	var siblings []string
+	for _, item := range v.Data {
+		t, ok := item.(dao.RegistryTag)
+		if !ok || t.Digest == "" {
+			continue
+		}
+		if _, sel := selected[t.GetID()]; !sel && digests[t.Repository+"@"+t.Digest] {
+			siblings = append(siblings, t.GetID())
+		}
+	}
+	return siblings
+}
+
+// CompareAction compares the registry manifest of a tag with the local
+// image of the same reference.
+func CompareAction(app common.AppController, v *view.ResourceView) {
+	t, ok := selectedTag(v)
+	if !ok {
+		return
+	}
+
+	c, err := Client(app)
+	if err != nil {
+		app.SetFlashError(fmt.Sprintf("%v", err))
+		return
+	}
+
+	ref := c.ImageRef(t.Repository, t.Name)
+	inspector := inspect.NewTextInspector("Compare", ref, fmt.Sprintf(" [%s]Comparing with local image...\n", styles.TagAccent), "yaml")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		content := compare(app, c, t, ref)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			inspector.Viewer.Update(content, "yaml")
+		})
+	})
+}
+
+// repositoryName returns the normalized repository of an image reference,
+// without tag or digest.
+func repositoryName(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return named.Name()
+}
+
+func compare(app common.AppController, c *dao.RegistryClient, t dao.RegistryTag, ref string) string {
+	var sb strings.Builder
+
+	m, err := c.Manifest(t.Repository, t.Name)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	fmt.Fprintf(&sb, "image: %s\n", ref)
+	sb.WriteString("remote:\n")
+	fmt.Fprintf(&sb, "  digest: %s\n", m.Digest)
+	fmt.Fprintf(&sb, "  platforms: %s\n", m.PlatformNames())
+	fmt.Fprintf(&sb, "  size: %s\n", daocommon.FormatBytes(m.Size))
+	if !m.Created.IsZero() {
+		fmt.Fprintf(&sb, "  created: %s\n", m.Created.Local().Format(time.RFC3339))
+	}
+
+	local, err := app.GetDocker().InspectImage(ref)
+	if err != nil {
+		sb.WriteString("local: not present\n")
+		sb.WriteString("status: not pulled\n")
+		return sb.String()
+	}
+
+	sb.WriteString("local:\n")
+	fmt.Fprintf(&sb, "  id: %s\n", local.ID)
+	// Only digests of this repository count: the same image may have been
+	// pushed elsewhere.
+	repository := repositoryName(ref)
+	localDigest := ""
+	pushed := false
+	for _, rd := range local.RepoDigests {
+		if name, digest, ok := strings.Cut(rd, "@"); ok {
+			fmt.Fprintf(&sb, "  repoDigest: %s\n", rd)
+			if repositoryName(name) != repository {
+				continue
+			}
+			pushed = true
+			if digest == m.Digest {
+				localDigest = digest
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "  platform: %s/%s\n", local.Os, local.Architecture)
+	fmt.Fprintf(&sb, "  size: %s\n", daocommon.FormatBytes(local.Size))
+	if local.Created != "" {
+		fmt.Fprintf(&sb, "  created: %s\n", local.Created)
+	}
+
+	switch {
+	case localDigest != "":
+		sb.WriteString("status: up to date\n")
+	case !pushed:
+		sb.WriteString("status: local only (never pushed to or pulled from this repository)\n")
+	default:
+		sb.WriteString("status: differs from registry\n")
+	}
+	return sb.String()
+}
@@ -102,9 +102,34 @@ func Secrets(app common.AppController, v *view.ResourceView) {
 	app.OpenInspector(inspect.NewTextInspector("Secrets service", subject, strings.Join(lines, "\n"), "text"))
 }
 
-var Headers = []string{"ID", "NAME", "IMAGE", "MODE", "REPLICAS", "PORTS", "CREATED", "UPDATED"}
+var Headers = []string{"ID", "NAME", "IMAGE", "MODE", "REPLICAS", "PORTS", "CREATED", "UPDATED", "UPDATE"}
 
 func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
+	data, err := fetch(app)
+	if err != nil {
+		return nil, err
+	}
+	return withUpdateStatus(app, data), nil
+}
+
+// withUpdateStatus fills the UPDATE column from the background checker.
+// The listing may be a shared cache slice, so results go into a copy.
+func withUpdateStatus(app common.AppController, services []dao.Resource) []dao.Resource {
+	checker := app.GetImageChecker()
+	res := make([]dao.Resource, 0, len(services))
+	for _, r := range services {
+		if svc, ok := r.(dao.Service); ok {
+			if result, ok := checker.ForService(svc.ImageRef); ok {
+				svc.Update = result.Label()
+			}
+			r = svc
+		}
+		res = append(res, r)
+	}
+	return res
+}
+
+func fetch(app common.AppController) ([]dao.Resource, error) {
 	scope := app.GetActiveScope()
 
 	// Filter by Secret Scope
@@ -173,6 +198,7 @@ func GetShortcuts() []string {
 		common.FormatSCHeader("d", "Describe"),
 		common.FormatSCHeader("s", "Scale"),
 		common.FormatSCHeader("r", "Restart"),
+		common.FormatSCHeader("shift-u", "Update Image"),
 		common.FormatSCHeader("z", "No Replica"),
 		common.FormatSCHeader("shift-f", "Port-Forward"),
 		common.FormatSCHeader("shift-e", "Edit Env"),
@@ -240,6 +266,9 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	case 'r':
 		RestartAction(app, v)
 		return nil
+	case 'U':
+		UpdateImageAction(app, v)
+		return nil
 	case 'z':
 		ScaleZero(app, v)
 		return nil
@@ -439,6 +468,36 @@ func RestartAction(app common.AppController, v *view.ResourceView) {
 	})
 }
 
+// UpdateImageAction re-resolves the image tag of the selected services
+// against the registry, rolling them onto the latest digest.
+func UpdateImageAction(app common.AppController, v *view.ResourceView) {
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	refs := make(map[string]string)
+	for _, item := range v.Data {
+		if svc, ok := item.(dao.Service); ok {
+			ref, _, _ := strings.Cut(svc.ImageRef, "@")
+			refs[svc.ID] = ref
+		}
+	}
+
+	dialogs.ShowConfirmation(app, "UPDATE IMAGE", fmt.Sprintf("%d services", len(ids)), func(force bool) {
+		updateAction := func(id string) error {
+			ref := refs[id]
+			if ref == "" {
+				return fmt.Errorf("%s: unknown image", id)
+			}
+			err := app.GetDocker().UpdateServiceImage(id, ref)
+			app.GetImageChecker().CheckNow()
+			return err
+		}
+		app.PerformAction(updateAction, "updating", styles.ColorStatusOrange)
+	})
+}
+
 func ScaleZero(app common.AppController, v *view.ResourceView) {
 	ids, err := v.GetSelectedIDs()
 	if err != nil {
@@ -1,6 +1,7 @@
 package volumes
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
@@ -21,12 +22,23 @@ import (
 	"github.com/jr-k/d4s/internal/ui/styles"
 )
 
-var Headers = []string{"NAME", "DRIVER", "SCOPE", "USED BY", "MOUNTPOINT", "CREATED", "ANON"}
+var Headers = []string{"NAME", "DRIVER", "SCOPE", "SIZE", "REFCOUNT", "USED BY", "MOUNTPOINT", "CREATED", "ANON"}
 var ContainerHeaders = []string{"NAME", "TYPE", "DRIVER", "SCOPE", "DESTINATION", "MOUNTPOINT", "CREATED", "ANON"}
-var AllHeaders = append(append([]string(nil), Headers...), "TYPE", "DESTINATION")
+var FileHeaders = []string{"NAME", "TYPE", "SIZE", "OWNER", "MODE", "MODIFIED"}
+var AllHeaders = append(append([]string(nil), Headers...), "TYPE", "DESTINATION", "OWNER", "MODE", "MODIFIED")
+
+// Scope type of the file browser, valued "<volume>:<directory>".
+const filesScope = "volume-files"
+
+// maxViewSize is the largest file shown in the viewer.
+const maxViewSize = 1 << 20
 
 func Fetch(app common.AppController, _ *view.ResourceView) ([]dao.Resource, []string, error) {
 	scope := app.GetActiveScope()
+	if volume, dir, ok := browsing(app); ok {
+		data, err := app.GetDocker().ListVolumeFiles(volume, dir, app.GetConfig().D4S.ShellPod.Image)
+		return data, FileHeaders, err
+	}
 	if scope != nil && scope.Type == "container" {
 		data, err := app.GetDocker().ListVolumesForContainer(scope.Value)
 		return data, ContainerHeaders, err
@@ -36,12 +48,37 @@ func Fetch(app common.AppController, _ *view.ResourceView) ([]dao.Resource, []st
 	return data, Headers, err
 }
 
-func GetShortcuts() []string {
+// browsing returns the volume and directory shown by the file browser.
+func browsing(app common.AppController) (string, string, bool) {
+	scope := app.GetActiveScope()
+	if scope == nil || scope.Type != filesScope {
+		return "", "", false
+	}
+	volume, dir, _ := strings.Cut(scope.Value, ":")
+	return volume, dir, true
+}
+
+func GetShortcuts(app common.AppController) []string {
+	if _, _, ok := browsing(app); ok {
+		return []string{
+			common.FormatSCHeader("enter", "Open"),
+			common.FormatSCHeader("shift-d", "Download"),
+			common.FormatSCHeader("shift-u", "Upload"),
+			common.FormatSCHeader("esc", "Up"),
+			common.FormatSCHeader("ctrl-d", "Delete"),
+		}
+	}
 	return []string{
-		common.FormatSCHeader("s", "Shell"),
+		common.FormatSCHeader("s", "Browse"),
+		common.FormatSCHeader("shift-s", "Shell"),
 		common.FormatSCHeader("d", "Describe"),
 		common.FormatSCHeader("o", "Open"),
 		common.FormatSCHeader("a", "Add"),
+		common.FormatSCHeader("b", "Backup"),
+		common.FormatSCHeader("r", "Restore"),
+		common.FormatSCHeader("y", "Clone"),
+		common.FormatSCHeader("t", "Copy to Context"),
+		common.FormatSCHeader("shift-u", "Usage"),
 		common.FormatSCHeader("shift-p", "Prune"),
 		common.FormatSCHeader("ctrl-d", "Delete"),
 	}
@@ -49,12 +86,20 @@ func GetShortcuts() []string {
 
 func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	app := v.App
+	if _, _, ok := browsing(app); ok {
+		return filesInputHandler(v, event)
+	}
 	if event.Key() == tcell.KeyCtrlD {
 		DeleteAction(app, v)
 		return nil
 	}
 	switch event.Rune() {
 	case 's':
+		if name := selectedName(v); name != "" {
+			Browse(app, name, "/")
+		}
+		return nil
+	case 'S':
 		// Shell
 		id, err := v.GetSelectedID()
 		if err == nil {
@@ -67,6 +112,21 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	case 'a':
 		Create(app)
 		return nil
+	case 'b':
+		BackupAction(app, v)
+		return nil
+	case 'r':
+		RestoreAction(app, v)
+		return nil
+	case 'y':
+		CloneAction(app, v)
+		return nil
+	case 't':
+		CopyToContextAction(app, v)
+		return nil
+	case 'U':
+		UsageAction(app, v)
+		return nil
 	case 'P':
 		PruneAction(app)
 		return nil
@@ -77,23 +137,37 @@ func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
 	return event
 }
 
-func PruneAction(app common.AppController) {
-	dialogs.ShowConfirmation(app, "PRUNE", "Volumes", func(force bool) {
-		app.SetFlashPending("pruning volumes...")
-		app.RunInBackground(func() {
-			err := Prune(app)
-			app.GetTviewApp().QueueUpdateDraw(func() {
-				if err != nil {
-					app.SetFlashError(fmt.Sprintf("%v", err))
-				} else {
-					app.SetFlashSuccess("pruned volumes")
-					app.RefreshCurrentView()
-				}
-			})
+// UsageAction shows the biggest directories of the selected volume.
+func UsageAction(app common.AppController, v *view.ResourceView) {
+	name := selectedName(v)
+	if name == "" {
+		return
+	}
+
+	inspector := inspect.NewTextInspector("Usage", name, fmt.Sprintf(" [%s]Measuring directories...\n", styles.TagAccent), "text")
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		report, err := app.GetDocker().VolumeTopDirectories(name, app.GetConfig().D4S.ShellPod.Image)
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Content = report
+			inspector.Viewer.Update(report, "text")
 		})
 	})
 }
 
+// PruneAction previews the volumes a prune would remove and removes the
+// ones left selected.
+func PruneAction(app common.AppController) {
+	dialogs.ShowPrunePreview(app, "volumes", app.GetDocker().PruneVolumesCandidates, func(id string) error {
+		return app.GetDocker().RemoveVolume(id, false)
+	})
+}
+
 func DeleteAction(app common.AppController, v *view.ResourceView) {
 	ids, err := v.GetSelectedIDs()
 	if err != nil {
@@ -113,10 +187,6 @@ func DeleteAction(app common.AppController, v *view.ResourceView) {
 	})
 }
 
-func Prune(app common.AppController) error {
-	return app.GetDocker().PruneVolumes()
-}
-
 func Remove(id string, force bool, app common.AppController) error {
 	return app.GetDocker().RemoveVolume(id, force)
 }
@@ -142,6 +212,233 @@ func Create(app common.AppController) {
 	})
 }
 
+func selectedName(v *view.ResourceView) string {
+	row, _ := v.Table.GetSelection()
+	if row > 0 && row <= len(v.Data) {
+		return v.Data[row-1].GetID()
+	}
+	return ""
+}
+
+// BackupAction streams the selected volume into a local tar archive.
+func BackupAction(app common.AppController, v *view.ResourceView) {
+	name := selectedName(v)
+	if name == "" {
+		return
+	}
+
+	defaultPath := fmt.Sprintf("./%s.tar.gz", name)
+	fields := []dialogs.FormField{
+		{Name: "path", Label: "Path", Type: dialogs.FieldTypeInput, Default: defaultPath},
+		{Name: "gzip", Label: "Gzip", Type: dialogs.FieldTypeCheckbox, Default: "true"},
+	}
+	dialogs.ShowFormWithDescription(app, "Backup Volume", fmt.Sprintf("Archive the content of %s", name), fields, func(result dialogs.FormResult) {
+		compress := result["gzip"] == "true"
+		path := strings.TrimSpace(result["path"])
+		if path == defaultPath && !compress {
+			path = strings.TrimSuffix(path, ".gz")
+		}
+		path = daoCommon.ExpandHome(path)
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		dialogs.ConfirmOverwrite(app, path, func() {
+			backup(app, name, path, compress)
+		})
+	})
+}
+
+// backup streams the content of a volume into the archive at path.
+func backup(app common.AppController, name, path string, compress bool) {
+	app.SetFlashPending(fmt.Sprintf("backing up %s...", name))
+	app.RunInBackground(func() {
+		f, err := os.Create(path)
+		if err != nil {
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				app.AppendFlashError(fmt.Sprintf("failed to create archive: %v", err))
+			})
+			return
+		}
+
+		image := app.GetConfig().D4S.ShellPod.Image
+		backupErr := app.GetDocker().BackupVolume(name, image, f, compress, common.TransferProgress(app, "backing up "+name, 0))
+		closeErr := f.Close()
+		if backupErr == nil {
+			backupErr = closeErr
+		}
+		if backupErr != nil {
+			os.Remove(path)
+		}
+
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if backupErr != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to back up %s: %v", name, backupErr))
+				return
+			}
+			app.AppendFlashSuccess(fmt.Sprintf("%s backed up to %s", name, daoCommon.ShortenPath(path)), 10*time.Second)
+		})
+	})
+}
+
+// RestoreAction extracts a local tar archive into the selected volume, or
+// into a new one when another name is given.
+func RestoreAction(app common.AppController, v *view.ResourceView) {
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	fields := []dialogs.FormField{
+		{Name: "path", Label: "Archive", Type: dialogs.FieldTypeInput, Default: "./", Placeholder: ".tar or .tar.gz"},
+		{Name: "volume", Label: "Volume", Type: dialogs.FieldTypeInput, Default: selectedName(v), Placeholder: "created if missing"},
+		{Name: "empty", Label: "Empty target first", Type: dialogs.FieldTypeCheckbox, Default: "false"},
+	}
+	dialogs.ShowFormWithDescription(app, "Restore Volume", "Extract an archive into a new or existing volume", fields, func(result dialogs.FormResult) {
+		path := daoCommon.ExpandHome(strings.TrimSpace(result["path"]))
+		name := strings.TrimSpace(result["volume"])
+		if path == "" || name == "" {
+			app.AppendFlashError("archive and volume are required")
+			return
+		}
+		empty := result["empty"] == "true"
+
+		restore := func() {
+			f, err := os.Open(path)
+			if err != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to open archive: %v", err))
+				return
+			}
+
+			var total int64
+			if info, err := f.Stat(); err == nil {
+				total = info.Size()
+			}
+
+			app.SetFlashPending(fmt.Sprintf("restoring %s...", name))
+			app.RunInBackground(func() {
+				defer f.Close()
+				image := app.GetConfig().D4S.ShellPod.Image
+				err := app.GetDocker().RestoreVolume(name, image, f, empty, common.TransferProgress(app, "restoring "+name, total))
+
+				app.GetTviewApp().QueueUpdateDraw(func() {
+					if err != nil {
+						app.AppendFlashError(fmt.Sprintf("failed to restore %s: %v", name, err))
+						return
+					}
+					app.AppendFlashSuccess(fmt.Sprintf("restored %s into %s", filepath.Base(path), name), 10*time.Second)
+					app.ScheduleViewHighlight(styles.TitleVolumes, func(res dao.Resource) bool {
+						return res.GetID() == name
+					}, styles.ColorStatusGreen, styles.ColorBlack, 2*time.Second)
+					app.RefreshCurrentView()
+				})
+			})
+		}
+
+		if empty {
+			dialogs.ShowConfirmation(app, "EMPTY & RESTORE", name, func(_ bool) {
+				restore()
+			})
+			return
+		}
+		restore()
+	})
+}
+
+// CloneAction copies the selected volume into a new volume.
+func CloneAction(app common.AppController, v *view.ResourceView) {
+	source := selectedName(v)
+	if source == "" {
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	dialogs.ShowInput(app, "Clone Volume", "New name:", source+"-clone", func(text string) {
+		target := strings.TrimSpace(text)
+		if target == "" {
+			return
+		}
+
+		app.SetFlashPending(fmt.Sprintf("cloning %s into %s...", source, target))
+		app.RunInBackground(func() {
+			err := app.GetDocker().CloneVolume(source, target, app.GetConfig().D4S.ShellPod.Image)
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to clone %s: %v", source, err))
+					return
+				}
+				app.AppendFlashSuccess(fmt.Sprintf("cloned %s into %s", source, target))
+				app.ScheduleViewHighlight(styles.TitleVolumes, func(res dao.Resource) bool {
+					return res.GetID() == target
+				}, styles.ColorStatusGreen, styles.ColorBlack, 2*time.Second)
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+// CopyToContextAction streams the selected volume into a new volume of
+// the same name on another docker context, then checks both copies match.
+func CopyToContextAction(app common.AppController, v *view.ResourceView) {
+	name := selectedName(v)
+	if name == "" {
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	contexts, err := dao.ListContexts()
+	if err != nil {
+		app.AppendFlashError(fmt.Sprintf("failed to load docker contexts: %v", err))
+		return
+	}
+
+	current := app.GetDocker().ContextName
+	var items []dialogs.PickerItem
+	for _, ctx := range contexts {
+		if ctx.Name == current {
+			continue
+		}
+		description := ctx.DockerEndpoint
+		if description == "" {
+			description = ctx.Description
+		}
+		items = append(items, dialogs.PickerItem{
+			Label:       ctx.Name,
+			Description: description,
+			Value:       ctx.Name,
+		})
+	}
+	if len(items) == 0 {
+		app.AppendFlashError("no other docker context to copy to")
+		return
+	}
+
+	dialogs.ShowPicker(app, "Copy to Context: "+name, items, func(target string) {
+		app.SetFlashPending(fmt.Sprintf("copying %s to %s...", name, target))
+		app.RunInBackground(func() {
+			progress := common.TransferProgress(app, fmt.Sprintf("copying %s to %s", name, target), 0)
+			sum, err := app.GetDocker().CopyVolumeToContext(name, app.GetConfig().D4S.ShellPod.Image, target, progress)
+
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to copy %s to %s: %v", name, target, err))
+					return
+				}
+				app.AppendFlashSuccess(fmt.Sprintf("copied %s to %s, checksums match (%.12s)", name, target, sum), 10*time.Second)
+			})
+		})
+	})
+}
+
 func OpenAction(app common.AppController, v *view.ResourceView) {
 	row, _ := v.Table.GetSelection()
 	if row > 0 && row <= len(v.Data) {
@@ -215,10 +512,218 @@ func resolveHostPath(mount, volName string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("volume data lives inside the Docker VM (%s), use <s> shell instead", mount)
+	return "", fmt.Errorf("volume data lives inside the Docker VM (%s), use <shift-s> shell instead", mount)
+}
+
+func filesInputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+	if event.Key() == tcell.KeyCtrlD {
+		DeleteFilesAction(app, v)
+		return nil
+	}
+	if event.Key() == tcell.KeyEnter {
+		if f, ok := selectedFile(v); ok {
+			OpenFile(app, f)
+		}
+		return nil
+	}
+	switch event.Rune() {
+	case 'D':
+		DownloadAction(app, v)
+		return nil
+	case 'U':
+		UploadAction(app)
+		return nil
+	}
+	return event
+}
+
+func selectedFile(v *view.ResourceView) (dao.VolumeFile, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return dao.VolumeFile{}, false
+	}
+	f, ok := v.Data[row-1].(dao.VolumeFile)
+	return f, ok
+}
+
+// Browse lists a directory of a volume. Each directory is stacked on the
+// scope it was opened from, so Esc goes back up one directory at a time
+// and then back to the volume list.
+func Browse(app common.AppController, volume, dir string) {
+	value := volume + ":" + dir
+	parent := app.GetActiveScope()
+	if parent != nil && parent.Type == filesScope && parent.Value == value {
+		parent = parent.Parent
+	}
+	app.SetActiveScope(&common.Scope{
+		Type:       filesScope,
+		Value:      value,
+		Label:      value,
+		OriginView: styles.TitleVolumes,
+		Parent:     parent,
+	})
+	app.SwitchTo(styles.TitleVolumes)
+}
+
+// OpenFile enters a directory or shows a text file.
+func OpenFile(app common.AppController, f dao.VolumeFile) {
+	if f.IsDir() {
+		Browse(app, f.Volume, f.Path)
+		return
+	}
+	if f.Type != "file" {
+		app.AppendFlashError(fmt.Sprintf("%s is not a regular file", f.Name))
+		return
+	}
+
+	subject := f.Volume + ":" + f.Path
+	lang := strings.TrimPrefix(filepath.Ext(f.Name), ".")
+	if lang == "" {
+		lang = "plaintext"
+	}
+	inspector := inspect.NewTextInspector("View file", subject, fmt.Sprintf(" [%s]Loading file...\n", styles.TagAccent), lang)
+	app.OpenInspector(inspector)
+
+	app.RunInBackground(func() {
+		content, err := app.GetDocker().ReadVolumeFile(f.Volume, f.Path, app.GetConfig().D4S.ShellPod.Image, maxViewSize)
+		if err == nil && bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+			err = fmt.Errorf("%s is a binary file, download it instead", f.Name)
+		}
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				inspector.Viewer.Update(fmt.Sprintf("Error: %v", err), "text")
+				return
+			}
+			inspector.Content = string(content)
+			inspector.Viewer.Update(inspector.Content, lang)
+		})
+	})
+}
+
+// DownloadAction saves the selected file on this machine, or the selected
+// directory as a tar archive.
+func DownloadAction(app common.AppController, v *view.ResourceView) {
+	f, ok := selectedFile(v)
+	if !ok {
+		return
+	}
+
+	name := f.Name
+	if f.IsDir() {
+		name += ".tar"
+	}
+	dialogs.ShowInput(app, "Download", "Path:", "./"+name, func(text string) {
+		path := daoCommon.ExpandHome(strings.TrimSpace(text))
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		dialogs.ConfirmOverwrite(app, path, func() {
+			app.SetFlashPending(fmt.Sprintf("downloading %s...", f.Name))
+			app.RunInBackground(func() {
+				out, err := os.Create(path)
+				if err != nil {
+					app.GetTviewApp().QueueUpdateDraw(func() {
+						app.AppendFlashError(fmt.Sprintf("failed to create %s: %v", path, err))
+					})
+					return
+				}
+
+				total := f.Size
+				if f.IsDir() {
+					total = 0
+				}
+				image := app.GetConfig().D4S.ShellPod.Image
+				dlErr := app.GetDocker().DownloadVolumeFile(f.Volume, f.Path, image, out, common.TransferProgress(app, "downloading "+f.Name, total))
+				closeErr := out.Close()
+				if dlErr == nil {
+					dlErr = closeErr
+				}
+				if dlErr != nil {
+					os.Remove(path)
+				}
+
+				app.GetTviewApp().QueueUpdateDraw(func() {
+					if dlErr != nil {
+						app.AppendFlashError(fmt.Sprintf("failed to download %s: %v", f.Name, dlErr))
+						return
+					}
+					app.AppendFlashSuccess(fmt.Sprintf("%s downloaded to %s", f.Name, daoCommon.ShortenPath(path)), 10*time.Second)
+				})
+			})
+		})
+	})
+}
+
+// UploadAction copies a local file or directory into the directory shown.
+func UploadAction(app common.AppController) {
+	volume, dir, ok := browsing(app)
+	if !ok {
+		return
+	}
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+
+	dialogs.ShowInput(app, "Upload to "+volume+":"+dir, "Local path:", "./", func(text string) {
+		path := daoCommon.ExpandHome(strings.TrimSpace(text))
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		app.SetFlashPending(fmt.Sprintf("uploading %s...", filepath.Base(path)))
+		app.RunInBackground(func() {
+			err := app.GetDocker().UploadVolumeFile(volume, dir, app.GetConfig().D4S.ShellPod.Image, path)
+			app.GetTviewApp().QueueUpdateDraw(func() {
+				if err != nil {
+					app.AppendFlashError(fmt.Sprintf("failed to upload %s: %v", filepath.Base(path), err))
+					return
+				}
+				app.AppendFlashSuccess(fmt.Sprintf("uploaded %s to %s:%s", filepath.Base(path), volume, dir))
+				app.RefreshCurrentView()
+			})
+		})
+	})
+}
+
+// DeleteFilesAction removes the selected files and directories.
+func DeleteFilesAction(app common.AppController, v *view.ResourceView) {
+	volume, _, ok := browsing(app)
+	if !ok {
+		return
+	}
+	ids, err := v.GetSelectedIDs()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	label := volume + ":" + ids[0]
+	if len(ids) > 1 {
+		label = fmt.Sprintf("%d items", len(ids))
+	}
+
+	dialogs.ShowConfirmation(app, "DELETE", label, func(_ bool) {
+		image := app.GetConfig().D4S.ShellPod.Image
+		app.PerformAction(func(id string) error {
+			return app.GetDocker().DeleteVolumeFile(volume, id, image)
+		}, "deleting", styles.ColorStatusRed)
+	})
 }
 
 func Inspect(app common.AppController, id string) {
+	if volume, _, ok := browsing(app); ok {
+		OpenFile(app, dao.VolumeFile{Volume: volume, Path: id, Name: filepath.Base(id), Type: "file"})
+		return
+	}
+
 	subject := id
 	inspector := inspect.NewTextInspector("Describe volume", subject, fmt.Sprintf(" [%s]Loading volume...\n", styles.TagAccent), "json")
 	app.OpenInspector(inspector)
@@ -0,0 +1,136 @@
+package watches
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao"
+	"github.com/jr-k/d4s/internal/ui/common"
+	"github.com/jr-k/d4s/internal/ui/components/inspect"
+	"github.com/jr-k/d4s/internal/ui/components/view"
+	"github.com/jr-k/d4s/internal/watch"
+)
+
+var Headers = []string{"STATUS", "CONTEXT", "PROJECT", "STATE", "LAST OUTPUT", "AGE"}
+
+func Fetch(app common.AppController, v *view.ResourceView) ([]dao.Resource, error) {
+	all := app.GetWatchManager().List()
+
+	scope := app.GetActiveScope()
+	if scope == nil || scope.Type != "compose" {
+		return all, nil
+	}
+
+	var filtered []dao.Resource
+	for _, r := range all {
+		if s, ok := r.(watch.Session); ok && s.Project == scope.Value {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+func GetShortcuts() []string {
+	return []string{
+		common.FormatSCHeader("enter", "Output"),
+		common.FormatSCHeader("r", "Run/Stop"),
+		common.FormatSCHeader("ctrl-d", "Delete"),
+	}
+}
+
+func InputHandler(v *view.ResourceView, event *tcell.EventKey) *tcell.EventKey {
+	app := v.App
+
+	if event.Key() == tcell.KeyCtrlD {
+		RemoveAction(app, v)
+		return nil
+	}
+
+	if event.Key() == tcell.KeyEnter {
+		ShowOutput(app, v)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'r':
+		ToggleAction(app, v)
+		return nil
+	}
+
+	return event
+}
+
+func selected(v *view.ResourceView) (watch.Session, bool) {
+	row, _ := v.Table.GetSelection()
+	if row <= 0 || row > len(v.Data) {
+		return watch.Session{}, false
+	}
+	s, ok := v.Data[row-1].(watch.Session)
+	return s, ok
+}
+
+// ShowOutput opens the output of the selected watch, live if it runs.
+func ShowOutput(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+	app.OpenInspector(inspect.NewOutputInspector("Watch", s.Project, s.Run()))
+}
+
+func ToggleAction(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+
+	if s.Status() == watch.StatusRunning {
+		app.GetWatchManager().Stop(s.ID)
+		app.AppendFlashSuccess(fmt.Sprintf("stopped watch of %s", s.Project))
+		app.RefreshCurrentView()
+		return
+	}
+
+	if app.IsReadOnly() {
+		app.AppendFlashError("read-only mode: modifications are disabled")
+		return
+	}
+	if docker := app.GetDocker(); docker == nil || docker.ContextName != s.ContextName {
+		app.AppendFlashError(fmt.Sprintf("switch to context %s to restart this watch", s.ContextName))
+		return
+	}
+
+	Start(app, s.Project)
+}
+
+// Start runs `docker compose watch` for a project in the background and
+// follows its output.
+func Start(app common.AppController, project string) {
+	app.RunInBackground(func() {
+		var session *watch.Session
+		docker := app.GetDocker()
+		step, err := docker.ComposeWatchStep(project)
+		if err == nil {
+			session, err = app.GetWatchManager().Start(docker.ContextName, project, step)
+		}
+		app.GetTviewApp().QueueUpdateDraw(func() {
+			if err != nil {
+				app.AppendFlashError(fmt.Sprintf("failed to watch: %v", err))
+				return
+			}
+			app.AppendFlashSuccess(fmt.Sprintf("watching %s", project))
+			app.OpenInspector(inspect.NewOutputInspector("Watch", project, session.Run()))
+		})
+	})
+}
+
+func RemoveAction(app common.AppController, v *view.ResourceView) {
+	s, ok := selected(v)
+	if !ok {
+		return
+	}
+
+	app.GetWatchManager().Remove(s.ID)
+	app.AppendFlashSuccess(fmt.Sprintf("removed watch of %s", s.Project))
+	app.RefreshCurrentView()
+}
@@ -0,0 +1,232 @@
+package watch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/jr-k/d4s/internal/dao/common"
+	"github.com/jr-k/d4s/internal/ui/styles"
+)
+
+type Status int
+
+const (
+	StatusRunning Status = iota
+	StatusStopped
+	StatusFailed
+)
+
+// Session is a `docker compose watch` of a project running in the
+// background, for as long as d4s runs or until it is stopped.
+type Session struct {
+	ID          string
+	ContextName string
+	Project     string
+	CreatedAt   time.Time
+
+	run *common.CmdRun
+}
+
+func (s Session) GetID() string { return s.ID }
+
+// Run is the watch process, whose output stays readable once it exits.
+func (s Session) Run() *common.CmdRun { return s.run }
+
+func (s Session) Status() Status {
+	done, _, err := s.run.State()
+	switch {
+	case !done:
+		return StatusRunning
+	case err != nil && err != common.ErrCanceled:
+		return StatusFailed
+	}
+	return StatusStopped
+}
+
+// StatusText is "watching", "stopped" or the reason the watch exited.
+func (s Session) StatusText() string {
+	switch s.Status() {
+	case StatusRunning:
+		return "watching"
+	case StatusFailed:
+		_, _, err := s.run.State()
+		return fmt.Sprintf("failed: %v", err)
+	}
+	return "stopped"
+}
+
+// LastLine is the latest output of the watch (last sync, rebuild, error).
+func (s Session) LastLine() string {
+	return s.run.LastLine()
+}
+
+func (s Session) GetCells() []string {
+	status := "●"
+	if s.Status() != StatusRunning {
+		status = "○"
+	}
+	return []string{
+		status,
+		s.ContextName,
+		s.Project,
+		s.StatusText(),
+		s.LastLine(),
+		common.FormatAge(s.CreatedAt),
+	}
+}
+
+func (s Session) GetStatusColor() (tcell.Color, tcell.Color) {
+	switch s.Status() {
+	case StatusRunning:
+		return styles.ColorInfo, styles.ColorBlack
+	case StatusFailed:
+		return styles.ColorError, styles.ColorBlack
+	}
+	return styles.ColorStatusGray, styles.ColorBlack
+}
+
+func (s Session) GetColumnValue(column string) string {
+	switch strings.ToLower(column) {
+	case "status":
+		return s.StatusText()
+	case "context":
+		return s.ContextName
+	case "project":
+		return s.Project
+	case "last output":
+		return s.LastLine()
+	case "age":
+		return common.FormatAge(s.CreatedAt)
+	}
+	return ""
+}
+
+func (s Session) GetDefaultColumn() string     { return "project" }
+func (s Session) GetDefaultSortColumn() string { return "project" }
+
+var _ common.Resource = Session{}
+
+// SessionID identifies the watch of a project on a docker context.
+func SessionID(contextName, project string) string {
+	return contextName + "/" + project
+}
+
+type Manager struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Start runs a watch for a project. A stopped session of the same project
+// is replaced; a running one is an error.
+func (m *Manager) Start(contextName, project string, step common.CmdStep) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := SessionID(contextName, project)
+	if s, ok := m.sessions[id]; ok && s.Status() == StatusRunning {
+		return nil, fmt.Errorf("%s is already watched", project)
+	}
+
+	s := &Session{
+		ID:          id,
+		ContextName: contextName,
+		Project:     project,
+		CreatedAt:   time.Now(),
+		run:         common.StartCmdRun("Watch "+project, step),
+	}
+	m.sessions[id] = s
+	return s, nil
+}
+
+// Stop interrupts the watch process; the session stays listed with its
+// output.
+func (m *Manager) Stop(id string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.run.Cancel()
+	}
+}
+
+func (m *Manager) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.run.Cancel()
+		delete(m.sessions, id)
+	}
+}
+
+func (m *Manager) Get(id string) *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions[id]
+}
+
+func (m *Manager) List() []common.Resource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]common.Resource, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, *m.sessions[id])
+	}
+	return result
+}
+
+// shutdownGrace is how long a watch has to exit when d4s does.
+const shutdownGrace = 5 * time.Second
+
+// Shutdown stops every watch when d4s exits. Each watch gets a few seconds
+// to exit on its own before it is killed.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.sessions = make(map[string]*Session)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done := make(chan struct{})
+			go func() {
+				s.run.Wait()
+				close(done)
+			}()
+
+			s.run.Cancel()
+			timer := time.NewTimer(shutdownGrace)
+			defer timer.Stop()
+			select {
+			case <-done:
+			case <-timer.C:
+				// A second cancel kills the process
+				s.run.Cancel()
+			}
+		}()
+	}
+	wg.Wait()
+}